@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"github.com/vasch3nko/songlibrary/internal/app"
 	"log"
 	"os"
 	"os/signal"
+	"syscall"
 )
 
 func main() {
@@ -15,14 +17,36 @@ func main() {
 }
 
 func run() error {
-	// Context initialization
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	// Context initialization, cancelled on interrupt or termination
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	// Starting app
-	if err := app.Run(ctx); err != nil {
-		return err
+	// Restoring default handling after the first signal,
+	// a second one kills the process during graceful shutdown
+	go func() {
+		<-ctx.Done()
+		cancel()
+	}()
+
+	// Running the command, server by default
+	command := "serve"
+	var args []string
+	if len(os.Args) > 1 {
+		command, args = os.Args[1], os.Args[2:]
 	}
 
-	return nil
+	switch command {
+	case "serve":
+		return app.Run(ctx)
+	case "import":
+		return app.Import(args)
+	case "export":
+		return app.Export(args)
+	case "scan":
+		return app.Scan(args)
+	case "apikey":
+		return app.ApiKey(args)
+	default:
+		return fmt.Errorf("unknown command %q (serve, import, export, scan, apikey)", command)
+	}
 }
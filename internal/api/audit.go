@@ -0,0 +1,81 @@
+package api
+
+import (
+    "github.com/vasch3nko/songlibrary/internal/services"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "net/http"
+    "strconv"
+    "time"
+)
+
+type AuditHandler struct {
+    service services.AuditService
+    mux     *LoggingMux
+}
+
+func NewAuditHandler(service services.AuditService, mux *LoggingMux) *AuditHandler {
+    return &AuditHandler{
+        service: service,
+        mux:     mux,
+    }
+}
+
+// RegisterAuditRoutes registers audit routes,
+// the audit log is available to admins only
+func (a AuditHandler) RegisterAuditRoutes() {
+    a.mux.HandleFuncScope("GET /audit", types.ScopeAdmin, a.handleGetAuditEvents)
+}
+
+func (a AuditHandler) handleGetAuditEvents(w http.ResponseWriter, r *http.Request) error {
+    filter, err := parseAuditFilter(r)
+    if err != nil {
+        return err
+    }
+
+    page, limit, err := parsePagination(r)
+    if err != nil {
+        return err
+    }
+
+    events, err := a.service.GetAuditEvents(r.Context(), filter, page, limit)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusOK, events)
+}
+
+// parseAuditFilter parses song, actor, from and to query
+// params, times are in RFC 3339 format
+func parseAuditFilter(r *http.Request) (types.GetAuditEvents, error) {
+    var filter types.GetAuditEvents
+    query := r.URL.Query()
+
+    if query.Has("song") {
+        id, err := strconv.Atoi(query.Get("song"))
+        if err != nil {
+            return filter, NewHttpError(http.StatusBadRequest)
+        }
+        filter.SongId = &id
+    }
+    if query.Has("actor") {
+        actor := query.Get("actor")
+        filter.Actor = &actor
+    }
+    if query.Has("from") {
+        from, err := time.Parse(time.RFC3339, query.Get("from"))
+        if err != nil {
+            return filter, NewHttpError(http.StatusBadRequest)
+        }
+        filter.From = &from
+    }
+    if query.Has("to") {
+        to, err := time.Parse(time.RFC3339, query.Get("to"))
+        if err != nil {
+            return filter, NewHttpError(http.StatusBadRequest)
+        }
+        filter.To = &to
+    }
+
+    return filter, nil
+}
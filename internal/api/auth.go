@@ -0,0 +1,95 @@
+package api
+
+import (
+    "context"
+    "errors"
+    "github.com/vasch3nko/songlibrary/internal/reqctx"
+    "github.com/vasch3nko/songlibrary/internal/services"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "net/http"
+    "strings"
+)
+
+// Authenticator returns the principal of the bearer token,
+// services.ErrInvalidToken if the token is not accepted
+type Authenticator interface {
+    Authenticate(ctx context.Context, token string) (types.Principal, error)
+}
+
+// Authenticators try each authenticator in order until
+// one of them accepts the token (API keys, then JWTs)
+type Authenticators []Authenticator
+
+func (a Authenticators) Authenticate(ctx context.Context, token string) (types.Principal, error) {
+    err := services.ErrInvalidToken
+    for _, authenticator := range a {
+        var principal types.Principal
+        principal, err = authenticator.Authenticate(ctx, token)
+        if !errors.Is(err, services.ErrInvalidToken) {
+            return principal, err
+        }
+    }
+    return types.Principal{}, err
+}
+
+// Auth describes how requests are authenticated.
+// Nil Authenticator disables authentication.
+type Auth struct {
+    Authenticator Authenticator
+    // Read routes do not require a token, but a given one is checked
+    PublicReads bool
+}
+
+// methodScope returns scope that the route requires by
+// default: reading for GET and HEAD, writing for the rest
+func methodScope(pattern string) types.Scope {
+    method, _, _ := strings.Cut(pattern, " ")
+    switch method {
+    case http.MethodGet, http.MethodHead:
+        return types.ScopeRead
+    }
+    return types.ScopeWrite
+}
+
+// authenticate checks bearer token of the request against the
+// required scope and returns the request with its principal
+func (m *LoggingMux) authenticate(w http.ResponseWriter, r *http.Request, scope types.Scope) (*http.Request, error) {
+    if m.auth.Authenticator == nil {
+        return r, nil
+    }
+
+    token, ok := bearerToken(r)
+    if !ok {
+        if scope == types.ScopeRead && m.auth.PublicReads {
+            return r, nil
+        }
+        w.Header().Set("WWW-Authenticate", `Bearer realm="songlibrary"`)
+        return r, NewHttpError(http.StatusUnauthorized)
+    }
+
+    principal, err := m.auth.Authenticator.Authenticate(r.Context(), token)
+    if errors.Is(err, services.ErrInvalidToken) {
+        w.Header().Set("WWW-Authenticate", `Bearer realm="songlibrary", error="invalid_token"`)
+        return r, NewHttpError(http.StatusUnauthorized)
+    }
+    if err != nil {
+        return r, err
+    }
+
+    if !principal.Allows(scope) {
+        w.Header().Set("WWW-Authenticate", `Bearer realm="songlibrary", error="insufficient_scope", scope="`+string(scope)+`"`)
+        return r, NewHttpError(http.StatusForbidden)
+    }
+
+    return r.WithContext(reqctx.WithPrincipal(r.Context(), principal)), nil
+}
+
+// bearerToken returns token of the "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+    scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+    if !ok || !strings.EqualFold(scheme, "Bearer") {
+        return "", false
+    }
+    token = strings.TrimSpace(token)
+    return token, token != ""
+}
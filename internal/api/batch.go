@@ -0,0 +1,52 @@
+package api
+
+import (
+    "encoding/json"
+    "errors"
+    "github.com/vasch3nko/songlibrary/internal/services"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "net/http"
+    "strconv"
+)
+
+// Max size of the batch request body, enough
+// for SL_BATCH_MAX_SIZE songs with details
+const maxBatchBodySize = 16 << 20
+
+func (s SongHandler) handleCreateSongs(w http.ResponseWriter, r *http.Request) error {
+    onConflict, err := parseOnConflict(r)
+    if err != nil {
+        return err
+    }
+
+    // Batch is created song by song by default
+    atomic := false
+    if r.URL.Query().Has("atomic") {
+        atomic, err = strconv.ParseBool(r.URL.Query().Get("atomic"))
+        if err != nil {
+            return NewHttpError(http.StatusBadRequest)
+        }
+    }
+
+    // Decoding the request in slice of CreateSong structs
+    var req []types.CreateSong
+    body := http.MaxBytesReader(w, r.Body, maxBatchBodySize)
+    defer body.Close()
+    if err := json.NewDecoder(body).Decode(&req); err != nil {
+        var tooLarge *http.MaxBytesError
+        if errors.As(err, &tooLarge) {
+            return NewHttpError(http.StatusRequestEntityTooLarge)
+        }
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    results, err := s.service.CreateSongs(r.Context(), req, onConflict, atomic)
+    if err != nil {
+        if errors.Is(err, services.ErrBatchTooLarge) {
+            return NewHttpError(http.StatusRequestEntityTooLarge)
+        }
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusOK, results)
+}
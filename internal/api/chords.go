@@ -0,0 +1,82 @@
+package api
+
+import (
+    "encoding/json"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "net/http"
+    "strconv"
+)
+
+func (s SongHandler) handleGetChordSheet(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    query := r.URL.Query()
+    opts := types.ChordSheetOptions{Format: "chordpro"}
+
+    if query.Has("format") {
+        opts.Format = query.Get("format")
+    }
+
+    if query.Has("transpose") {
+        opts.Transpose, err = strconv.Atoi(query.Get("transpose"))
+        if err != nil || opts.Transpose < -11 || opts.Transpose > 11 {
+            return NewHttpError(http.StatusBadRequest)
+        }
+    }
+
+    switch query.Get("accidentals") {
+    case "", "sharps":
+    case "flats":
+        opts.Flats = true
+    default:
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    if query.Has("page") {
+        opts.Page, err = strconv.Atoi(query.Get("page"))
+        if err != nil || opts.Page < 1 {
+            return NewHttpError(http.StatusBadRequest)
+        }
+    }
+
+    doc, err := s.service.GetChordSheet(r.Context(), id, opts)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    switch opts.Format {
+    case "chordpro":
+        return WriteText(w, http.StatusOK, "application/x-chordpro", doc.String())
+    case "text":
+        return WriteText(w, http.StatusOK, "text/plain", doc.Text())
+    case "html":
+        return WriteText(w, http.StatusOK, "text/html", doc.HTML())
+    case "json":
+        return WriteJson(w, http.StatusOK, doc)
+    default:
+        return NewHttpError(http.StatusBadRequest)
+    }
+}
+
+func (s SongHandler) handleSetChordSheet(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    // Decoding the request in SetChordSheet struct
+    var req types.SetChordSheet
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+    defer r.Body.Close()
+
+    if err := s.service.SetChordSheet(r.Context(), id, req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusNoContent, struct{}{})
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+    "encoding/json"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "net/http"
+    "strconv"
+)
+
+func (s SongHandler) handleGetDuplicates(w http.ResponseWriter, r *http.Request) error {
+    groups, err := s.service.GetDuplicates(r.Context())
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    if groups == nil {
+        return WriteJson(w, http.StatusOK, []interface{}{})
+    }
+
+    return WriteJson(w, http.StatusOK, groups)
+}
+
+func (s SongHandler) handleMergeSongs(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    // Decoding the request in MergeSongs struct
+    var req types.MergeSongs
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+    defer r.Body.Close()
+
+    if err := s.service.MergeSongs(r.Context(), id, req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusNoContent, struct{}{})
+}
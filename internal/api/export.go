@@ -0,0 +1,40 @@
+package api
+
+import (
+    "github.com/vasch3nko/songlibrary/internal/songio"
+    "net/http"
+    "time"
+)
+
+// Longest export, the library is streamed
+// longer than the server write timeout
+const exportTimeout = 10 * time.Minute
+
+func (s SongHandler) handleExport(w http.ResponseWriter, r *http.Request) error {
+    filter, err := parseSongsFilter(r)
+    if err != nil {
+        return err
+    }
+
+    format := songio.FormatJSON
+    if r.URL.Query().Has("format") {
+        if format, err = songio.ParseFormat(r.URL.Query().Get("format")); err != nil {
+            return NewHttpError(http.StatusBadRequest)
+        }
+    }
+
+    ctx, cancel := extendDeadline(w, r, exportTimeout)
+    defer cancel()
+
+    w.Header().Set("Content-Type", songio.ContentType(format)+"; charset=utf-8")
+    w.Header().Set("Content-Disposition", `attachment; filename="songs.`+format+`"`)
+    w.WriteHeader(http.StatusOK)
+
+    if _, err := s.service.Export(ctx, w, format, filter); err != nil {
+        // Status is already sent, so the response
+        // is aborted to let the client see it is truncated
+        panic(http.ErrAbortHandler)
+    }
+
+    return nil
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+    "github.com/vasch3nko/songlibrary/internal/services"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "net/http"
+)
+
+type HealthHandler struct {
+    service *services.HealthService
+    mux     *http.ServeMux
+}
+
+// NewHealthHandler takes the plain ServeMux, since probes
+// are neither authenticated, rate limited nor logged
+func NewHealthHandler(service *services.HealthService, mux *http.ServeMux) *HealthHandler {
+    return &HealthHandler{
+        service: service,
+        mux:     mux,
+    }
+}
+
+func (h HealthHandler) RegisterHealthRoutes() {
+    h.mux.HandleFunc("GET /healthz", h.handleHealthz)
+    h.mux.HandleFunc("GET /readyz", h.handleReadyz)
+}
+
+// handleHealthz reports that the process is alive
+// and serving, dependencies are not checked
+func (h HealthHandler) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+    WriteJson(w, http.StatusOK, struct {
+        Status string `json:"status"`
+    }{Status: types.HealthUp})
+}
+
+// handleReadyz reports whether the service can handle
+// requests, 503 if a dependency is down or it is draining
+func (h HealthHandler) handleReadyz(w http.ResponseWriter, r *http.Request) {
+    readiness := h.service.Ready(r.Context())
+
+    status := http.StatusOK
+    if readiness.Status != types.HealthUp {
+        status = http.StatusServiceUnavailable
+    }
+    WriteJson(w, status, readiness)
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+    "context"
     "encoding/json"
     "net/http"
+    "time"
 )
 
 // WriteJson is the helper function that encodes
@@ -13,3 +15,49 @@ func WriteJson(w http.ResponseWriter, status int, v any) error {
     w.WriteHeader(status)
     return json.NewEncoder(w).Encode(v)
 }
+
+// WriteText is the helper function that writes
+// a plain text body with provided content type
+// and response status code. Returns the error.
+func WriteText(w http.ResponseWriter, status int, contentType string, s string) error {
+    w.Header().Add("Content-Type", contentType+"; charset=utf-8")
+    w.WriteHeader(status)
+    _, err := w.Write([]byte(s))
+    return err
+}
+
+// Problem is the problem details object (RFC 9457)
+type Problem struct {
+    Type   string `json:"type"`
+    Title  string `json:"title"`
+    Status int    `json:"status"`
+    Detail string `json:"detail,omitempty"`
+}
+
+// WriteProblem is the helper function that writes problem
+// details of the response status code with the detail
+// message as application/problem+json. Returns the error.
+func WriteProblem(w http.ResponseWriter, status int, detail string) error {
+    w.Header().Add("Content-Type", "application/problem+json; charset=utf-8")
+    w.WriteHeader(status)
+    return json.NewEncoder(w).Encode(Problem{
+        Type:   "about:blank",
+        Title:  http.StatusText(status),
+        Status: status,
+        Detail: detail,
+    })
+}
+
+// extendDeadline lets the long request of the route run past
+// the server read and write timeouts up to the timeout,
+// the returned context ends at the same deadline
+func extendDeadline(w http.ResponseWriter, r *http.Request, timeout time.Duration) (context.Context, context.CancelFunc) {
+    deadline := time.Now().Add(timeout)
+
+    // Writers without deadlines keep the server timeouts
+    rc := http.NewResponseController(w)
+    rc.SetReadDeadline(deadline)
+    rc.SetWriteDeadline(deadline)
+
+    return context.WithDeadline(r.Context(), deadline)
+}
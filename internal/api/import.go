@@ -0,0 +1,81 @@
+package api
+
+import (
+    "errors"
+    "github.com/vasch3nko/songlibrary/internal/songio"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "mime"
+    "net/http"
+    "strconv"
+    "time"
+)
+
+// Max size of the imported file and longest import,
+// files are larger and slower than other requests
+const (
+    maxImportBodySize = 64 << 20
+    importTimeout     = 10 * time.Minute
+)
+
+func (s SongHandler) handleImport(w http.ResponseWriter, r *http.Request) error {
+    opts, err := parseImportOptions(r)
+    if err != nil {
+        return err
+    }
+
+    ctx, cancel := extendDeadline(w, r, importTimeout)
+    defer cancel()
+
+    body := http.MaxBytesReader(w, r.Body, maxImportBodySize)
+    defer body.Close()
+
+    result, err := s.service.Import(ctx, body, opts)
+    if err != nil {
+        var tooLarge *http.MaxBytesError
+        if errors.As(err, &tooLarge) {
+            return NewHttpError(http.StatusRequestEntityTooLarge)
+        }
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusOK, result)
+}
+
+// parseImportOptions parses import options from query params,
+// format is taken from the content type if not set
+func parseImportOptions(r *http.Request) (types.ImportOptions, error) {
+    var opts types.ImportOptions
+    var err error
+
+    format := r.URL.Query().Get("format")
+    if format == "" {
+        mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+        switch mediaType {
+        case "application/json":
+            format = songio.FormatJSON
+        case "text/csv":
+            format = songio.FormatCSV
+        case "application/jsonl", "application/x-ndjson":
+            format = songio.FormatJSONL
+        }
+    }
+    if opts.Format, err = songio.ParseFormat(format); err != nil {
+        return opts, NewHttpError(http.StatusBadRequest)
+    }
+
+    if opts.Mapping, err = songio.ParseMapping(r.URL.Query().Get("map")); err != nil {
+        return opts, NewHttpError(http.StatusBadRequest)
+    }
+
+    if r.URL.Query().Has("enrich") {
+        if opts.Enrich, err = strconv.ParseBool(r.URL.Query().Get("enrich")); err != nil {
+            return opts, NewHttpError(http.StatusBadRequest)
+        }
+    }
+
+    if opts.OnConflict, err = parseOnConflict(r); err != nil {
+        return opts, err
+    }
+
+    return opts, nil
+}
@@ -2,6 +2,10 @@ package api
 
 import (
     "errors"
+    "github.com/vasch3nko/songlibrary/internal/metrics"
+    "github.com/vasch3nko/songlibrary/internal/reqctx"
+    "github.com/vasch3nko/songlibrary/internal/tracing"
+    "github.com/vasch3nko/songlibrary/internal/types"
     "log/slog"
     "net/http"
     "time"
@@ -9,28 +13,65 @@ import (
 
 type errorHandlerFunc func(http.ResponseWriter, *http.Request) error
 
-// LoggingMux is the wrapper over http.ServeMux that logs and handle errors
+// LoggingMux is the wrapper over http.ServeMux
+// that authenticates, logs, measures, traces and handle errors
 type LoggingMux struct {
-    mux *http.ServeMux
-    log *slog.Logger
+    mux     *http.ServeMux
+    auth    Auth
+    metrics httpMetrics
+    tracer  *tracing.Tracer
+    log     *slog.Logger
 }
 
-// NewLoggingMux is the constructor for LoggingMux that returns pointer
-func NewLoggingMux(logger *slog.Logger) *LoggingMux {
+// NewLoggingMux is the constructor for LoggingMux that returns
+// pointer, request metrics are registered in the registry and
+// every request is the span of the tracer (nil traces nothing)
+func NewLoggingMux(auth Auth, registry *metrics.Registry, tracer *tracing.Tracer, logger *slog.Logger) *LoggingMux {
     log := logger.With("component", "logging mux")
 
     return &LoggingMux{
-        mux: http.NewServeMux(),
-        log: log,
+        mux:     http.NewServeMux(),
+        auth:    auth,
+        metrics: newHttpMetrics(registry),
+        tracer:  tracer,
+        log:     log,
     }
 }
 
-// HandleFunc handles error, delegates handling pattern
-// to internal ServeMux and logs it
+// HandleFunc handles the pattern with the
+// scope of its method (see HandleFuncScope)
 func (m *LoggingMux) HandleFunc(pattern string, handlerFunc errorHandlerFunc) {
-    m.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+    m.HandleFuncScope(pattern, methodScope(pattern), handlerFunc)
+}
+
+// HandleFuncScope authenticates request against the scope,
+// handles error, delegates handling pattern to internal
+// ServeMux and logs it
+func (m *LoggingMux) HandleFuncScope(pattern string, scope types.Scope, handlerFunc errorHandlerFunc) {
+    m.mux.HandleFunc(pattern, func(rw http.ResponseWriter, r *http.Request) {
         // Starting request logging
         start := time.Now()
+
+        // Observing the request with the status written by the end
+        w := &statusRecorder{ResponseWriter: rw}
+        defer func() { m.metrics.observe(pattern, r, w.Status(), start) }()
+
+        // Tracing the request as the child of the caller's span
+        ctx, span := m.tracer.Start(tracing.Extract(r.Context(), r.Header), pattern, tracing.KindServer,
+            tracing.String("http.request.method", r.Method),
+            tracing.String("http.route", routeOf(pattern)),
+            tracing.String("url.path", r.URL.Path),
+        )
+        r = r.WithContext(ctx)
+        defer func() {
+            status := w.Status()
+            span.SetAttributes(tracing.Int("http.response.status_code", status))
+            if status >= http.StatusInternalServerError {
+                span.SetError(http.StatusText(status))
+            }
+            span.End()
+        }()
+
         entry := m.log.With(
             slog.String("method", r.Method),
             slog.String("path", r.URL.Path),
@@ -38,11 +79,17 @@ func (m *LoggingMux) HandleFunc(pattern string, handlerFunc errorHandlerFunc) {
             slog.String("user_agent", r.UserAgent()),
         )
 
-        err := handlerFunc(w, r)
+        // Services attribute changes to the origin of the request
+        r = r.WithContext(reqctx.WithRemoteAddr(r.Context(), r.RemoteAddr))
+
+        r, err := m.authenticate(w, r, scope)
+        if err == nil {
+            err = handlerFunc(w, r)
+        }
         if err == nil {
             // Completing request logging
             duration := time.Since(start)
-            entry.Info(
+            entry.InfoContext(r.Context(),
                 "Request completed",
                 slog.Duration("duration", duration),
             )
@@ -50,7 +97,7 @@ func (m *LoggingMux) HandleFunc(pattern string, handlerFunc errorHandlerFunc) {
         }
 
         // Logging error
-        m.log.Error(
+        entry.ErrorContext(r.Context(),
             "Request error",
             slog.Any("error", err),
         )
@@ -60,7 +107,7 @@ func (m *LoggingMux) HandleFunc(pattern string, handlerFunc errorHandlerFunc) {
         if errors.As(err, &httpErr) {
             // Writing json response with status and message from httpError
             if err := WriteJson(w, httpErr.StatusCode, struct{}{}); err != nil {
-                m.log.Error("Failed to write response")
+                m.log.ErrorContext(r.Context(), "Failed to write response")
                 return
             }
             return
@@ -68,7 +115,7 @@ func (m *LoggingMux) HandleFunc(pattern string, handlerFunc errorHandlerFunc) {
 
         // Else responding internal server error
         if err := WriteJson(w, http.StatusInternalServerError, struct{}{}); err != nil {
-            m.log.Error("Failed to write response")
+            m.log.ErrorContext(r.Context(), "Failed to write response")
             return
         }
     })
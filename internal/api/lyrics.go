@@ -0,0 +1,100 @@
+package api
+
+import (
+    "encoding/json"
+    "github.com/vasch3nko/songlibrary/internal/lrc"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "net/http"
+    "strconv"
+)
+
+func (s SongHandler) handleGetSyncedLyrics(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    // Without playback position responding all lines
+    if !r.URL.Query().Has("t") {
+        lyrics, err := s.service.GetSyncedLyrics(r.Context(), id)
+        if err != nil {
+            return NewHttpError(http.StatusBadRequest)
+        }
+
+        return WriteJson(w, http.StatusOK, lyrics)
+    }
+
+    t, err := lrc.ParseOffset(r.URL.Query().Get("t"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    i, lyrics, err := s.service.GetSyncedLyricsLine(r.Context(), id, t)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    resp := map[string]interface{}{
+        "id":    id,
+        "t":     lrc.Timestamp(t),
+        "index": i,
+        "line":  nil,
+        "next":  nil,
+    }
+    if i >= 0 {
+        resp["line"] = lyrics.Lines[i]
+    }
+    if i+1 < len(lyrics.Lines) {
+        resp["next"] = lyrics.Lines[i+1]
+    }
+
+    return WriteJson(w, http.StatusOK, resp)
+}
+
+func (s SongHandler) handleSetSyncedLyrics(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    // Decoding the request in SetSyncedLyrics struct
+    var req types.SetSyncedLyrics
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+    defer r.Body.Close()
+
+    if err := s.service.SetSyncedLyrics(r.Context(), id, req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusNoContent, struct{}{})
+}
+
+func (s SongHandler) handleGetSyncedLyricsFile(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    lyrics, err := s.service.GetSyncedLyrics(r.Context(), id)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteText(w, http.StatusOK, "application/x-lrc", lyrics.String())
+}
+
+func (s SongHandler) handleGetLyricsSkeleton(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    skeleton, err := s.service.GetLyricsSkeleton(r.Context(), id)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteText(w, http.StatusOK, "application/x-lrc", skeleton)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+    "github.com/vasch3nko/songlibrary/internal/metrics"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// httpMetrics count and time the requests by method,
+// route pattern (not the path, to bound the series) and status
+type httpMetrics struct {
+    requests *metrics.CounterVec
+    duration *metrics.HistogramVec
+}
+
+func newHttpMetrics(registry *metrics.Registry) httpMetrics {
+    return httpMetrics{
+        requests: registry.Counter("songlibrary_http_requests_total",
+            "HTTP requests by method, route and status.",
+            "method", "route", "status"),
+        duration: registry.Histogram("songlibrary_http_request_duration_seconds",
+            "Duration of the HTTP requests by method, route and status.",
+            metrics.DefaultBuckets, "method", "route", "status"),
+    }
+}
+
+// observe records the request of the pattern started at start
+func (m httpMetrics) observe(pattern string, r *http.Request, status int, start time.Time) {
+    route := routeOf(pattern)
+    code := strconv.Itoa(status)
+    m.requests.Inc(r.Method, route, code)
+    m.duration.Observe(time.Since(start).Seconds(), r.Method, route, code)
+}
+
+// routeOf returns the path of the pattern without the method
+func routeOf(pattern string) string {
+    if _, route, ok := strings.Cut(pattern, " "); ok {
+        return route
+    }
+    return pattern
+}
+
+// statusRecorder remembers the status code written to the response
+type statusRecorder struct {
+    http.ResponseWriter
+    status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+    if r.status == 0 {
+        r.status = status
+    }
+    r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+    if r.status == 0 {
+        r.status = http.StatusOK
+    }
+    return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+    return r.ResponseWriter
+}
+
+// Status returns the written status, 200 if nothing is written
+func (r *statusRecorder) Status() int {
+    if r.status == 0 {
+        return http.StatusOK
+    }
+    return r.status
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "math"
+    "net/http"
+    "strconv"
+)
+
+// parseSongsFilter fills GetSongs filter from query params
+func parseSongsFilter(r *http.Request) (types.GetSongs, error) {
+    var req types.GetSongs
+    ptrByParam := map[string]interface{}{
+        "id":           &req.Id,
+        "song":         &req.Song,
+        "group":        &req.Group,
+        "text":         &req.Text,
+        "link":         &req.Link,
+        "release_date": &req.ReleaseDate,
+        "language":     &req.Language,
+        "explicit":     &req.Explicit,
+    }
+
+    for param, ptr := range ptrByParam {
+        if !r.URL.Query().Has(param) {
+            continue
+        }
+        value := r.URL.Query().Get(param)
+
+        switch field := ptr.(type) {
+        case **int:
+            n, err := strconv.Atoi(value)
+            if err != nil {
+                return types.GetSongs{}, NewHttpError(http.StatusBadRequest)
+            }
+            *field = &n
+        case **string:
+            *field = &value
+        case **bool:
+            b, err := strconv.ParseBool(value)
+            if err != nil {
+                return types.GetSongs{}, NewHttpError(http.StatusBadRequest)
+            }
+            *field = &b
+        case **types.Date:
+            var date types.Date
+            if err := date.Scan(value); err != nil {
+                return types.GetSongs{}, NewHttpError(http.StatusBadRequest)
+            }
+            *field = &date
+        default:
+            return types.GetSongs{}, NewHttpError(http.StatusBadRequest)
+        }
+    }
+
+    // Every tag param must match, tags separated by "|" are alternatives
+    for _, param := range r.URL.Query()["tag"] {
+        tags, err := types.ParseTagGroup(param)
+        if err != nil {
+            return types.GetSongs{}, NewHttpError(http.StatusBadRequest)
+        }
+        req.Tags = append(req.Tags, tags)
+    }
+
+    return req, nil
+}
+
+// parsePagination parses and validates page and limit query params
+func parsePagination(r *http.Request) (int, int, error) {
+    page, err := strconv.Atoi(r.URL.Query().Get("page"))
+    if err != nil {
+        return 0, 0, NewHttpError(http.StatusBadRequest)
+    }
+
+    // Validating the page parameter
+    if page < 1 || page > math.MaxInt32 {
+        return 0, 0, NewHttpError(http.StatusBadRequest)
+    }
+
+    limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+    if err != nil {
+        return 0, 0, NewHttpError(http.StatusBadRequest)
+    }
+
+    // Validating the limit parameter
+    if limit < 1 || limit > math.MaxInt32 {
+        return 0, 0, NewHttpError(http.StatusBadRequest)
+    }
+
+    return page, limit, nil
+}
+
+// parseOnConflict parses on_conflict query param,
+// conflicts are errors by default
+func parseOnConflict(r *http.Request) (types.OnConflict, error) {
+    if !r.URL.Query().Has("on_conflict") {
+        return types.OnConflictError, nil
+    }
+
+    onConflict := types.OnConflict(r.URL.Query().Get("on_conflict"))
+    switch onConflict {
+    case types.OnConflictError, types.OnConflictIgnore, types.OnConflictUpdate:
+        return onConflict, nil
+    }
+    return "", NewHttpError(http.StatusBadRequest)
+}
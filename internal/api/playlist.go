@@ -0,0 +1,203 @@
+package api
+
+import (
+    "encoding/json"
+    "github.com/vasch3nko/songlibrary/internal/services"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "net/http"
+    "strconv"
+)
+
+type PlaylistHandler struct {
+    service services.PlaylistService
+    mux     *LoggingMux
+}
+
+func NewPlaylistHandler(service services.PlaylistService, mux *LoggingMux) *PlaylistHandler {
+    return &PlaylistHandler{
+        service: service,
+        mux:     mux,
+    }
+}
+
+func (p PlaylistHandler) RegisterPlaylistRoutes() {
+    p.mux.HandleFunc("GET /playlists", p.handleGetPlaylists)
+    p.mux.HandleFunc("POST /playlists", p.handleCreatePlaylist)
+    p.mux.HandleFunc("POST /playlists/import", p.handleImport)
+    p.mux.HandleFunc("GET /playlists/{id}", p.handleGetPlaylist)
+    p.mux.HandleFunc("PATCH /playlists/{id}", p.handleUpdatePlaylist)
+    p.mux.HandleFunc("DELETE /playlists/{id}", p.handleDeletePlaylist)
+    p.mux.HandleFunc("GET /playlists/{id}/export", p.handleExport)
+
+    p.mux.HandleFunc("POST /playlists/{id}/entries", p.handleAddEntries)
+    p.mux.HandleFunc("PUT /playlists/{id}/entries/order", p.handleReorder)
+    p.mux.HandleFunc("PATCH /playlists/{id}/entries/{entryId}", p.handleMoveEntry)
+    p.mux.HandleFunc("DELETE /playlists/{id}/entries/{entryId}", p.handleRemoveEntry)
+}
+
+func (p PlaylistHandler) handleGetPlaylists(w http.ResponseWriter, r *http.Request) error {
+    page, limit, err := parsePagination(r)
+    if err != nil {
+        return err
+    }
+
+    playlists, err := p.service.GetPlaylists(r.Context(), page, limit)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    if playlists == nil {
+        return WriteJson(w, http.StatusOK, []interface{}{})
+    }
+
+    return WriteJson(w, http.StatusOK, playlists)
+}
+
+func (p PlaylistHandler) handleGetPlaylist(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    playlist, err := p.service.GetPlaylist(r.Context(), id)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusOK, playlist)
+}
+
+func (p PlaylistHandler) handleCreatePlaylist(w http.ResponseWriter, r *http.Request) error {
+    // Decoding the request in CreatePlaylist struct
+    var req types.CreatePlaylist
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+    defer r.Body.Close()
+
+    id, err := p.service.CreatePlaylist(r.Context(), req)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusCreated, map[string]int{"id": id})
+}
+
+func (p PlaylistHandler) handleUpdatePlaylist(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    // Decoding the request in UpdatePlaylist struct
+    var req types.UpdatePlaylist
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+    defer r.Body.Close()
+
+    if err := p.service.UpdatePlaylist(r.Context(), id, req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusNoContent, struct{}{})
+}
+
+func (p PlaylistHandler) handleDeletePlaylist(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    if err := p.service.DeletePlaylist(r.Context(), id); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusNoContent, struct{}{})
+}
+
+func (p PlaylistHandler) handleAddEntries(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    // Decoding the request in AddPlaylistEntries struct
+    var req types.AddPlaylistEntries
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+    defer r.Body.Close()
+
+    if err := p.service.AddEntries(r.Context(), id, req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusNoContent, struct{}{})
+}
+
+func (p PlaylistHandler) handleRemoveEntry(w http.ResponseWriter, r *http.Request) error {
+    id, entryId, err := parseEntryPath(r)
+    if err != nil {
+        return err
+    }
+
+    if err := p.service.RemoveEntry(r.Context(), id, entryId); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusNoContent, struct{}{})
+}
+
+func (p PlaylistHandler) handleMoveEntry(w http.ResponseWriter, r *http.Request) error {
+    id, entryId, err := parseEntryPath(r)
+    if err != nil {
+        return err
+    }
+
+    // Decoding the request in MovePlaylistEntry struct
+    var req types.MovePlaylistEntry
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+    defer r.Body.Close()
+
+    if err := p.service.MoveEntry(r.Context(), id, entryId, req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusNoContent, struct{}{})
+}
+
+func (p PlaylistHandler) handleReorder(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    // Decoding the request in ReorderPlaylist struct
+    var req types.ReorderPlaylist
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+    defer r.Body.Close()
+
+    if err := p.service.Reorder(r.Context(), id, req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusNoContent, struct{}{})
+}
+
+// parseEntryPath parses playlist and entry ids of the path
+func parseEntryPath(r *http.Request) (int, int, error) {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return 0, 0, NewHttpError(http.StatusBadRequest)
+    }
+    entryId, err := strconv.Atoi(r.PathValue("entryId"))
+    if err != nil {
+        return 0, 0, NewHttpError(http.StatusBadRequest)
+    }
+    return id, entryId, nil
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+    "bytes"
+    "github.com/vasch3nko/songlibrary/internal/playlistio"
+    "mime"
+    "net/http"
+    "strconv"
+)
+
+func (p PlaylistHandler) handleExport(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    format := playlistio.FormatM3U8
+    if r.URL.Query().Has("format") {
+        if format, err = playlistio.ParseFormat(r.URL.Query().Get("format")); err != nil {
+            return NewHttpError(http.StatusBadRequest)
+        }
+    }
+
+    // Playlists are small, so the file is buffered
+    // to respond an error instead of a truncated file
+    var buf bytes.Buffer
+    if err := p.service.Export(r.Context(), &buf, id, format); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    w.Header().Set("Content-Type", playlistio.ContentType(format)+"; charset=utf-8")
+    w.Header().Set("Content-Disposition", `attachment; filename="playlist-`+strconv.Itoa(id)+`.`+format+`"`)
+    w.WriteHeader(http.StatusOK)
+    _, err = buf.WriteTo(w)
+
+    return err
+}
+
+func (p PlaylistHandler) handleImport(w http.ResponseWriter, r *http.Request) error {
+    // Format is taken from the content type if not set
+    format := r.URL.Query().Get("format")
+    if format == "" {
+        mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+        switch mediaType {
+        case "audio/x-mpegurl", "audio/mpegurl", "application/vnd.apple.mpegurl":
+            format = playlistio.FormatM3U8
+        case "application/xspf+xml":
+            format = playlistio.FormatXSPF
+        case "audio/x-scpls":
+            format = playlistio.FormatPLS
+        }
+    }
+    format, err := playlistio.ParseFormat(format)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+    defer r.Body.Close()
+
+    result, err := p.service.Import(r.Context(), r.Body, format, r.URL.Query().Get("name"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusCreated, result)
+}
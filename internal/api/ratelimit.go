@@ -0,0 +1,140 @@
+package api
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/ratelimit"
+    "log/slog"
+    "math"
+    "net"
+    "net/http"
+    "net/netip"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// RateLimits are the limits of read (GET and HEAD) and write
+// requests of every client. Nil limiter does not limit requests.
+type RateLimits struct {
+    Read  *ratelimit.Limiter
+    Write *ratelimit.Limiter
+    // Proxies whose X-Forwarded-For header tells the client address
+    TrustedProxies []netip.Prefix
+}
+
+// RateLimiter is the middleware that limits requests
+// of the clients before they reach the handler
+type RateLimiter struct {
+    limits RateLimits
+    next   http.Handler
+    log    *slog.Logger
+}
+
+// NewRateLimiter is the constructor for RateLimiter that returns pointer
+func NewRateLimiter(limits RateLimits, next http.Handler, logger *slog.Logger) *RateLimiter {
+    log := logger.With("component", "rate limiter")
+
+    return &RateLimiter{
+        limits: limits,
+        next:   next,
+        log:    log,
+    }
+}
+
+// ServeHTTP takes a token of the client bucket, sets RateLimit-*
+// headers and responds 429 with Retry-After if the bucket is empty
+func (l *RateLimiter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    limiter, kind := l.limits.Write, "write"
+    if r.Method == http.MethodGet || r.Method == http.MethodHead {
+        limiter, kind = l.limits.Read, "read"
+    }
+    if limiter == nil {
+        l.next.ServeHTTP(w, r)
+        return
+    }
+
+    key := l.clientKey(r)
+    result := limiter.Allow(kind + ":" + key)
+
+    h := w.Header()
+    h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+    h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+    h.Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+
+    if !result.Allowed {
+        retryAfter := seconds(result.RetryAfter)
+        h.Set("Retry-After", strconv.Itoa(retryAfter))
+
+        l.log.WarnContext(r.Context(), "Rate limit exceeded",
+            slog.String("client", key),
+            slog.String("kind", kind),
+            slog.String("method", r.Method),
+            slog.String("path", r.URL.Path),
+        )
+
+        detail := fmt.Sprintf("%s rate limit exceeded, retry in %d seconds", kind, retryAfter)
+        if err := WriteProblem(w, http.StatusTooManyRequests, detail); err != nil {
+            l.log.ErrorContext(r.Context(), "Failed to write response")
+        }
+        return
+    }
+
+    l.next.ServeHTTP(w, r)
+}
+
+// clientKey returns the key of the client bucket: hash of the
+// bearer token if the request has one, otherwise client address
+func (l *RateLimiter) clientKey(r *http.Request) string {
+    if token, ok := bearerToken(r); ok {
+        // Tokens are secrets, only their hashes are kept
+        sum := sha256.Sum256([]byte(token))
+        return "token:" + hex.EncodeToString(sum[:8])
+    }
+    return "ip:" + clientIP(r, l.limits.TrustedProxies)
+}
+
+// clientIP returns address of the client. Behind trusted
+// proxies it is the last X-Forwarded-For address that
+// is not a trusted proxy itself.
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+    host, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+        host = r.RemoteAddr
+    }
+
+    addr, err := netip.ParseAddr(host)
+    if err != nil || !isTrusted(addr, trusted) {
+        return host
+    }
+
+    forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+    for i := len(forwarded) - 1; i >= 0; i-- {
+        hop, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+        if err != nil {
+            // Addresses before the malformed one cannot be trusted
+            break
+        }
+        addr = hop.Unmap()
+        if !isTrusted(addr, trusted) {
+            break
+        }
+    }
+    return addr.String()
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+    addr = addr.Unmap()
+    for _, prefix := range trusted {
+        if prefix.Contains(addr) {
+            return true
+        }
+    }
+    return false
+}
+
+// seconds rounds the duration up to whole seconds
+func seconds(d time.Duration) int {
+    return int(math.Ceil(d.Seconds()))
+}
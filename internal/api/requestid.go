@@ -0,0 +1,58 @@
+package api
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "github.com/vasch3nko/songlibrary/internal/reqctx"
+    "net/http"
+)
+
+// Longest request id accepted from the client
+const maxRequestIdLen = 128
+
+// RequestIdHandler is the middleware that gives every request an
+// id: X-Request-ID of the client if it is valid, otherwise a new
+// one. The id is kept in the request context and echoed in the
+// response, so log entries of the request can be correlated.
+type RequestIdHandler struct {
+    next http.Handler
+}
+
+// NewRequestIdHandler is the constructor for RequestIdHandler that returns pointer
+func NewRequestIdHandler(next http.Handler) *RequestIdHandler {
+    return &RequestIdHandler{next: next}
+}
+
+func (h *RequestIdHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    id := r.Header.Get("X-Request-ID")
+    if !validRequestId(id) {
+        id = newRequestId()
+    }
+
+    w.Header().Set("X-Request-ID", id)
+    h.next.ServeHTTP(w, r.WithContext(reqctx.WithRequestId(r.Context(), id)))
+}
+
+// validRequestId reports whether the id is not empty, not too
+// long and has only letters, digits and "-", "_", ".", ":"
+func validRequestId(id string) bool {
+    if id == "" || len(id) > maxRequestIdLen {
+        return false
+    }
+    for _, c := range id {
+        switch {
+        case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+        case c == '-', c == '_', c == '.', c == ':':
+        default:
+            return false
+        }
+    }
+    return true
+}
+
+// newRequestId returns 16 random bytes in hex
+func newRequestId() string {
+    b := make([]byte, 16)
+    rand.Read(b)
+    return hex.EncodeToString(b)
+}
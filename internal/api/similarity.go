@@ -0,0 +1,58 @@
+package api
+
+import (
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "net/http"
+    "strconv"
+)
+
+func (s SongHandler) handleGetSimilarSongs(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    query := r.URL.Query()
+
+    n := 10
+    if query.Has("n") {
+        n, err = strconv.Atoi(query.Get("n"))
+        if err != nil || n < 1 || n > 100 {
+            return NewHttpError(http.StatusBadRequest)
+        }
+    }
+
+    var filter types.SimilarSongsFilter
+    if query.Has("group") {
+        group := query.Get("group")
+        filter.Group = &group
+    }
+    if query.Has("language") {
+        language := query.Get("language")
+        filter.Language = &language
+    }
+    for param, ptr := range map[string]**types.Date{
+        "release_from": &filter.ReleaseFrom,
+        "release_to":   &filter.ReleaseTo,
+    } {
+        if !query.Has(param) {
+            continue
+        }
+        var date types.Date
+        if err := date.Scan(query.Get(param)); err != nil {
+            return NewHttpError(http.StatusBadRequest)
+        }
+        *ptr = &date
+    }
+
+    songs, err := s.service.GetSimilarSongs(r.Context(), id, n, filter)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    if len(songs) == 0 {
+        return WriteJson(w, http.StatusOK, []interface{}{})
+    }
+
+    return WriteJson(w, http.StatusOK, songs)
+}
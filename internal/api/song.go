@@ -2,9 +2,10 @@ package api
 
 import (
     "encoding/json"
+    "errors"
     "github.com/vasch3nko/songlibrary/internal/services"
+    "github.com/vasch3nko/songlibrary/internal/storage"
     "github.com/vasch3nko/songlibrary/internal/types"
-    "math"
     "net/http"
     "strconv"
 )
@@ -21,73 +22,50 @@ func NewSongHandler(service services.SongService, mux *LoggingMux) *SongHandler
     }
 }
 
+// RegisterSongRoutes registers song routes. Viewers (read scope)
+// get songs, editors (write scope) change them, and only admins
+// import, merge and delete songs.
 func (s SongHandler) RegisterSongRoutes() {
     s.mux.HandleFunc("GET /songs", s.handleGetSongs)
+    s.mux.HandleFunc("GET /songs/export", s.handleExport)
+    s.mux.HandleFunc("GET /songs/duplicates", s.handleGetDuplicates)
     s.mux.HandleFunc("GET /songs/{id}", s.handleGetSongText)
     s.mux.HandleFunc("POST /songs", s.handleCreateSong)
+    s.mux.HandleFunc("POST /songs:batch", s.handleCreateSongs)
+    s.mux.HandleFuncScope("POST /imports", types.ScopeAdmin, s.handleImport)
     s.mux.HandleFunc("PATCH /songs/{id}", s.handleUpdateSong)
-    s.mux.HandleFunc("DELETE /songs/{id}", s.handleDeleteSong)
+    s.mux.HandleFuncScope("DELETE /songs/{id}", types.ScopeAdmin, s.handleDeleteSong)
+    s.mux.HandleFunc("POST /songs/{id}/enrich", s.handleEnrichSong)
+    s.mux.HandleFunc("PUT /songs/{id}/explicit", s.handleSetExplicitOverride)
+    s.mux.HandleFunc("GET /songs/{id}/similar", s.handleGetSimilarSongs)
+    s.mux.HandleFuncScope("POST /songs/{id}/merge", types.ScopeAdmin, s.handleMergeSongs)
+
+    s.mux.HandleFunc("GET /songs/{id}/lyrics", s.handleGetSyncedLyrics)
+    s.mux.HandleFunc("PUT /songs/{id}/lyrics", s.handleSetSyncedLyrics)
+    s.mux.HandleFunc("GET /songs/{id}/lyrics.lrc", s.handleGetSyncedLyricsFile)
+    s.mux.HandleFunc("GET /songs/{id}/lyrics/skeleton", s.handleGetLyricsSkeleton)
+
+    s.mux.HandleFunc("GET /songs/{id}/chords", s.handleGetChordSheet)
+    s.mux.HandleFunc("PUT /songs/{id}/chords", s.handleSetChordSheet)
+
+    s.mux.HandleFunc("GET /songs/{id}/translations", s.handleGetSongTranslations)
+    s.mux.HandleFunc("GET /songs/{id}/translations/{lang}", s.handleGetSongTranslation)
+    s.mux.HandleFunc("PUT /songs/{id}/translations/{lang}", s.handleSetSongTranslation)
+    s.mux.HandleFunc("DELETE /songs/{id}/translations/{lang}", s.handleDeleteSongTranslation)
 }
 
 func (s SongHandler) handleGetSongs(w http.ResponseWriter, r *http.Request) error {
-    var req types.GetSongs
-    ptrByParam := map[string]interface{}{
-        "id":           &req.Id,
-        "song":         &req.Song,
-        "group":        &req.Group,
-        "text":         &req.Text,
-        "link":         &req.Link,
-        "release_date": &req.ReleaseDate,
-    }
-
-    // Filling get songs request struct from query params
-    for param, ptr := range ptrByParam {
-        if !r.URL.Query().Has(param) {
-            continue
-        }
-        value := r.URL.Query().Get(param)
-
-        switch field := ptr.(type) {
-        case **int:
-            n, err := strconv.Atoi(value)
-            if err != nil {
-                return NewHttpError(http.StatusBadRequest)
-            }
-            *field = &n
-        case **string:
-            *field = &value
-        case **types.Date:
-            var date types.Date
-            if err := date.Scan(value); err != nil {
-                return NewHttpError(http.StatusBadRequest)
-            }
-            *field = &date
-        default:
-            return NewHttpError(http.StatusBadRequest)
-        }
-    }
-
-    page, err := strconv.Atoi(r.URL.Query().Get("page"))
+    req, err := parseSongsFilter(r)
     if err != nil {
-        return NewHttpError(http.StatusBadRequest)
+        return err
     }
 
-    // Validating the page parameter
-    if page < 1 || page > math.MaxInt32 {
-        return NewHttpError(http.StatusBadRequest)
-    }
-
-    limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+    page, limit, err := parsePagination(r)
     if err != nil {
-        return NewHttpError(http.StatusBadRequest)
+        return err
     }
 
-    // Validating the limit parameter
-    if limit < 1 || limit > math.MaxInt32 {
-        return NewHttpError(http.StatusBadRequest)
-    }
-
-    songs, err := s.service.GetSongs(req, page, limit)
+    songs, err := s.service.GetSongs(r.Context(), req, page, limit)
     if err != nil {
         return NewHttpError(http.StatusBadRequest)
     }
@@ -110,7 +88,26 @@ func (s SongHandler) handleGetSongText(w http.ResponseWriter, r *http.Request) e
         return NewHttpError(http.StatusBadRequest)
     }
 
-    verse, err := s.service.GetSongText(id, page)
+    // Masking explicit words on demand
+    mask := false
+    if r.URL.Query().Has("mask") {
+        mask, err = strconv.ParseBool(r.URL.Query().Get("mask"))
+        if err != nil {
+            return NewHttpError(http.StatusBadRequest)
+        }
+    }
+
+    // Responding verse aligned with its translation
+    if r.URL.Query().Has("lang") {
+        verse, err := s.service.GetTranslatedVerse(r.Context(), id, page, r.URL.Query().Get("lang"), mask)
+        if err != nil {
+            return NewHttpError(http.StatusBadRequest)
+        }
+
+        return WriteJson(w, http.StatusOK, verse)
+    }
+
+    verse, err := s.service.GetSongText(r.Context(), id, page, mask)
     if err != nil {
         return NewHttpError(http.StatusBadRequest)
     }
@@ -123,6 +120,11 @@ func (s SongHandler) handleGetSongText(w http.ResponseWriter, r *http.Request) e
 }
 
 func (s SongHandler) handleCreateSong(w http.ResponseWriter, r *http.Request) error {
+    onConflict, err := parseOnConflict(r)
+    if err != nil {
+        return err
+    }
+
     // Decoding the request in CreateSong struct
     var req types.CreateSong
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -130,14 +132,25 @@ func (s SongHandler) handleCreateSong(w http.ResponseWriter, r *http.Request) er
     }
     defer r.Body.Close()
 
-    id, err := s.service.CreateSong(req)
+    result, err := s.service.CreateSong(r.Context(), req, onConflict)
     if err != nil {
+        if errors.Is(err, storage.ErrConflict) {
+            return NewHttpError(http.StatusConflict)
+        }
+        // Responding ids of the songs it duplicates
+        var duplicateErr *services.DuplicateError
+        if errors.As(err, &duplicateErr) {
+            return WriteJson(w, http.StatusConflict, map[string]interface{}{
+                "duplicates": duplicateErr.Ids,
+            })
+        }
         return NewHttpError(http.StatusBadRequest)
     }
 
-    return WriteJson(w, http.StatusCreated, map[string]interface{}{
-        "id": id,
-    })
+    if result.Status != types.SongCreated {
+        return WriteJson(w, http.StatusOK, result)
+    }
+    return WriteJson(w, http.StatusCreated, result)
 }
 
 func (s SongHandler) handleUpdateSong(w http.ResponseWriter, r *http.Request) error {
@@ -153,7 +166,43 @@ func (s SongHandler) handleUpdateSong(w http.ResponseWriter, r *http.Request) er
     }
     defer r.Body.Close()
 
-    if err := s.service.UpdateSong(id, req); err != nil {
+    if err := s.service.UpdateSong(r.Context(), id, req); err != nil {
+        if errors.Is(err, storage.ErrConflict) {
+            return NewHttpError(http.StatusConflict)
+        }
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusNoContent, struct{}{})
+}
+
+func (s SongHandler) handleEnrichSong(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    if err := s.service.EnrichSong(r.Context(), id); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusNoContent, struct{}{})
+}
+
+func (s SongHandler) handleSetExplicitOverride(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    // Decoding the request in SetExplicitOverride struct
+    var req types.SetExplicitOverride
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+    defer r.Body.Close()
+
+    if err := s.service.SetExplicitOverride(r.Context(), id, req); err != nil {
         return NewHttpError(http.StatusBadRequest)
     }
 
@@ -166,7 +215,7 @@ func (s SongHandler) handleDeleteSong(w http.ResponseWriter, r *http.Request) er
         return NewHttpError(http.StatusBadRequest)
     }
 
-    if err := s.service.DeleteSong(id); err != nil {
+    if err := s.service.DeleteSong(r.Context(), id); err != nil {
         return NewHttpError(http.StatusBadRequest)
     }
 
@@ -0,0 +1,110 @@
+package api
+
+import (
+    "github.com/vasch3nko/songlibrary/internal/services"
+    "net/http"
+    "strconv"
+)
+
+type StatsHandler struct {
+    service services.StatsService
+    mux     *LoggingMux
+}
+
+func NewStatsHandler(service services.StatsService, mux *LoggingMux) *StatsHandler {
+    return &StatsHandler{
+        service: service,
+        mux:     mux,
+    }
+}
+
+// RegisterStatsRoutes registers statistics routes.
+// All of them accept the same filters as GET /songs.
+func (s StatsHandler) RegisterStatsRoutes() {
+    s.mux.HandleFunc("GET /stats", s.handleGetLibraryStats)
+    s.mux.HandleFunc("GET /stats/songs", s.handleGetSongStats)
+    s.mux.HandleFunc("GET /stats/top-words", s.handleGetTopWords)
+    s.mux.HandleFunc("GET /stats/vocabulary", s.handleGetVocabularyGrowth)
+}
+
+func (s StatsHandler) handleGetLibraryStats(w http.ResponseWriter, r *http.Request) error {
+    filter, err := parseSongsFilter(r)
+    if err != nil {
+        return err
+    }
+
+    stats, err := s.service.GetLibraryStats(r.Context(), filter)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusOK, stats)
+}
+
+func (s StatsHandler) handleGetSongStats(w http.ResponseWriter, r *http.Request) error {
+    filter, err := parseSongsFilter(r)
+    if err != nil {
+        return err
+    }
+
+    page, limit, err := parsePagination(r)
+    if err != nil {
+        return err
+    }
+
+    stats, err := s.service.GetSongStats(r.Context(), filter, page, limit)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    if stats == nil {
+        return WriteJson(w, http.StatusOK, []interface{}{})
+    }
+
+    return WriteJson(w, http.StatusOK, stats)
+}
+
+func (s StatsHandler) handleGetTopWords(w http.ResponseWriter, r *http.Request) error {
+    filter, err := parseSongsFilter(r)
+    if err != nil {
+        return err
+    }
+
+    // Number of words per artist
+    n := 10
+    if r.URL.Query().Has("n") {
+        n, err = strconv.Atoi(r.URL.Query().Get("n"))
+        if err != nil || n < 1 || n > 1000 {
+            return NewHttpError(http.StatusBadRequest)
+        }
+    }
+
+    artists, err := s.service.GetTopWords(r.Context(), filter, n)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    if artists == nil {
+        return WriteJson(w, http.StatusOK, []interface{}{})
+    }
+
+    return WriteJson(w, http.StatusOK, artists)
+}
+
+func (s StatsHandler) handleGetVocabularyGrowth(w http.ResponseWriter, r *http.Request) error {
+    filter, err := parseSongsFilter(r)
+    if err != nil {
+        return err
+    }
+
+    years, err := s.service.GetVocabularyGrowth(r.Context(), filter)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    if years == nil {
+        return WriteJson(w, http.StatusOK, []interface{}{})
+    }
+
+    return WriteJson(w, http.StatusOK, years)
+}
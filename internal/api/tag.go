@@ -0,0 +1,107 @@
+package api
+
+import (
+    "encoding/json"
+    "github.com/vasch3nko/songlibrary/internal/services"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "net/http"
+    "strconv"
+)
+
+type TagHandler struct {
+    service services.TagService
+    mux     *LoggingMux
+}
+
+func NewTagHandler(service services.TagService, mux *LoggingMux) *TagHandler {
+    return &TagHandler{
+        service: service,
+        mux:     mux,
+    }
+}
+
+// RegisterTagRoutes registers tag routes. Facets
+// accept the same filters as GET /songs.
+func (t TagHandler) RegisterTagRoutes() {
+    t.mux.HandleFunc("GET /tags", t.handleGetTags)
+    t.mux.HandleFunc("GET /songs/facets", t.handleGetTagFacets)
+    t.mux.HandleFunc("GET /songs/{id}/tags", t.handleGetSongTags)
+    t.mux.HandleFunc("POST /songs/{id}/tags", t.handleTagSong)
+    t.mux.HandleFunc("DELETE /songs/{id}/tags/{tag}", t.handleUntagSong)
+}
+
+func (t TagHandler) handleGetTags(w http.ResponseWriter, r *http.Request) error {
+    tags, err := t.service.GetTags(r.Context(), r.URL.Query().Get("kind"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusOK, tags)
+}
+
+func (t TagHandler) handleGetTagFacets(w http.ResponseWriter, r *http.Request) error {
+    filter, err := parseSongsFilter(r)
+    if err != nil {
+        return err
+    }
+
+    facets, err := t.service.GetTagFacets(r.Context(), filter)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusOK, facets)
+}
+
+func (t TagHandler) handleGetSongTags(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    tags, err := t.service.GetSongTags(r.Context(), id)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusOK, tags)
+}
+
+func (t TagHandler) handleTagSong(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    // Decoding the request in SetSongTags struct,
+    // tags are parsed and validated on decoding
+    var req types.SetSongTags
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+    defer r.Body.Close()
+
+    if err := t.service.TagSong(r.Context(), id, req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusNoContent, struct{}{})
+}
+
+func (t TagHandler) handleUntagSong(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    tag, err := types.ParseTag(r.PathValue("tag"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    if err := t.service.UntagSong(r.Context(), id, tag); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusNoContent, struct{}{})
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+    "encoding/json"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "net/http"
+    "strconv"
+)
+
+func (s SongHandler) handleGetSongTranslations(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    translations, err := s.service.GetSongTranslations(r.Context(), id)
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    if translations == nil {
+        return WriteJson(w, http.StatusOK, []interface{}{})
+    }
+
+    return WriteJson(w, http.StatusOK, translations)
+}
+
+func (s SongHandler) handleGetSongTranslation(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    translation, err := s.service.GetSongTranslation(r.Context(), id, r.PathValue("lang"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusOK, translation)
+}
+
+func (s SongHandler) handleSetSongTranslation(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    // Decoding the request in SetSongTranslation struct
+    var req types.SetSongTranslation
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+    defer r.Body.Close()
+
+    if err := s.service.SetSongTranslation(r.Context(), id, r.PathValue("lang"), req); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusNoContent, struct{}{})
+}
+
+func (s SongHandler) handleDeleteSongTranslation(w http.ResponseWriter, r *http.Request) error {
+    id, err := strconv.Atoi(r.PathValue("id"))
+    if err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    if err := s.service.DeleteSongTranslation(r.Context(), id, r.PathValue("lang")); err != nil {
+        return NewHttpError(http.StatusBadRequest)
+    }
+
+    return WriteJson(w, http.StatusNoContent, struct{}{})
+}
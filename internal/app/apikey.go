@@ -0,0 +1,136 @@
+package app
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "os"
+    "strconv"
+    "strings"
+    "text/tabwriter"
+    "time"
+)
+
+// ApiKey manages API keys of the HTTP API
+// (songlibrary apikey create|revoke|list [flags])
+func ApiKey(args []string) error {
+    usage := errors.New("usage: songlibrary apikey create|revoke|list [flags]")
+    if len(args) == 0 {
+        return usage
+    }
+
+    switch args[0] {
+    case "create":
+        return createApiKey(args[1:])
+    case "revoke":
+        return revokeApiKey(args[1:])
+    case "list":
+        return listApiKeys(args[1:])
+    }
+    return usage
+}
+
+func createApiKey(args []string) error {
+    fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+    name := fs.String("name", "", "name of the key owner (required)")
+    scopes := fs.String("scopes", string(types.ScopeRead), "comma separated scopes: read, write, admin")
+    fs.Usage = func() {
+        fmt.Fprintln(fs.Output(), "Usage: songlibrary apikey create [flags]")
+        fs.PrintDefaults()
+    }
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+
+    req := types.CreateApiKey{Name: *name}
+    for _, s := range strings.Split(*scopes, ",") {
+        scope, err := types.ParseScope(strings.TrimSpace(s))
+        if err != nil {
+            return err
+        }
+        req.Scopes = append(req.Scopes, scope)
+    }
+
+    d, err := setup(os.Stderr)
+    if err != nil {
+        return err
+    }
+
+    key, created, err := d.apiKeyService.CreateApiKey(cliContext(), req)
+    if err != nil {
+        return err
+    }
+
+    // The key cannot be shown again, only its hash is stored
+    fmt.Fprintf(os.Stderr, "Created api key %d (%s), store it now, it is not shown again:\n", created.Id, created.Name)
+    fmt.Println(key)
+
+    return nil
+}
+
+func revokeApiKey(args []string) error {
+    if len(args) != 1 {
+        return errors.New("usage: songlibrary apikey revoke <id>")
+    }
+    id, err := strconv.Atoi(args[0])
+    if err != nil {
+        return fmt.Errorf("invalid api key id %q", args[0])
+    }
+
+    d, err := setup(os.Stderr)
+    if err != nil {
+        return err
+    }
+
+    if err := d.apiKeyService.RevokeApiKey(cliContext(), id); err != nil {
+        return err
+    }
+
+    fmt.Fprintf(os.Stderr, "Revoked api key %d\n", id)
+
+    return nil
+}
+
+func listApiKeys(args []string) error {
+    if len(args) != 0 {
+        return errors.New("usage: songlibrary apikey list")
+    }
+
+    d, err := setup(os.Stderr)
+    if err != nil {
+        return err
+    }
+
+    keys, err := d.apiKeyService.GetApiKeys(cliContext())
+    if err != nil {
+        return err
+    }
+
+    formatTime := func(t *time.Time) string {
+        if t == nil {
+            return "-"
+        }
+        return t.Local().Format(time.DateTime)
+    }
+
+    w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+    fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tCREATED\tLAST USED\tREVOKED")
+    for _, key := range keys {
+        scopes := make([]string, len(key.Scopes))
+        for i, scope := range key.Scopes {
+            scopes[i] = string(scope)
+        }
+        fmt.Fprintf(w, "%d\t%s\tsl_%s...\t%s\t%s\t%s\t%s\n",
+            key.Id,
+            key.Name,
+            key.Prefix,
+            strings.Join(scopes, ","),
+            formatTime(&key.CreatedAt),
+            formatTime(key.LastUsedAt),
+            formatTime(key.RevokedAt),
+        )
+    }
+
+    return w.Flush()
+}
@@ -6,11 +6,27 @@ import (
     "github.com/joho/godotenv"
     "github.com/vasch3nko/songlibrary/internal/api"
     "github.com/vasch3nko/songlibrary/internal/config"
+    "github.com/vasch3nko/songlibrary/internal/explicit"
+    "github.com/vasch3nko/songlibrary/internal/jwt"
+    "github.com/vasch3nko/songlibrary/internal/langdetect"
+    "github.com/vasch3nko/songlibrary/internal/metrics"
+    "github.com/vasch3nko/songlibrary/internal/ratelimit"
+    "github.com/vasch3nko/songlibrary/internal/reqctx"
     "github.com/vasch3nko/songlibrary/internal/services"
+    "github.com/vasch3nko/songlibrary/internal/similarity"
     "github.com/vasch3nko/songlibrary/internal/storage"
+    "github.com/vasch3nko/songlibrary/internal/textstats"
+    "github.com/vasch3nko/songlibrary/internal/tracing"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "io"
     "log/slog"
     "net/http"
+    "net/netip"
     "os"
+    "os/user"
+    "strings"
+    "sync"
+    "time"
 )
 
 const (
@@ -18,22 +34,258 @@ const (
     envProd = "prod"
 )
 
+// Modes of bearer token authentication
+const (
+    authNone   = "none"
+    authApiKey = "apikey"
+    authJwt    = "jwt"
+)
+
+// How often expired audit events are deleted
+const auditPruneInterval = time.Hour
+
+// Exporters of the finished spans
+const (
+    tracingNone   = "none"
+    tracingOtlp   = "otlp"
+    tracingStderr = "stderr"
+    tracingFile   = "file"
+)
+
+// How long queued spans are sent on exit
+const tracingShutdownTimeout = 5 * time.Second
+
 func Run(ctx context.Context) error {
-    if err := godotenv.Load(); err != nil {
+    d, err := setup(os.Stdout)
+    if err != nil {
         return err
     }
+    cfg, log, songService := d.cfg, d.log, d.songService
 
-    cfg := config.NewConfig()
-    if err := cfg.LoadFromEnv(); err != nil {
+    // Closing the database after everything that uses it
+    defer func() {
+        if err := d.store.Close(); err != nil {
+            log.Error("Failed to close database", slog.String("error", err.Error()))
+            return
+        }
+        log.Info("Database closed")
+    }()
+
+    log.Info("Starting song library app", slog.String("env", cfg.Env))
+
+    auth, err := setupAuth(cfg, d)
+    if err != nil {
+        log.Error("Invalid auth config", slog.String("error", err.Error()))
         return err
     }
 
-    log, err := setupLogger(cfg.Env)
+    if cfg.Server.ShutdownTimeout <= 0 {
+        err := errors.New("shutdown timeout must be positive")
+        log.Error("Invalid server config", slog.String("error", err.Error()))
+        return err
+    }
+
+    limits, err := setupRateLimits(cfg)
     if err != nil {
+        log.Error("Invalid rate limit config", slog.String("error", err.Error()))
         return err
     }
 
-    log.Info("Starting song library app", slog.String("env", cfg.Env))
+    tracer, err := setupTracing(cfg, log)
+    if err != nil {
+        log.Error("Invalid tracing config", slog.String("error", err.Error()))
+        return err
+    }
+    defer func() {
+        if tracer == nil {
+            return
+        }
+        ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+        defer cancel()
+        if err := tracer.Shutdown(ctx); err != nil {
+            log.Error("Failed to shutdown tracer", slog.String("error", err.Error()))
+            return
+        }
+        log.Info("Spans flushed")
+    }()
+
+    // Loading lyrics of existing songs to the similarity index
+    if err := songService.BuildIndex(ctx); err != nil {
+        log.Error("Failed to build similarity index", slog.String("error", err.Error()))
+        return err
+    }
+
+    // Background jobs stop after the server drains, not on the
+    // signal, and are waited for
+    jobsCtx, stopJobs := context.WithCancel(context.Background())
+    defer stopJobs()
+    var jobs sync.WaitGroup
+
+    // Computing stats of the songs created before they existed
+    jobs.Add(1)
+    go func() {
+        defer jobs.Done()
+        err := songService.BackfillStats(systemContext(jobsCtx, "backfill"))
+        if err != nil && !errors.Is(err, context.Canceled) {
+            log.Error("Failed to backfill stats", slog.String("error", err.Error()))
+        }
+    }()
+
+    // Deleting audit events older than the retention period
+    jobs.Add(1)
+    go func() {
+        defer jobs.Done()
+        pruneAuditEvents(jobsCtx, d.auditService, cfg.Audit.Retention, log)
+    }()
+
+    mux := api.NewLoggingMux(auth, d.registry, tracer, log)
+    api.NewSongHandler(songService, mux).RegisterSongRoutes()
+    api.NewStatsHandler(d.statsService, mux).RegisterStatsRoutes()
+    api.NewPlaylistHandler(d.playlistService, mux).RegisterPlaylistRoutes()
+    api.NewTagHandler(d.tagService, mux).RegisterTagRoutes()
+    api.NewAuditHandler(d.auditService, mux).RegisterAuditRoutes()
+
+    // Readiness of the orchestrator probes, the migrations
+    // do not change while the service runs
+    latest, err := storage.LatestMigration(cfg.Db.MigrationsPath)
+    if err != nil {
+        log.Error("Failed to collect migrations",
+            slog.String("path", cfg.Db.MigrationsPath),
+            slog.String("error", err.Error()),
+        )
+        return err
+    }
+    checks := services.HealthChecks{
+        LatestMigration: latest,
+        Timeout:         cfg.Health.Timeout,
+    }
+    if cfg.Health.CheckEnrichment {
+        checks.SongDetailApiUrl = cfg.SongDetailsApiUrl
+    }
+    if err = checks.Validate(); err != nil {
+        log.Error("Invalid health checks", slog.String("error", err.Error()))
+        return err
+    }
+    health := services.NewHealthService(d.store, checks, log)
+
+    // Metrics and probes are served past authentication and rate limits
+    root := http.NewServeMux()
+    root.Handle("GET /metrics", d.registry)
+    api.NewHealthHandler(health, root).RegisterHealthRoutes()
+    root.Handle("/", api.NewRequestIdHandler(api.NewRateLimiter(limits, mux, log)))
+
+    srv := &http.Server{
+        Addr:         cfg.Server.Addr,
+        Handler:      root,
+        ReadTimeout:  cfg.Server.ReadTimeout,
+        WriteTimeout: cfg.Server.WriteTimeout,
+        IdleTimeout:  cfg.Server.IdleTimeout,
+        ErrorLog:     slog.NewLogLogger(log.Handler(), slog.LevelInfo),
+    }
+
+    serveErr := make(chan error, 1)
+    go func() {
+        log.Info("Starting server", slog.String("addr", cfg.Server.Addr))
+        serveErr <- srv.ListenAndServe()
+    }()
+
+    select {
+    case err = <-serveErr:
+        log.Error("Unexpected server shutdown", slog.String("error", err.Error()))
+        stopJobs()
+        jobs.Wait()
+        return err
+    case <-ctx.Done():
+    }
+
+    return shutdown(srv, health, stopJobs, &jobs, cfg, log)
+}
+
+// shutdown stops the server gracefully: it reports not ready
+// for the shutdown delay, drains in-flight requests and waits
+// for background jobs, both within the shutdown timeout
+func shutdown(
+    srv *http.Server,
+    health *services.HealthService,
+    stopJobs context.CancelFunc,
+    jobs *sync.WaitGroup,
+    cfg *config.Config,
+    log *slog.Logger,
+) error {
+    log.Info("Shutting down server",
+        slog.Duration("delay", cfg.Server.ShutdownDelay),
+        slog.Duration("timeout", cfg.Server.ShutdownTimeout),
+    )
+
+    // Load balancers stop routing requests before the listener is closed
+    health.Drain()
+    time.Sleep(cfg.Server.ShutdownDelay)
+
+    ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+    defer cancel()
+
+    log.Info("Draining in-flight requests")
+    err := srv.Shutdown(ctx)
+    if err != nil {
+        log.Error("Failed to drain requests in time, closing connections", slog.String("error", err.Error()))
+        srv.Close()
+    } else {
+        log.Info("Server stopped")
+    }
+
+    log.Info("Waiting for background jobs")
+    stopJobs()
+    done := make(chan struct{})
+    go func() {
+        jobs.Wait()
+        close(done)
+    }()
+    select {
+    case <-done:
+        log.Info("Background jobs stopped")
+    case <-ctx.Done():
+        log.Error("Background jobs did not stop in time")
+        if err == nil {
+            err = ctx.Err()
+        }
+    }
+
+    return err
+}
+
+// deps are the dependencies shared by the server and commands
+type deps struct {
+    cfg             *config.Config
+    log             *slog.Logger
+    registry        *metrics.Registry
+    store           *storage.PostgresStore
+    songService     services.SongService
+    statsService    services.StatsService
+    playlistService services.PlaylistService
+    tagService      services.TagService
+    apiKeyService   services.ApiKeyService
+    auditService    services.AuditService
+}
+
+// setup loads config, connects and migrates storage
+// and initializes services, logs are written to w
+func setup(w io.Writer) (*deps, error) {
+    if err := godotenv.Load(); err != nil {
+        return nil, err
+    }
+
+    cfg := config.NewConfig()
+    if err := cfg.LoadFromEnv(); err != nil {
+        return nil, err
+    }
+
+    log, err := setupLogger(cfg.Env, w)
+    if err != nil {
+        return nil, err
+    }
+
+    // Metrics of the requests, queries and enrichment
+    registry := metrics.NewRegistry()
 
     // Postgres storage initialization and connecting
     store, err := storage.NewPostgresStore(
@@ -43,64 +295,315 @@ func Run(ctx context.Context) error {
         cfg.Db.Password,
         cfg.Db.Database,
         cfg.Db.SSLMode,
+        registry,
         log,
     )
     if err != nil {
         log.Error("Failed to init storage", slog.String("error", err.Error()))
-        return err
+        return nil, err
     }
 
     // Postgres storage migrating
-    if err = store.Migrate(cfg.Db.MigrationsPath); err != nil {
+    if err = store.Migrate(context.Background(), cfg.Db.MigrationsPath); err != nil {
         log.Error("Failed to migrate storage", slog.String("error", err.Error()))
-        return err
+        return nil, err
     }
 
-    songService := services.NewSongService(store, cfg.SongDetailsApiUrl, log)
+    // Language detector initialization from embedded profiles
+    detector, err := langdetect.New()
+    if err != nil {
+        log.Error("Failed to init language detector", slog.String("error", err.Error()))
+        return nil, err
+    }
 
-    mux := api.NewLoggingMux(log)
-    api.NewSongHandler(songService, mux).RegisterSongRoutes()
+    // Explicit content scanner initialization from word lists
+    scanner, err := explicit.Load(cfg.Lyrics.ExplicitWordListsPath)
+    if err != nil {
+        log.Error("Failed to load explicit word lists",
+            slog.String("path", cfg.Lyrics.ExplicitWordListsPath),
+            slog.String("error", err.Error()),
+        )
+        return nil, err
+    }
 
-    srv := &http.Server{
-        Addr:         cfg.Server.Addr,
-        Handler:      mux,
-        ReadTimeout:  cfg.Server.ReadTimeout,
-        WriteTimeout: cfg.Server.WriteTimeout,
-        IdleTimeout:  cfg.Server.IdleTimeout,
-        ErrorLog:     slog.NewLogLogger(log.Handler(), slog.LevelInfo),
+    // Stop words initialization for word statistics
+    stopWords, err := textstats.LoadStopWords(cfg.Lyrics.StopWordsPath)
+    if err != nil {
+        log.Error("Failed to load stop words",
+            slog.String("path", cfg.Lyrics.StopWordsPath),
+            slog.String("error", err.Error()),
+        )
+        return nil, err
     }
 
-    // Goroutine that handles an interrupt
-    go func() {
-        <-ctx.Done()
-        log.Info("Shutting down server")
-        if err = srv.Shutdown(ctx); err != nil {
-            log.Error("error", err)
+    analyzer := services.NewAnalyzer(detector, scanner, stopWords, log)
+    duplicates := services.DuplicatePolicy{
+        Mode:            cfg.Duplicates.Policy,
+        LyricsThreshold: cfg.Duplicates.LyricsThreshold,
+    }
+    if err = duplicates.Validate(); err != nil {
+        log.Error("Invalid duplicate policy", slog.String("error", err.Error()))
+        return nil, err
+    }
+
+    batch := services.BatchLimits{
+        MaxSize:     cfg.Batch.MaxSize,
+        Concurrency: cfg.Batch.Concurrency,
+    }
+    if err = batch.Validate(); err != nil {
+        log.Error("Invalid batch limits", slog.String("error", err.Error()))
+        return nil, err
+    }
+
+    playlists := services.PlaylistPolicy{DeletedSongs: cfg.Playlists.DeletedSongs}
+    if err = playlists.Validate(); err != nil {
+        log.Error("Invalid playlist policy", slog.String("error", err.Error()))
+        return nil, err
+    }
+
+    if cfg.SongDetailsApiTimeout <= 0 {
+        err := errors.New("song details API timeout must be positive")
+        log.Error("Invalid song details API config", slog.String("error", err.Error()))
+        return nil, err
+    }
+
+    songService := services.NewSongService(
+        store,
+        cfg.SongDetailsApiUrl,
+        cfg.SongDetailsApiTimeout,
+        analyzer,
+        similarity.NewIndex(services.TitleKeys),
+        duplicates,
+        batch,
+        playlists,
+        registry,
+        log,
+    )
+    statsService := services.NewStatsService(store, log)
+    playlistService := services.NewPlaylistService(store, log)
+    tagService := services.NewTagService(store, log)
+    apiKeyService := services.NewApiKeyService(store, log)
+    auditService := services.NewAuditService(store, log)
+
+    return &deps{
+        cfg:             cfg,
+        log:             log,
+        registry:        registry,
+        store:           store,
+        songService:     songService,
+        statsService:    statsService,
+        playlistService: playlistService,
+        tagService:      tagService,
+        apiKeyService:   apiKeyService,
+        auditService:    auditService,
+    }, nil
+}
+
+// setupAuth returns authentication of the configured modes,
+// tokens are tried against the modes in the given order
+func setupAuth(cfg *config.Config, d *deps) (api.Auth, error) {
+    auth := api.Auth{PublicReads: cfg.Auth.PublicReads}
+
+    var authenticators api.Authenticators
+    for _, mode := range strings.Split(cfg.Auth.Mode, ",") {
+        switch strings.TrimSpace(mode) {
+        case authNone:
+        case authApiKey:
+            authenticators = append(authenticators, d.apiKeyService)
+        case authJwt:
+            authenticator, err := setupJwt(cfg, d.log)
+            if err != nil {
+                return api.Auth{}, err
+            }
+            authenticators = append(authenticators, authenticator)
+        default:
+            return api.Auth{}, errors.New("invalid auth mode provided")
         }
-    }()
+    }
 
-    log.Info("Starting server", slog.String("addr", cfg.Server.Addr))
-    err = srv.ListenAndServe()
-    if err != nil && !errors.Is(err, http.ErrServerClosed) {
-        log.Error("Unexpected server shutdown", slog.String("error", err.Error()))
-        return err
+    if len(authenticators) > 0 {
+        auth.Authenticator = authenticators
+    }
+
+    return auth, nil
+}
+
+// setupJwt loads keys of the JWKS and PEM files
+// and returns authenticator of the platform tokens
+func setupJwt(cfg *config.Config, log *slog.Logger) (services.JwtAuthenticator, error) {
+    var keys jwt.KeySet
+    if cfg.Jwt.JwksPath != "" {
+        jwks, err := jwt.LoadJWKS(cfg.Jwt.JwksPath)
+        if err != nil {
+            return services.JwtAuthenticator{}, err
+        }
+        keys = append(keys, jwks...)
+    }
+    if cfg.Jwt.PublicKeysPath != "" {
+        pem, err := jwt.LoadPEM(cfg.Jwt.PublicKeysPath)
+        if err != nil {
+            return services.JwtAuthenticator{}, err
+        }
+        keys = append(keys, pem...)
+    }
+    if len(keys) == 0 {
+        return services.JwtAuthenticator{}, errors.New("jwt mode requires jwks or public keys path")
+    }
+
+    log.Info("Jwt keys loaded", slog.Int("keys", len(keys)))
+
+    verifier := jwt.Verifier{
+        Keys:     keys,
+        Issuer:   cfg.Jwt.Issuer,
+        Audience: cfg.Jwt.Audience,
+        Leeway:   cfg.Jwt.Leeway,
+    }
+    roles := services.JwtRoles{
+        Claim:  cfg.Jwt.RolesClaim,
+        Viewer: cfg.Jwt.ViewerRole,
+        Editor: cfg.Jwt.EditorRole,
+        Admin:  cfg.Jwt.AdminRole,
+    }
+    return services.NewJwtAuthenticator(verifier, roles, log), nil
+}
+
+// setupTracing returns the tracer of the configured
+// exporter, nil tracer if tracing is disabled
+func setupTracing(cfg *config.Config, log *slog.Logger) (*tracing.Tracer, error) {
+    if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+        return nil, errors.New("tracing sample ratio must be from 0 to 1")
+    }
+
+    var exporter tracing.Exporter
+    switch cfg.Tracing.Exporter {
+    case tracingNone, "":
+        return nil, nil
+    case tracingOtlp:
+        if cfg.Tracing.OtlpEndpoint == "" {
+            return nil, errors.New("otlp exporter requires otlp endpoint")
+        }
+        exporter = tracing.NewOtlpExporter(cfg.Tracing.OtlpEndpoint)
+    case tracingStderr:
+        // Stdout is taken by the logs
+        exporter = tracing.NewWriterExporter(os.Stderr)
+    case tracingFile:
+        fileExporter, err := tracing.NewFileExporter(cfg.Tracing.FilePath)
+        if err != nil {
+            return nil, err
+        }
+        exporter = fileExporter
+    default:
+        return nil, errors.New("unknown tracing exporter " + cfg.Tracing.Exporter)
+    }
+
+    log.Info("Tracing enabled",
+        slog.String("exporter", cfg.Tracing.Exporter),
+        slog.Float64("sample_ratio", cfg.Tracing.SampleRatio),
+    )
+
+    return tracing.NewTracer("songlibrary", cfg.Tracing.SampleRatio, exporter, log), nil
+}
+
+// setupRateLimits returns limiters of reads and writes,
+// limits with zero rate are disabled
+func setupRateLimits(cfg *config.Config) (api.RateLimits, error) {
+    var limits api.RateLimits
+
+    newLimiter := func(rate float64, burst int) (*ratelimit.Limiter, error) {
+        if rate == 0 {
+            return nil, nil
+        }
+        if rate < 0 || burst < 1 {
+            return nil, errors.New("rate limit must have positive rate and burst")
+        }
+        return ratelimit.New(ratelimit.Limit{Rate: rate, Burst: burst}), nil
+    }
+
+    var err error
+    if limits.Read, err = newLimiter(cfg.RateLimit.ReadRate, cfg.RateLimit.ReadBurst); err != nil {
+        return api.RateLimits{}, err
+    }
+    if limits.Write, err = newLimiter(cfg.RateLimit.WriteRate, cfg.RateLimit.WriteBurst); err != nil {
+        return api.RateLimits{}, err
+    }
+
+    for _, proxy := range strings.Split(cfg.RateLimit.TrustedProxies, ",") {
+        proxy = strings.TrimSpace(proxy)
+        if proxy == "" {
+            continue
+        }
+        if !strings.Contains(proxy, "/") {
+            addr, err := netip.ParseAddr(proxy)
+            if err != nil {
+                return api.RateLimits{}, err
+            }
+            limits.TrustedProxies = append(limits.TrustedProxies, netip.PrefixFrom(addr, addr.BitLen()))
+            continue
+        }
+        prefix, err := netip.ParsePrefix(proxy)
+        if err != nil {
+            return api.RateLimits{}, err
+        }
+        limits.TrustedProxies = append(limits.TrustedProxies, prefix.Masked())
+    }
+
+    return limits, nil
+}
+
+// pruneAuditEvents deletes expired audit events on start
+// and then hourly until ctx is done
+func pruneAuditEvents(ctx context.Context, auditService services.AuditService, retention time.Duration, log *slog.Logger) {
+    if retention <= 0 {
+        return
+    }
+
+    ticker := time.NewTicker(auditPruneInterval)
+    defer ticker.Stop()
+    for {
+        if _, err := auditService.PruneAuditEvents(ctx, retention); err != nil {
+            log.Error("Failed to prune audit events", slog.String("error", err.Error()))
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// systemContext returns the context of the background job,
+// its changes are attributed to the job rather than a person
+func systemContext(ctx context.Context, job string) context.Context {
+    principal := types.Principal{Subject: "system:" + job, Scopes: []types.Scope{types.ScopeAdmin}}
+    return reqctx.WithPrincipal(ctx, principal)
+}
+
+// cliContext returns the context of the command, its
+// changes are attributed to the local user running it
+func cliContext() context.Context {
+    subject := "cli"
+    if u, err := user.Current(); err == nil {
+        subject += ":" + u.Username
     }
 
-    return nil
+    principal := types.Principal{Subject: subject, Scopes: []types.Scope{types.ScopeAdmin}}
+    return reqctx.WithPrincipal(context.Background(), principal)
 }
 
-func setupLogger(env string) (*slog.Logger, error) {
+func setupLogger(env string, w io.Writer) (*slog.Logger, error) {
     var logger *slog.Logger
 
+    // Entries logged with the context of the request are correlated
     switch env {
     case envDev:
-        logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+        logger = slog.New(reqctx.NewHandler(slog.NewJSONHandler(w, &slog.HandlerOptions{
             Level: slog.LevelDebug,
-        }))
+        })))
     case envProd:
-        logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+        logger = slog.New(reqctx.NewHandler(slog.NewJSONHandler(w, &slog.HandlerOptions{
             Level: slog.LevelInfo,
-        }))
+        })))
     default:
         return nil, errors.New("invalid environment provided")
     }
@@ -0,0 +1,114 @@
+package app
+
+import (
+    "flag"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/songio"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "io"
+    "log/slog"
+    "os"
+    "path/filepath"
+    "strconv"
+)
+
+// Export writes songs to the file or stdout in the import format.
+//
+//  songlibrary export [flags]
+func Export(args []string) error {
+    fs := flag.NewFlagSet("export", flag.ContinueOnError)
+    format := fs.String("format", "", "file format: json, csv or jsonl (default from output extension, jsonl for stdout)")
+    output := fs.String("o", "", "output file (default stdout)")
+    group := fs.String("group", "", "only songs of the group")
+    song := fs.String("song", "", "only songs with the name")
+    language := fs.String("language", "", "only songs in the language")
+    explicit := fs.String("explicit", "", "only explicit (true) or clean (false) songs")
+    releaseDate := fs.String("release-date", "", "only songs released on the date (dd.mm.yyyy)")
+    var tags [][]types.Tag
+    fs.Func("tag", "only songs with the tag, repeat to require several (genre:rock|genre:metal for either)", func(s string) error {
+        group, err := types.ParseTagGroup(s)
+        if err != nil {
+            return err
+        }
+        tags = append(tags, group)
+        return nil
+    })
+    fs.Usage = func() {
+        fmt.Fprintln(fs.Output(), "Usage: songlibrary export [flags]")
+        fmt.Fprintln(fs.Output(), "Only song, group, text, link and release date are exported.")
+        fs.PrintDefaults()
+    }
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+
+    if *format == "" {
+        *format = songio.FormatJSONL
+        if *output != "" {
+            *format = filepath.Ext(*output)
+        }
+    }
+    songFormat, err := songio.ParseFormat(*format)
+    if err != nil {
+        return err
+    }
+
+    // Filter of the set flags only
+    filter := types.GetSongs{Tags: tags}
+    var parseErr error
+    fs.Visit(func(f *flag.Flag) {
+        switch f.Name {
+        case "group":
+            filter.Group = group
+        case "song":
+            filter.Song = song
+        case "language":
+            filter.Language = language
+        case "explicit":
+            b, err := strconv.ParseBool(*explicit)
+            if err != nil {
+                parseErr = fmt.Errorf("invalid explicit flag: %w", err)
+            }
+            filter.Explicit = &b
+        case "release-date":
+            var date types.Date
+            if err := date.Scan(*releaseDate); err != nil {
+                parseErr = fmt.Errorf("invalid release date flag: %w", err)
+            }
+            filter.ReleaseDate = &date
+        }
+    })
+    if parseErr != nil {
+        return parseErr
+    }
+
+    d, err := setup(os.Stderr)
+    if err != nil {
+        return err
+    }
+
+    var w io.Writer = os.Stdout
+    if *output != "" {
+        f, err := os.Create(*output)
+        if err != nil {
+            return err
+        }
+        defer f.Close()
+        w = f
+    }
+
+    count, err := d.songService.Export(cliContext(), w, songFormat, filter)
+    if err != nil {
+        d.log.Error("Failed to export songs", slog.String("error", err.Error()))
+        return err
+    }
+
+    if f, ok := w.(*os.File); ok && f != os.Stdout {
+        if err := f.Close(); err != nil {
+            return err
+        }
+        fmt.Fprintf(os.Stderr, "exported %d songs to %s\n", count, *output)
+    }
+
+    return nil
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/songio"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+// Import imports songs from JSON, CSV or JSON Lines file.
+//
+//  songlibrary import [flags] <file>
+func Import(args []string) error {
+    fs := flag.NewFlagSet("import", flag.ContinueOnError)
+    format := fs.String("format", "", "file format: json, csv or jsonl (default from file extension)")
+    mapping := fs.String("map", "", "CSV columns of song fields: song=Title,group=Artist,releaseDate=Released")
+    enrich := fs.Bool("enrich", false, "request missing text, link and release date from external API")
+    onConflict := fs.String("on-conflict", string(types.OnConflictError), "existing song with the same group and name: error, ignore or update")
+    rejectedPath := fs.String("rejected", "", "rejected rows report file (default <file>.rejected.csv)")
+    fs.Usage = func() {
+        fmt.Fprintln(fs.Output(), "Usage: songlibrary import [flags] <file>")
+        fs.PrintDefaults()
+    }
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if fs.NArg() != 1 {
+        fs.Usage()
+        return errors.New("import file is required")
+    }
+    path := fs.Arg(0)
+
+    opts := types.ImportOptions{
+        Enrich:     *enrich,
+        OnConflict: types.OnConflict(*onConflict),
+    }
+    var err error
+    if *format == "" {
+        *format = filepath.Ext(path)
+    }
+    if opts.Format, err = songio.ParseFormat(*format); err != nil {
+        return err
+    }
+    if opts.Mapping, err = songio.ParseMapping(*mapping); err != nil {
+        return err
+    }
+
+    d, err := setup(os.Stderr)
+    if err != nil {
+        return err
+    }
+
+    f, err := os.Open(path)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+
+    // Duplicates are looked for among the existing songs
+    ctx := cliContext()
+    if err := d.songService.BuildIndex(ctx); err != nil {
+        d.log.Error("Failed to build similarity index", slog.String("error", err.Error()))
+        return err
+    }
+
+    result, err := d.songService.Import(ctx, f, opts)
+    if err != nil {
+        d.log.Error("Failed to import songs", slog.String("path", path), slog.String("error", err.Error()))
+        return err
+    }
+
+    fmt.Printf("rows: %d, created: %d, existing: %d, updated: %d, rejected: %d\n",
+        result.Rows, result.Created, result.Existing, result.Updated, len(result.Rejected))
+
+    if len(result.Rejected) == 0 {
+        return nil
+    }
+
+    // Writing rejected rows next to the imported file
+    if *rejectedPath == "" {
+        *rejectedPath = strings.TrimSuffix(path, filepath.Ext(path)) + ".rejected.csv"
+    }
+    report, err := os.Create(*rejectedPath)
+    if err != nil {
+        return err
+    }
+    defer report.Close()
+
+    if err := songio.WriteRejected(report, result.Rejected); err != nil {
+        return err
+    }
+    fmt.Printf("rejected rows: %s\n", *rejectedPath)
+
+    return report.Close()
+}
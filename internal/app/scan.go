@@ -0,0 +1,82 @@
+package app
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+    "os"
+    "strings"
+)
+
+// Scan creates or updates songs from tags of the audio
+// files in the directory (songlibrary scan [flags] <dir>)
+func Scan(args []string) error {
+    fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+    dryRun := fs.Bool("dry-run", false, "report changes without writing them")
+    verbose := fs.Bool("v", false, "list unchanged and skipped files too")
+    fs.Usage = func() {
+        fmt.Fprintln(fs.Output(), "Usage: songlibrary scan [flags] <dir>")
+        fs.PrintDefaults()
+    }
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if fs.NArg() != 1 {
+        fs.Usage()
+        return errors.New("directory is required")
+    }
+    dir := fs.Arg(0)
+
+    if info, err := os.Stat(dir); err != nil {
+        return err
+    } else if !info.IsDir() {
+        return fmt.Errorf("%s is not a directory", dir)
+    }
+
+    d, err := setup(os.Stderr)
+    if err != nil {
+        return err
+    }
+
+    // Duplicates are looked for among the existing songs
+    ctx := cliContext()
+    if err := d.songService.BuildIndex(ctx); err != nil {
+        d.log.Error("Failed to build similarity index", slog.String("error", err.Error()))
+        return err
+    }
+
+    result, err := d.songService.Scan(ctx, dir, *dryRun)
+    if err != nil {
+        d.log.Error("Failed to scan directory", slog.String("dir", dir), slog.String("error", err.Error()))
+        return err
+    }
+
+    for _, item := range result.Items {
+        if !*verbose && (item.Status == types.SongUnchanged || item.Status == types.SongSkipped) {
+            continue
+        }
+
+        line := fmt.Sprintf("%-9s %s", item.Status, item.Path)
+        if item.Song != "" {
+            line += fmt.Sprintf(" (%s - %s)", item.Group, item.Song)
+        }
+        if len(item.Changes) > 0 {
+            line += ": " + strings.Join(item.Changes, ", ")
+        }
+        if item.Reason != "" {
+            line += ": " + item.Reason
+        }
+        fmt.Println(line)
+    }
+
+    summary := fmt.Sprintf("files: %d, created: %d, updated: %d, unchanged: %d, skipped: %d, failed: %d",
+        result.Files, result.Created, result.Updated, result.Unchanged, result.Skipped, result.Failed)
+    if result.DryRun {
+        summary += " (dry run, nothing written)"
+    }
+    fmt.Println(summary)
+
+    return nil
+}
@@ -0,0 +1,182 @@
+// Package audiotag reads song metadata and embedded lyrics
+// from MP3 (ID3v1, ID3v2.2-2.4), FLAC and Ogg Vorbis/Opus files.
+package audiotag
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+)
+
+// ErrNoTags is returned when file has no supported tags
+var ErrNoTags = errors.New("no tags found")
+
+// ErrUnsupported is returned for files of unknown format
+var ErrUnsupported = errors.New("unsupported file format")
+
+// Max size of the tag read to memory
+const maxTagSize = 64 << 20
+
+// Tags are the song metadata of the audio file
+type Tags struct {
+    Title  string
+    Artist string
+    Album  string
+    // Date as written in the file ("2006", "2006-07-16")
+    Date   string
+    Lyrics string
+}
+
+// Extensions of the supported files
+var Extensions = map[string]bool{
+    ".mp3":  true,
+    ".flac": true,
+    ".ogg":  true,
+    ".oga":  true,
+    ".opus": true,
+}
+
+// ReadFile reads tags of the file, format is detected by content
+func ReadFile(path string) (Tags, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return Tags{}, err
+    }
+    defer f.Close()
+
+    tags, err := Read(f)
+    if err != nil {
+        return Tags{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+    }
+    return tags, nil
+}
+
+// Read reads tags of MP3, FLAC or Ogg file
+func Read(r io.ReadSeeker) (Tags, error) {
+    magic := make([]byte, 4)
+    if _, err := io.ReadFull(r, magic); err != nil {
+        return Tags{}, ErrUnsupported
+    }
+    if _, err := r.Seek(0, io.SeekStart); err != nil {
+        return Tags{}, err
+    }
+
+    var tags Tags
+    var err error
+    switch {
+    case bytes.Equal(magic, []byte("OggS")):
+        tags, err = readOgg(r)
+    case bytes.Equal(magic, []byte("fLaC")):
+        tags, err = readFLAC(r)
+    case bytes.HasPrefix(magic, []byte("ID3")):
+        // FLAC files can be prefixed with ID3v2 tag too
+        tags, err = readID3v2(r)
+        if err != nil {
+            return Tags{}, err
+        }
+        if isFLAC(r) {
+            flacTags, err := readFLAC(r)
+            if err == nil {
+                tags = merge(flacTags, tags)
+            }
+        }
+    default:
+        // MP3 without ID3v2 can still have ID3v1
+        if !isMPEGFrame(magic) {
+            return Tags{}, ErrUnsupported
+        }
+    }
+    if err != nil {
+        return Tags{}, err
+    }
+
+    if !tags.complete() {
+        if v1, err := readID3v1(r); err == nil {
+            tags = merge(tags, v1)
+        }
+    }
+
+    tags.Lyrics = normalizeLines(tags.Lyrics)
+    if tags.Title == "" && tags.Artist == "" && tags.Lyrics == "" {
+        return Tags{}, ErrNoTags
+    }
+    return tags, nil
+}
+
+// ParseDate parses tag date of year, month and day precision,
+// missing month and day are January and the first
+func ParseDate(s string) (time.Time, bool) {
+    s = strings.TrimSpace(s)
+    for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+        if len(s) < len(layout) {
+            continue
+        }
+        if t, err := time.Parse(layout, s[:len(layout)]); err == nil {
+            return t, true
+        }
+    }
+    return time.Time{}, false
+}
+
+func (t Tags) complete() bool {
+    return t.Title != "" && t.Artist != "" && t.Album != "" && t.Date != ""
+}
+
+// merge fills empty fields of a from b
+func merge(a, b Tags) Tags {
+    if a.Title == "" {
+        a.Title = b.Title
+    }
+    if a.Artist == "" {
+        a.Artist = b.Artist
+    }
+    if a.Album == "" {
+        a.Album = b.Album
+    }
+    if a.Date == "" {
+        a.Date = b.Date
+    }
+    if a.Lyrics == "" {
+        a.Lyrics = b.Lyrics
+    }
+    return a
+}
+
+// normalizeLines brings line breaks to "\n"
+func normalizeLines(s string) string {
+    s = strings.ReplaceAll(s, "\r\n", "\n")
+    s = strings.ReplaceAll(s, "\r", "\n")
+    return strings.TrimSpace(s)
+}
+
+// isMPEGFrame reports whether b starts with MPEG audio frame sync
+func isMPEGFrame(b []byte) bool {
+    return len(b) >= 2 && b[0] == 0xFF && b[1]&0xE0 == 0xE0
+}
+
+// isFLAC reports whether FLAC stream starts at the current position
+func isFLAC(r io.ReadSeeker) bool {
+    pos, err := r.Seek(0, io.SeekCurrent)
+    if err != nil {
+        return false
+    }
+    magic := make([]byte, 4)
+    _, err = io.ReadFull(r, magic)
+    r.Seek(pos, io.SeekStart)
+    return err == nil && bytes.Equal(magic, []byte("fLaC"))
+}
+
+// readN reads n bytes refusing sizes over maxTagSize
+func readN(r io.Reader, n int64) ([]byte, error) {
+    if n < 0 || n > maxTagSize {
+        return nil, fmt.Errorf("tag size %d is out of range", n)
+    }
+    b := make([]byte, n)
+    _, err := io.ReadFull(r, b)
+    return b, err
+}
@@ -0,0 +1,287 @@
+package audiotag
+
+import (
+    "bytes"
+    "compress/zlib"
+    "encoding/binary"
+    "errors"
+    "io"
+    "strings"
+    "unicode/utf16"
+)
+
+// Frames of the tag fields by ID3v2 version
+var id3Frames = map[byte]map[string]string{
+    2: {"TT2": "title", "TP1": "artist", "TAL": "album", "TYE": "year", "TDA": "daymonth", "ULT": "lyrics"},
+    3: {"TIT2": "title", "TPE1": "artist", "TALB": "album", "TYER": "year", "TDAT": "daymonth", "USLT": "lyrics"},
+    4: {"TIT2": "title", "TPE1": "artist", "TALB": "album", "TDRC": "date", "TYER": "year", "USLT": "lyrics"},
+}
+
+// readID3v2 reads ID3v2 tag at the start of the file
+// and leaves reader right after the tag
+func readID3v2(r io.Reader) (Tags, error) {
+    header := make([]byte, 10)
+    if _, err := io.ReadFull(r, header); err != nil {
+        return Tags{}, err
+    }
+    version, flags := header[3], header[5]
+    frames, ok := id3Frames[version]
+    if !ok {
+        return Tags{}, ErrUnsupported
+    }
+
+    data, err := readN(r, int64(syncsafe(header[6:10])))
+    if err != nil {
+        return Tags{}, err
+    }
+    if flags&0x10 != 0 {
+        // Footer is the copy of the header
+        io.CopyN(io.Discard, r, 10)
+    }
+
+    if version < 4 && flags&0x80 != 0 {
+        data = removeUnsync(data)
+    }
+
+    // ID3v2.2 has no extended header, the flag means compression
+    if flags&0x40 != 0 {
+        if version == 2 || len(data) < 4 {
+            return Tags{}, ErrUnsupported
+        }
+        skip := int(binary.BigEndian.Uint32(data)) + 4
+        if version == 4 {
+            skip = syncsafe(data[:4])
+        }
+        if skip > len(data) {
+            return Tags{}, errors.New("invalid id3 extended header")
+        }
+        data = data[skip:]
+    }
+
+    values := map[string]string{}
+    headerSize := 10
+    if version == 2 {
+        headerSize = 6
+    }
+    for len(data) >= headerSize && data[0] != 0 {
+        var id string
+        var size int
+        var frameFlags uint16
+        switch version {
+        case 2:
+            id = string(data[:3])
+            size = int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+        case 3:
+            id = string(data[:4])
+            size = int(binary.BigEndian.Uint32(data[4:8]))
+            frameFlags = binary.BigEndian.Uint16(data[8:10])
+        case 4:
+            id = string(data[:4])
+            size = syncsafe(data[4:8])
+            frameFlags = binary.BigEndian.Uint16(data[8:10])
+        }
+        if size > len(data)-headerSize {
+            break
+        }
+        body := data[headerSize : headerSize+size]
+        data = data[headerSize+size:]
+
+        field, ok := frames[id]
+        if !ok || values[field] != "" {
+            continue
+        }
+        body, ok = frameBody(version, frameFlags, body)
+        if !ok || len(body) == 0 {
+            continue
+        }
+
+        if field == "lyrics" {
+            values[field] = decodeLyrics(body)
+        } else {
+            values[field] = decodeText(body[0], body[1:])
+        }
+    }
+
+    tags := Tags{
+        Title:  values["title"],
+        Artist: values["artist"],
+        Album:  values["album"],
+        Date:   values["date"],
+        Lyrics: values["lyrics"],
+    }
+
+    // ID3v2.3 keeps year and "DDMM" in separate frames
+    if tags.Date == "" && values["year"] != "" {
+        tags.Date = values["year"]
+        if dm := values["daymonth"]; len(dm) == 4 {
+            tags.Date += "-" + dm[2:] + "-" + dm[:2]
+        }
+    }
+
+    return tags, nil
+}
+
+// frameBody removes frame decorations according to frame flags,
+// encrypted frames and frames that cannot be decoded are not ok
+func frameBody(version byte, flags uint16, body []byte) ([]byte, bool) {
+    var grouping, compression, encryption, unsync, dataLength bool
+    switch version {
+    case 3:
+        compression, encryption, grouping = flags&0x0080 != 0, flags&0x0040 != 0, flags&0x0020 != 0
+        // Decompressed size precedes compressed frame
+        dataLength = compression
+    case 4:
+        grouping, compression, encryption = flags&0x0040 != 0, flags&0x0008 != 0, flags&0x0004 != 0
+        unsync, dataLength = flags&0x0002 != 0, flags&0x0001 != 0
+    }
+    if encryption {
+        return nil, false
+    }
+
+    if version == 3 && dataLength {
+        if len(body) < 4 {
+            return nil, false
+        }
+        body = body[4:]
+    }
+    if grouping {
+        if len(body) < 1 {
+            return nil, false
+        }
+        body = body[1:]
+    }
+    if version == 4 && dataLength {
+        if len(body) < 4 {
+            return nil, false
+        }
+        body = body[4:]
+    }
+    if unsync {
+        body = removeUnsync(body)
+    }
+    if compression {
+        zr, err := zlib.NewReader(bytes.NewReader(body))
+        if err != nil {
+            return nil, false
+        }
+        defer zr.Close()
+        decompressed, err := io.ReadAll(io.LimitReader(zr, maxTagSize))
+        if err != nil {
+            return nil, false
+        }
+        body = decompressed
+    }
+    return body, true
+}
+
+// decodeLyrics decodes USLT / ULT frame: encoding,
+// language, content descriptor and the lyrics
+func decodeLyrics(body []byte) string {
+    if len(body) < 4 {
+        return ""
+    }
+    encoding, rest := body[0], body[4:]
+
+    // Skipping content descriptor
+    if encoding == 1 || encoding == 2 {
+        for i := 0; i+1 < len(rest); i += 2 {
+            if rest[i] == 0 && rest[i+1] == 0 {
+                rest = rest[i+2:]
+                break
+            }
+        }
+    } else if i := bytes.IndexByte(rest, 0); i >= 0 {
+        rest = rest[i+1:]
+    }
+
+    return decodeText(encoding, rest)
+}
+
+// decodeText decodes ID3 string of the encoding (ISO-8859-1,
+// UTF-16 with BOM, UTF-16BE, UTF-8). Only the first of
+// NUL separated values is returned.
+func decodeText(encoding byte, b []byte) string {
+    var s string
+    switch encoding {
+    case 0:
+        runes := make([]rune, len(b))
+        for i, c := range b {
+            runes[i] = rune(c)
+        }
+        s = string(runes)
+    case 1, 2:
+        s = decodeUTF16(b, encoding == 2)
+    case 3:
+        s = string(b)
+    default:
+        return ""
+    }
+
+    if i := strings.IndexByte(s, 0); i >= 0 {
+        s = s[:i]
+    }
+    return strings.TrimSpace(s)
+}
+
+func decodeUTF16(b []byte, bigEndian bool) string {
+    var order binary.ByteOrder = binary.LittleEndian
+    if bigEndian {
+        order = binary.BigEndian
+    }
+    if len(b) >= 2 {
+        switch {
+        case b[0] == 0xFE && b[1] == 0xFF:
+            order, b = binary.BigEndian, b[2:]
+        case b[0] == 0xFF && b[1] == 0xFE:
+            order, b = binary.LittleEndian, b[2:]
+        }
+    }
+
+    units := make([]uint16, 0, len(b)/2)
+    for i := 0; i+1 < len(b); i += 2 {
+        units = append(units, order.Uint16(b[i:]))
+    }
+    return string(utf16.Decode(units))
+}
+
+// syncsafe decodes 28-bit integer stored in 7 bits of 4 bytes
+func syncsafe(b []byte) int {
+    return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+// removeUnsync reverts unsynchronisation: 0xFF 0x00 becomes 0xFF
+func removeUnsync(b []byte) []byte {
+    out := make([]byte, 0, len(b))
+    for i := 0; i < len(b); i++ {
+        out = append(out, b[i])
+        if b[i] == 0xFF && i+1 < len(b) && b[i+1] == 0x00 {
+            i++
+        }
+    }
+    return out
+}
+
+// readID3v1 reads ID3v1 tag from the last 128 bytes of the file
+func readID3v1(r io.ReadSeeker) (Tags, error) {
+    if _, err := r.Seek(-128, io.SeekEnd); err != nil {
+        return Tags{}, err
+    }
+    b := make([]byte, 128)
+    if _, err := io.ReadFull(r, b); err != nil {
+        return Tags{}, err
+    }
+    if string(b[:3]) != "TAG" {
+        return Tags{}, ErrNoTags
+    }
+
+    field := func(from, to int) string {
+        s := decodeText(0, b[from:to])
+        return strings.TrimRight(s, " ")
+    }
+    return Tags{
+        Title:  field(3, 33),
+        Artist: field(33, 63),
+        Album:  field(63, 93),
+        Date:   field(93, 97),
+    }, nil
+}
@@ -0,0 +1,166 @@
+package audiotag
+
+import (
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "io"
+    "strings"
+)
+
+// Max number of Ogg pages read to find the comment header
+const maxOggPages = 1024
+
+// readFLAC reads Vorbis comment block of the FLAC stream
+func readFLAC(r io.Reader) (Tags, error) {
+    magic := make([]byte, 4)
+    if _, err := io.ReadFull(r, magic); err != nil || string(magic) != "fLaC" {
+        return Tags{}, ErrUnsupported
+    }
+
+    header := make([]byte, 4)
+    for {
+        if _, err := io.ReadFull(r, header); err != nil {
+            return Tags{}, err
+        }
+        last, blockType := header[0]&0x80 != 0, header[0]&0x7F
+        size := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+        if blockType == 4 {
+            block, err := readN(r, size)
+            if err != nil {
+                return Tags{}, err
+            }
+            return parseVorbisComment(block)
+        }
+        if _, err := io.CopyN(io.Discard, r, size); err != nil {
+            return Tags{}, err
+        }
+        if last {
+            return Tags{}, ErrNoTags
+        }
+    }
+}
+
+// readOgg reads comment header packet of the first
+// logical stream, Vorbis and Opus streams are supported
+func readOgg(r io.Reader) (Tags, error) {
+    header := make([]byte, 27)
+    var serial uint32
+    var packet []byte
+    packets := 0
+
+    for page := 0; page < maxOggPages; page++ {
+        if _, err := io.ReadFull(r, header); err != nil {
+            return Tags{}, err
+        }
+        if string(header[:4]) != "OggS" {
+            return Tags{}, errors.New("invalid ogg page")
+        }
+        pageSerial := binary.LittleEndian.Uint32(header[14:18])
+        if page == 0 {
+            serial = pageSerial
+        }
+
+        segments := make([]byte, header[26])
+        if _, err := io.ReadFull(r, segments); err != nil {
+            return Tags{}, err
+        }
+        for _, size := range segments {
+            segment, err := readN(r, int64(size))
+            if err != nil {
+                return Tags{}, err
+            }
+            // Pages of other multiplexed streams are skipped
+            if pageSerial != serial {
+                continue
+            }
+            packet = append(packet, segment...)
+            if len(packet) > maxTagSize {
+                return Tags{}, errors.New("ogg packet is too large")
+            }
+
+            // Segment shorter than 255 bytes ends the packet
+            if size == 255 {
+                continue
+            }
+            packets++
+            if packets == 2 {
+                return parseOggComment(packet)
+            }
+            packet = packet[:0]
+        }
+    }
+    return Tags{}, ErrNoTags
+}
+
+func parseOggComment(packet []byte) (Tags, error) {
+    switch {
+    case bytes.HasPrefix(packet, []byte("\x03vorbis")):
+        return parseVorbisComment(packet[7:])
+    case bytes.HasPrefix(packet, []byte("OpusTags")):
+        return parseVorbisComment(packet[8:])
+    }
+    return Tags{}, ErrUnsupported
+}
+
+// parseVorbisComment parses vendor string and
+// "KEY=value" comments with little-endian lengths
+func parseVorbisComment(b []byte) (Tags, error) {
+    errInvalid := errors.New("invalid vorbis comment")
+
+    next := func() ([]byte, bool) {
+        if len(b) < 4 {
+            return nil, false
+        }
+        n := binary.LittleEndian.Uint32(b)
+        if uint64(n) > uint64(len(b)-4) {
+            return nil, false
+        }
+        s := b[4 : 4+n]
+        b = b[4+n:]
+        return s, true
+    }
+
+    // Vendor string
+    if _, ok := next(); !ok {
+        return Tags{}, errInvalid
+    }
+    if len(b) < 4 {
+        return Tags{}, errInvalid
+    }
+    count := binary.LittleEndian.Uint32(b)
+    b = b[4:]
+
+    values := map[string]string{}
+    for i := uint32(0); i < count; i++ {
+        comment, ok := next()
+        if !ok {
+            return Tags{}, errInvalid
+        }
+        key, value, ok := strings.Cut(string(comment), "=")
+        if !ok {
+            continue
+        }
+        // First value wins for repeated keys (several artists)
+        key = strings.ToUpper(key)
+        if values[key] == "" {
+            values[key] = strings.TrimSpace(value)
+        }
+    }
+
+    tags := Tags{
+        Title:  values["TITLE"],
+        Artist: values["ARTIST"],
+        Album:  values["ALBUM"],
+        Date:   values["DATE"],
+        Lyrics: values["LYRICS"],
+    }
+    if tags.Date == "" {
+        tags.Date = values["YEAR"]
+    }
+    if tags.Lyrics == "" {
+        tags.Lyrics = values["UNSYNCEDLYRICS"]
+    }
+    return tags, nil
+}
@@ -0,0 +1,140 @@
+package chordpro
+
+import (
+    "fmt"
+    "strings"
+)
+
+var sharpNotes = []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+var flatNotes = []string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+
+// Chord is the parsed chord name (for example "F#m7/C#")
+type Chord struct {
+    Root    int    // Semitone of the root note (C = 0)
+    Quality string // Everything between root and bass ("m7", "sus4")
+    Bass    int    // Semitone of the bass note, -1 if none
+}
+
+// ParseChord parses the chord name.
+// "N.C." (no chord) is parsed as the chord with root -1.
+func ParseChord(s string) (Chord, error) {
+    if s == "N.C." || s == "NC" {
+        return Chord{Root: -1, Bass: -1}, nil
+    }
+
+    name, bass, hasBass := strings.Cut(s, "/")
+
+    root, n := parseNote(name)
+    if n == 0 {
+        return Chord{}, fmt.Errorf("invalid chord %q", s)
+    }
+    chord := Chord{Root: root, Quality: name[n:], Bass: -1}
+
+    for _, r := range chord.Quality {
+        if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+            strings.ContainsRune("#b+-()°ø^Δ", r)) {
+            return Chord{}, fmt.Errorf("invalid chord %q", s)
+        }
+    }
+
+    if hasBass {
+        b, n := parseNote(bass)
+        if n == 0 || n != len(bass) {
+            return Chord{}, fmt.Errorf("invalid chord %q", s)
+        }
+        chord.Bass = b
+    }
+
+    return chord, nil
+}
+
+// parseNote parses the note at the beginning of s.
+// Returns semitone and length of the note, zero length if none.
+func parseNote(s string) (int, int) {
+    if s == "" || s[0] < 'A' || s[0] > 'G' {
+        return 0, 0
+    }
+    semitone := map[byte]int{'C': 0, 'D': 2, 'E': 4, 'F': 5, 'G': 7, 'A': 9, 'B': 11}[s[0]]
+    if len(s) > 1 {
+        switch s[1] {
+        case '#':
+            return (semitone + 1) % 12, 2
+        case 'b':
+            return (semitone + 11) % 12, 2
+        }
+    }
+    return semitone, 1
+}
+
+// Transpose shifts the chord by n semitones
+func (c Chord) Transpose(n int) Chord {
+    if c.Root < 0 {
+        return c
+    }
+    c.Root = ((c.Root+n)%12 + 12) % 12
+    if c.Bass >= 0 {
+        c.Bass = ((c.Bass+n)%12 + 12) % 12
+    }
+    return c
+}
+
+// Format encodes the chord spelling accidentals
+// with flats or sharps
+func (c Chord) Format(flats bool) string {
+    if c.Root < 0 {
+        return "N.C."
+    }
+    notes := sharpNotes
+    if flats {
+        notes = flatNotes
+    }
+    s := notes[c.Root] + c.Quality
+    if c.Bass >= 0 {
+        s += "/" + notes[c.Bass]
+    }
+    return s
+}
+
+// Transpose returns the copy of the document with all chords
+// and the key shifted by n semitones. Accidentals are spelled
+// with flats if flats is set, with sharps otherwise.
+func (d Document) Transpose(n int, flats bool) Document {
+    transpose := func(s string) string {
+        chord, err := ParseChord(s)
+        if err != nil {
+            return s
+        }
+        return chord.Transpose(n).Format(flats)
+    }
+
+    out := Document{Sections: make([]Section, len(d.Sections))}
+    if d.Meta != nil {
+        out.Meta = make(map[string]string, len(d.Meta))
+        for key, value := range d.Meta {
+            out.Meta[key] = value
+        }
+        if key, ok := out.Meta["key"]; ok {
+            out.Meta["key"] = transpose(key)
+        }
+    }
+
+    for i, section := range d.Sections {
+        section.Lines = append([]Line(nil), section.Lines...)
+        for j, line := range section.Lines {
+            if section.Kind == KindTab || len(line.Segments) == 0 {
+                continue
+            }
+            segments := make([]Segment, len(line.Segments))
+            for k, segment := range line.Segments {
+                if segment.Chord != "" {
+                    segment.Chord = transpose(segment.Chord)
+                }
+                segments[k] = segment
+            }
+            section.Lines[j].Segments = segments
+        }
+        out.Sections[i] = section
+    }
+
+    return out
+}
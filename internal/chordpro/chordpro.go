@@ -0,0 +1,274 @@
+package chordpro
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+)
+
+// Section kinds
+const (
+    KindVerse  = "verse"
+    KindChorus = "chorus"
+    KindBridge = "bridge"
+    KindTab    = "tab"
+)
+
+// Segment is the piece of lyrics line
+// with the chord that is played over it
+type Segment struct {
+    Chord string `json:"chord,omitempty"`
+    Lyric string `json:"lyric"`
+}
+
+// Line is the single line of the section.
+// Comment lines ({comment: ...}) have no segments.
+type Line struct {
+    Comment  string    `json:"comment,omitempty"`
+    Segments []Segment `json:"segments,omitempty"`
+}
+
+// Section is the verse, chorus, bridge or tab of the chord sheet.
+// Sections are the pages of the chord sheet.
+type Section struct {
+    Kind  string `json:"kind"`
+    Label string `json:"label,omitempty"`
+    Lines []Line `json:"lines"`
+}
+
+// Document is the parsed ChordPro chord sheet
+type Document struct {
+    Meta     map[string]string `json:"meta,omitempty"`
+    Sections []Section         `json:"sections"`
+}
+
+// ParseError describes the invalid line of ChordPro document
+type ParseError struct {
+    Line int
+    Msg  string
+}
+
+func (e *ParseError) Error() string {
+    return fmt.Sprintf("chordpro: line %d: %s", e.Line, e.Msg)
+}
+
+// ErrEmpty is returned when document has no sections
+var ErrEmpty = errors.New("chordpro: empty document")
+
+// Short forms of directives
+var directiveAliases = map[string]string{
+    "t":   "title",
+    "st":  "subtitle",
+    "c":   "comment",
+    "ci":  "comment_italic",
+    "cb":  "comment_box",
+    "soc": "start_of_chorus",
+    "eoc": "end_of_chorus",
+    "sov": "start_of_verse",
+    "eov": "end_of_verse",
+    "sob": "start_of_bridge",
+    "eob": "end_of_bridge",
+    "sot": "start_of_tab",
+    "eot": "end_of_tab",
+}
+
+// Directives that are stored in the document meta
+var metaDirectives = map[string]bool{
+    "title":    true,
+    "subtitle": true,
+    "artist":   true,
+    "composer": true,
+    "lyricist": true,
+    "album":    true,
+    "year":     true,
+    "key":      true,
+    "capo":     true,
+    "tempo":    true,
+    "time":     true,
+    "duration": true,
+}
+
+// Parse parses and validates ChordPro document.
+// Lines outside of environments are grouped in verses
+// that are separated by blank lines.
+func Parse(s string) (Document, error) {
+    doc := Document{Meta: map[string]string{}}
+
+    var current *Section
+    // Kind of the opened environment, empty if none
+    env := ""
+    lastChorus := -1
+
+    flush := func() {
+        if current != nil && len(current.Lines) > 0 {
+            doc.Sections = append(doc.Sections, *current)
+            if current.Kind == KindChorus {
+                lastChorus = len(doc.Sections) - 1
+            }
+        }
+        current = nil
+    }
+
+    for i, raw := range strings.Split(strings.TrimPrefix(s, "\ufeff"), "\n") {
+        n := i + 1
+        raw = strings.TrimRight(raw, "\r")
+        trimmed := strings.TrimSpace(raw)
+
+        // Tab lines are kept as is
+        if env == KindTab && !strings.HasPrefix(trimmed, "{") {
+            current.Lines = append(current.Lines, Line{Segments: []Segment{{Lyric: raw}}})
+            continue
+        }
+
+        switch {
+        case strings.HasPrefix(trimmed, "#"):
+            continue
+        case trimmed == "":
+            if env == "" {
+                flush()
+            }
+            continue
+        case strings.HasPrefix(trimmed, "{"):
+            if !strings.HasSuffix(trimmed, "}") {
+                return Document{}, &ParseError{Line: n, Msg: "unclosed directive"}
+            }
+            name, value, _ := strings.Cut(trimmed[1:len(trimmed)-1], ":")
+            name = strings.ToLower(strings.TrimSpace(name))
+            value = strings.TrimSpace(value)
+            if alias, ok := directiveAliases[name]; ok {
+                name = alias
+            }
+
+            switch {
+            case metaDirectives[name]:
+                doc.Meta[name] = value
+            case strings.HasPrefix(name, "x_"):
+                // Custom directives are allowed by the spec and ignored
+            case name == "comment" || name == "comment_italic" || name == "comment_box":
+                if current == nil {
+                    current = &Section{Kind: KindVerse}
+                }
+                current.Lines = append(current.Lines, Line{Comment: value})
+            case strings.HasPrefix(name, "start_of_"):
+                kind := strings.TrimPrefix(name, "start_of_")
+                if !isKind(kind) {
+                    return Document{}, &ParseError{Line: n, Msg: fmt.Sprintf("unknown directive %q", name)}
+                }
+                if env != "" {
+                    return Document{}, &ParseError{Line: n, Msg: "nested " + kind + " inside " + env}
+                }
+                flush()
+                env = kind
+                current = &Section{Kind: kind, Label: value}
+            case strings.HasPrefix(name, "end_of_"):
+                kind := strings.TrimPrefix(name, "end_of_")
+                if !isKind(kind) {
+                    return Document{}, &ParseError{Line: n, Msg: fmt.Sprintf("unknown directive %q", name)}
+                }
+                if env != kind {
+                    return Document{}, &ParseError{Line: n, Msg: "unexpected end of " + kind}
+                }
+                env = ""
+                flush()
+            case name == "chorus":
+                // Repeating of the last chorus
+                if lastChorus < 0 {
+                    return Document{}, &ParseError{Line: n, Msg: "chorus reference before any chorus"}
+                }
+                if env != "" {
+                    return Document{}, &ParseError{Line: n, Msg: "chorus reference inside " + env}
+                }
+                flush()
+                chorus := doc.Sections[lastChorus]
+                if value != "" {
+                    chorus.Label = value
+                }
+                doc.Sections = append(doc.Sections, chorus)
+            default:
+                return Document{}, &ParseError{Line: n, Msg: fmt.Sprintf("unknown directive %q", name)}
+            }
+        default:
+            segments, err := parseLine(raw)
+            if err != nil {
+                return Document{}, &ParseError{Line: n, Msg: err.Error()}
+            }
+            if current == nil {
+                current = &Section{Kind: KindVerse}
+            }
+            current.Lines = append(current.Lines, Line{Segments: segments})
+        }
+    }
+
+    if env != "" {
+        return Document{}, &ParseError{Line: strings.Count(s, "\n") + 1, Msg: "unclosed " + env}
+    }
+    flush()
+
+    if len(doc.Sections) == 0 {
+        return Document{}, ErrEmpty
+    }
+    if len(doc.Meta) == 0 {
+        doc.Meta = nil
+    }
+
+    return doc, nil
+}
+
+// parseLine splits lyrics line with inline chords ("[Am]Hello [F]world")
+func parseLine(s string) ([]Segment, error) {
+    var segments []Segment
+
+    // Text before the first chord
+    i := strings.IndexByte(s, '[')
+    if i < 0 {
+        i = len(s)
+    }
+    if strings.ContainsRune(s[:i], ']') {
+        return nil, errors.New("unexpected ]")
+    }
+    if i > 0 {
+        segments = append(segments, Segment{Lyric: s[:i]})
+    }
+
+    rest := s[i:]
+    for rest != "" {
+        end := strings.IndexByte(rest, ']')
+        if end < 0 {
+            return nil, errors.New("unclosed chord")
+        }
+        chord := strings.TrimSpace(rest[1:end])
+        if _, err := ParseChord(chord); err != nil {
+            return nil, err
+        }
+        rest = rest[end+1:]
+
+        next := strings.IndexByte(rest, '[')
+        if next < 0 {
+            next = len(rest)
+        }
+        if strings.ContainsRune(rest[:next], ']') {
+            return nil, errors.New("unexpected ]")
+        }
+        segments = append(segments, Segment{Chord: chord, Lyric: rest[:next]})
+        rest = rest[next:]
+    }
+
+    return segments, nil
+}
+
+func isKind(kind string) bool {
+    switch kind {
+    case KindVerse, KindChorus, KindBridge, KindTab:
+        return true
+    }
+    return false
+}
+
+// Page returns the document that contains only
+// the section with the 1-based index page
+func (d Document) Page(page int) (Document, error) {
+    if page < 1 || page > len(d.Sections) {
+        return Document{}, errors.New("page out of range")
+    }
+    return Document{Meta: d.Meta, Sections: d.Sections[page-1 : page]}, nil
+}
@@ -0,0 +1,185 @@
+package chordpro
+
+import (
+    "fmt"
+    "html"
+    "sort"
+    "strings"
+    "unicode/utf8"
+)
+
+// String encodes the document back to ChordPro
+func (d Document) String() string {
+    var b strings.Builder
+
+    keys := make([]string, 0, len(d.Meta))
+    for key := range d.Meta {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        fmt.Fprintf(&b, "{%s: %s}\n", key, d.Meta[key])
+    }
+
+    for i, section := range d.Sections {
+        if i > 0 || len(keys) > 0 {
+            b.WriteByte('\n')
+        }
+
+        // Plain verses are written without environment
+        env := section.Kind != KindVerse || section.Label != ""
+        if env {
+            b.WriteString("{start_of_" + section.Kind)
+            if section.Label != "" {
+                b.WriteString(": " + section.Label)
+            }
+            b.WriteString("}\n")
+        }
+
+        for _, line := range section.Lines {
+            if len(line.Segments) == 0 {
+                fmt.Fprintf(&b, "{comment: %s}\n", line.Comment)
+                continue
+            }
+            for _, segment := range line.Segments {
+                if segment.Chord != "" {
+                    b.WriteString("[" + segment.Chord + "]")
+                }
+                b.WriteString(segment.Lyric)
+            }
+            b.WriteByte('\n')
+        }
+
+        if env {
+            b.WriteString("{end_of_" + section.Kind + "}\n")
+        }
+    }
+
+    return b.String()
+}
+
+// Text renders the document as plain text
+// with chords placed above lyrics
+func (d Document) Text() string {
+    var b strings.Builder
+
+    for _, key := range []string{"title", "subtitle", "artist", "key", "capo"} {
+        value, ok := d.Meta[key]
+        if !ok {
+            continue
+        }
+        if key == "title" || key == "subtitle" {
+            b.WriteString(value + "\n")
+            continue
+        }
+        b.WriteString(strings.ToUpper(key[:1]) + key[1:] + ": " + value + "\n")
+    }
+
+    for i, section := range d.Sections {
+        if i > 0 || b.Len() > 0 {
+            b.WriteByte('\n')
+        }
+        if label := sectionLabel(section); label != "" {
+            b.WriteString(label + ":\n")
+        }
+
+        for _, line := range section.Lines {
+            if len(line.Segments) == 0 {
+                b.WriteString("(" + line.Comment + ")\n")
+                continue
+            }
+
+            var chords, lyrics strings.Builder
+            hasChords := false
+            for _, segment := range line.Segments {
+                lyric := segment.Lyric
+                if segment.Chord != "" {
+                    hasChords = true
+                    // Lyric is padded so the next chord
+                    // is not glued to the current one
+                    width := utf8.RuneCountInString(segment.Chord) + 1
+                    if n := utf8.RuneCountInString(lyric); n < width {
+                        lyric += strings.Repeat(" ", width-n)
+                    }
+                }
+                chords.WriteString(segment.Chord)
+                chords.WriteString(strings.Repeat(" ",
+                    utf8.RuneCountInString(lyric)-utf8.RuneCountInString(segment.Chord)))
+                lyrics.WriteString(lyric)
+            }
+
+            if hasChords {
+                b.WriteString(strings.TrimRight(chords.String(), " ") + "\n")
+            }
+            if text := strings.TrimRight(lyrics.String(), " "); text != "" || !hasChords {
+                b.WriteString(text + "\n")
+            }
+        }
+    }
+
+    return b.String()
+}
+
+// HTML renders the document as HTML fragment.
+// Chords and lyrics are wrapped in spans so
+// chords can be positioned above lyrics with CSS.
+func (d Document) HTML() string {
+    var b strings.Builder
+
+    b.WriteString(`<div class="chordpro">` + "\n")
+    if title, ok := d.Meta["title"]; ok {
+        b.WriteString("<h1>" + html.EscapeString(title) + "</h1>\n")
+    }
+    if subtitle, ok := d.Meta["subtitle"]; ok {
+        b.WriteString("<h2>" + html.EscapeString(subtitle) + "</h2>\n")
+    }
+    for _, key := range []string{"artist", "key", "capo", "tempo"} {
+        if value, ok := d.Meta[key]; ok {
+            fmt.Fprintf(&b, `<p class="meta %s">%s</p>`+"\n", key, html.EscapeString(value))
+        }
+    }
+
+    for _, section := range d.Sections {
+        fmt.Fprintf(&b, `<section class="%s">`+"\n", section.Kind)
+        if label := sectionLabel(section); label != "" {
+            b.WriteString(`<h3 class="label">` + html.EscapeString(label) + "</h3>\n")
+        }
+
+        for _, line := range section.Lines {
+            if len(line.Segments) == 0 {
+                b.WriteString(`<p class="comment">` + html.EscapeString(line.Comment) + "</p>\n")
+                continue
+            }
+            if section.Kind == KindTab {
+                b.WriteString("<pre>" + html.EscapeString(line.Segments[0].Lyric) + "</pre>\n")
+                continue
+            }
+
+            b.WriteString(`<div class="line">`)
+            for _, segment := range line.Segments {
+                b.WriteString(`<span class="chunk">`)
+                if segment.Chord != "" {
+                    b.WriteString(`<span class="chord">` + html.EscapeString(segment.Chord) + "</span>")
+                }
+                b.WriteString(`<span class="lyric">` + html.EscapeString(segment.Lyric) + "</span>")
+                b.WriteString("</span>")
+            }
+            b.WriteString("</div>\n")
+        }
+
+        b.WriteString("</section>\n")
+    }
+    b.WriteString("</div>\n")
+
+    return b.String()
+}
+
+func sectionLabel(section Section) string {
+    if section.Label != "" {
+        return section.Label
+    }
+    if section.Kind == KindVerse {
+        return ""
+    }
+    return strings.ToUpper(section.Kind[:1]) + section.Kind[1:]
+}
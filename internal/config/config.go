@@ -11,6 +11,8 @@ type Config struct {
     // App environment (dev, prod)
     Env               string
     SongDetailsApiUrl string
+    // Longest time of the song details API request
+    SongDetailsApiTimeout time.Duration
 
     Server struct {
         Addr string
@@ -19,6 +21,12 @@ type Config struct {
         ReadTimeout  time.Duration
         WriteTimeout time.Duration
         IdleTimeout  time.Duration
+        // How long the server is not ready before it stops
+        // accepting requests, so load balancers notice it
+        ShutdownDelay time.Duration
+        // How long in-flight requests and background
+        // jobs are waited for on shutdown
+        ShutdownTimeout time.Duration
     }
 
     Db struct {
@@ -31,6 +39,94 @@ type Config struct {
 
         MigrationsPath string // Path string ("./migrations")
     }
+
+    Lyrics struct {
+        // Directory with <language>.txt explicit word lists ("./wordlists")
+        ExplicitWordListsPath string
+        // Directory with <language>.txt stop word lists ("./stopwords")
+        StopWordsPath string
+    }
+
+    Duplicates struct {
+        // What to do with duplicate on create (reject / warn / allow)
+        Policy string
+        // Lyrics similarity from 0 to 1 that counts as the same lyrics
+        LyricsThreshold float64
+    }
+
+    Batch struct {
+        // Max number of songs in POST /songs:batch
+        MaxSize int
+        // Number of songs enriched from external API at once
+        Concurrency int
+    }
+
+    Playlists struct {
+        // What to do with entries of deleted songs (remove / flag)
+        DeletedSongs string
+    }
+
+    Auth struct {
+        // How bearer tokens are checked (none / apikey / jwt),
+        // modes are combined with comma ("apikey,jwt")
+        Mode string
+        // Read routes do not require a token (true / false)
+        PublicReads bool
+    }
+
+    Jwt struct {
+        // JWKS file and PEM file with public keys or certificates,
+        // either may be empty but not both in jwt mode
+        JwksPath       string
+        PublicKeysPath string
+        // Expected "iss" and "aud" claims, not checked if empty
+        Issuer   string
+        Audience string
+        // Allowed clock skew of the token times
+        Leeway time.Duration
+
+        // Dotted path of the roles claim ("realm_access.roles")
+        RolesClaim string
+        // Claim values of the viewer (read), editor (write) and admin roles
+        ViewerRole string
+        EditorRole string
+        AdminRole  string
+    }
+
+    Audit struct {
+        // Age of the audit events to delete, 0 keeps them forever
+        Retention time.Duration
+    }
+
+    RateLimit struct {
+        // Requests per second and bucket size of every client
+        // for reads (GET, HEAD) and writes, 0 rate disables the limit
+        ReadRate   float64
+        ReadBurst  int
+        WriteRate  float64
+        WriteBurst int
+        // Comma separated proxy addresses or CIDRs whose
+        // X-Forwarded-For is trusted ("10.0.0.0/8,127.0.0.1")
+        TrustedProxies string
+    }
+
+    Tracing struct {
+        // Where finished spans go (none / otlp / stderr / file)
+        Exporter string
+        // OTLP/HTTP traces endpoint ("http://localhost:4318/v1/traces")
+        OtlpEndpoint string
+        // File the spans are appended to as OTLP JSON lines
+        FilePath string
+        // Ratio of new traces that are recorded, from 0 to 1
+        SampleRatio float64
+    }
+
+    Health struct {
+        // Longest time of the readiness checks
+        Timeout time.Duration
+        // Readiness depends on the song details API (true / false)
+        CheckEnrichment bool
+    }
 }
 
 func NewConfig() *Config {
@@ -39,13 +135,16 @@ func NewConfig() *Config {
 
 func (cfg *Config) LoadFromEnv() error {
     cfgPtrByEnv := map[string]interface{}{
-        "SL_SONG_DETAILS_API_URL": &cfg.SongDetailsApiUrl,
-        "SL_ENV":                  &cfg.Env,
+        "SL_SONG_DETAILS_API_URL":     &cfg.SongDetailsApiUrl,
+        "SL_SONG_DETAILS_API_TIMEOUT": &cfg.SongDetailsApiTimeout,
+        "SL_ENV":                      &cfg.Env,
 
-        "SL_SRV_ADDR":          &cfg.Server.Addr,
-        "SL_SRV_READ_TIMEOUT":  &cfg.Server.ReadTimeout,
-        "SL_SRV_WRITE_TIMEOUT": &cfg.Server.WriteTimeout,
-        "SL_SRV_IDLE_TIMEOUT":  &cfg.Server.IdleTimeout,
+        "SL_SRV_ADDR":             &cfg.Server.Addr,
+        "SL_SRV_READ_TIMEOUT":     &cfg.Server.ReadTimeout,
+        "SL_SRV_WRITE_TIMEOUT":    &cfg.Server.WriteTimeout,
+        "SL_SRV_IDLE_TIMEOUT":     &cfg.Server.IdleTimeout,
+        "SL_SRV_SHUTDOWN_DELAY":   &cfg.Server.ShutdownDelay,
+        "SL_SRV_SHUTDOWN_TIMEOUT": &cfg.Server.ShutdownTimeout,
 
         "SL_DB_HOST":            &cfg.Db.Host,
         "SL_DB_PORT":            &cfg.Db.Port,
@@ -54,6 +153,46 @@ func (cfg *Config) LoadFromEnv() error {
         "SL_DB_DATABASE":        &cfg.Db.Database,
         "SL_DB_SSL_MODE":        &cfg.Db.SSLMode,
         "SL_DB_MIGRATIONS_PATH": &cfg.Db.MigrationsPath,
+
+        "SL_LYRICS_EXPLICIT_WORDLISTS_PATH": &cfg.Lyrics.ExplicitWordListsPath,
+        "SL_LYRICS_STOPWORDS_PATH":          &cfg.Lyrics.StopWordsPath,
+
+        "SL_DUPLICATE_POLICY":           &cfg.Duplicates.Policy,
+        "SL_DUPLICATE_LYRICS_THRESHOLD": &cfg.Duplicates.LyricsThreshold,
+
+        "SL_BATCH_MAX_SIZE":    &cfg.Batch.MaxSize,
+        "SL_BATCH_CONCURRENCY": &cfg.Batch.Concurrency,
+
+        "SL_PLAYLIST_DELETED_SONGS": &cfg.Playlists.DeletedSongs,
+
+        "SL_AUTH_MODE":         &cfg.Auth.Mode,
+        "SL_AUTH_PUBLIC_READS": &cfg.Auth.PublicReads,
+
+        "SL_JWT_JWKS_PATH":        &cfg.Jwt.JwksPath,
+        "SL_JWT_PUBLIC_KEYS_PATH": &cfg.Jwt.PublicKeysPath,
+        "SL_JWT_ISSUER":           &cfg.Jwt.Issuer,
+        "SL_JWT_AUDIENCE":         &cfg.Jwt.Audience,
+        "SL_JWT_LEEWAY":           &cfg.Jwt.Leeway,
+        "SL_JWT_ROLES_CLAIM":      &cfg.Jwt.RolesClaim,
+        "SL_JWT_ROLE_VIEWER":      &cfg.Jwt.ViewerRole,
+        "SL_JWT_ROLE_EDITOR":      &cfg.Jwt.EditorRole,
+        "SL_JWT_ROLE_ADMIN":       &cfg.Jwt.AdminRole,
+
+        "SL_AUDIT_RETENTION": &cfg.Audit.Retention,
+
+        "SL_RATE_LIMIT_READ_RATE":       &cfg.RateLimit.ReadRate,
+        "SL_RATE_LIMIT_READ_BURST":      &cfg.RateLimit.ReadBurst,
+        "SL_RATE_LIMIT_WRITE_RATE":      &cfg.RateLimit.WriteRate,
+        "SL_RATE_LIMIT_WRITE_BURST":     &cfg.RateLimit.WriteBurst,
+        "SL_RATE_LIMIT_TRUSTED_PROXIES": &cfg.RateLimit.TrustedProxies,
+
+        "SL_TRACING_EXPORTER":      &cfg.Tracing.Exporter,
+        "SL_TRACING_OTLP_ENDPOINT": &cfg.Tracing.OtlpEndpoint,
+        "SL_TRACING_FILE_PATH":     &cfg.Tracing.FilePath,
+        "SL_TRACING_SAMPLE_RATIO":  &cfg.Tracing.SampleRatio,
+
+        "SL_HEALTH_TIMEOUT":          &cfg.Health.Timeout,
+        "SL_HEALTH_CHECK_ENRICHMENT": &cfg.Health.CheckEnrichment,
     }
 
     for env, ptr := range cfgPtrByEnv {
@@ -72,6 +211,20 @@ func (cfg *Config) LoadFromEnv() error {
             }
 
             *field = n
+        case *float64:
+            f, err := strconv.ParseFloat(temp, 64)
+            if err != nil {
+                return err
+            }
+
+            *field = f
+        case *bool:
+            b, err := strconv.ParseBool(temp)
+            if err != nil {
+                return err
+            }
+
+            *field = b
         case *time.Duration:
             duration, err := time.ParseDuration(temp)
             if err != nil {
@@ -0,0 +1,188 @@
+package explicit
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "unicode"
+    "unicode/utf8"
+)
+
+// wordList is the explicit words of one language.
+// Words ending with "*" in the file match by prefix.
+type wordList struct {
+    words    map[string]bool
+    prefixes []string
+}
+
+func (l wordList) match(word string) bool {
+    if l.words[word] {
+        return true
+    }
+    for _, prefix := range l.prefixes {
+        if strings.HasPrefix(word, prefix) {
+            return true
+        }
+    }
+    return false
+}
+
+// Scanner finds explicit words in the song text
+// using word lists of the languages
+type Scanner struct {
+    lists map[string]wordList
+}
+
+// Result is the result of the text scanning
+type Result struct {
+    Explicit bool
+    // 1-based indices of verses with explicit words
+    Verses []int
+}
+
+// Load is the constructor for Scanner that loads
+// word lists from <dir>/<language>.txt files.
+// Each line of the file is a word, lines starting with "#" are comments.
+func Load(dir string) (*Scanner, error) {
+    // Missing directory is the error rather than no lists,
+    // so a mistyped path does not turn off explicit detection
+    info, err := os.Stat(dir)
+    if err != nil {
+        return nil, err
+    }
+    if !info.IsDir() {
+        return nil, fmt.Errorf("explicit: %s is not a directory", dir)
+    }
+
+    paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+    if err != nil {
+        return nil, err
+    }
+
+    s := &Scanner{lists: map[string]wordList{}}
+    for _, path := range paths {
+        list, err := loadWordList(path)
+        if err != nil {
+            return nil, err
+        }
+        lang := strings.ToLower(strings.TrimSuffix(filepath.Base(path), ".txt"))
+        s.lists[lang] = list
+    }
+
+    return s, nil
+}
+
+func loadWordList(path string) (wordList, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return wordList{}, err
+    }
+    defer f.Close()
+
+    list := wordList{words: map[string]bool{}}
+    scanner := bufio.NewScanner(f)
+    for scanner.Scan() {
+        word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+        if word == "" || strings.HasPrefix(word, "#") {
+            continue
+        }
+        if prefix, ok := strings.CutSuffix(word, "*"); ok {
+            list.prefixes = append(list.prefixes, prefix)
+            continue
+        }
+        list.words[word] = true
+    }
+
+    return list, scanner.Err()
+}
+
+// Languages returns languages that have word lists
+func (s *Scanner) Languages() []string {
+    langs := make([]string, 0, len(s.lists))
+    for lang := range s.lists {
+        langs = append(langs, lang)
+    }
+    return langs
+}
+
+// Scan finds verses with explicit words. If lang has no word list
+// (or is empty), word lists of all languages are used.
+func (s *Scanner) Scan(text, lang string) Result {
+    var result Result
+    for i, verse := range strings.Split(text, "\n\n") {
+        for _, span := range words(verse) {
+            if s.match(strings.ToLower(verse[span[0]:span[1]]), lang) {
+                result.Explicit = true
+                result.Verses = append(result.Verses, i+1)
+                break
+            }
+        }
+    }
+    return result
+}
+
+// Mask replaces all letters of explicit words
+// except the first one with asterisks
+func (s *Scanner) Mask(text, lang string) string {
+    var b strings.Builder
+    last := 0
+    for _, span := range words(text) {
+        word := text[span[0]:span[1]]
+        if !s.match(strings.ToLower(word), lang) {
+            continue
+        }
+        _, size := utf8.DecodeRuneInString(word)
+        b.WriteString(text[last:span[0]])
+        b.WriteString(word[:size])
+        b.WriteString(strings.Repeat("*", utf8.RuneCountInString(word)-1))
+        last = span[1]
+    }
+    b.WriteString(text[last:])
+    return b.String()
+}
+
+func (s *Scanner) match(word, lang string) bool {
+    // Matching regional tags by base language ("en-US" by "en")
+    base, _, _ := strings.Cut(strings.ToLower(lang), "-")
+    if list, ok := s.lists[base]; ok {
+        return list.match(word)
+    }
+    for _, list := range s.lists {
+        if list.match(word) {
+            return true
+        }
+    }
+    return false
+}
+
+// words returns byte offsets of the words of the text.
+// Apostrophes inside words are the part of the word.
+func words(text string) [][2]int {
+    var spans [][2]int
+    start := -1
+    for i, r := range text {
+        letter := unicode.IsLetter(r) || unicode.IsDigit(r)
+        inner := (r == '\'' || r == '’') && start >= 0
+        if letter || inner {
+            if start < 0 {
+                start = i
+            }
+            continue
+        }
+        if start >= 0 {
+            spans = append(spans, [2]int{start, i})
+            start = -1
+        }
+    }
+    if start >= 0 {
+        spans = append(spans, [2]int{start, len(text)})
+    }
+
+    // Trailing apostrophes are not the part of the word
+    for i, span := range spans {
+        spans[i][1] = span[0] + len(strings.TrimRight(text[span[0]:span[1]], "'’"))
+    }
+    return spans
+}
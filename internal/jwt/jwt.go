@@ -0,0 +1,263 @@
+// Package jwt verifies signed JSON Web Tokens (JWS compact
+// serialization) with keys of the JWKS or PEM files.
+package jwt
+
+import (
+    "bytes"
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "crypto/hmac"
+    "crypto/rsa"
+    "crypto/sha256"
+    "crypto/sha512"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math/big"
+    "slices"
+    "strings"
+    "time"
+)
+
+// ErrInvalid is wrapped by all errors of the token verification
+var ErrInvalid = errors.New("invalid token")
+
+// Claims are the claims of the verified token
+type Claims map[string]any
+
+// Subject returns "sub" claim
+func (c Claims) Subject() string {
+    s, _ := c["sub"].(string)
+    return s
+}
+
+// Strings returns values of the claim at the dotted path
+// ("realm_access.roles"). String claims are split by spaces
+// the way "scope" claim is.
+func (c Claims) Strings(path string) []string {
+    var value any = map[string]any(c)
+    for _, name := range strings.Split(path, ".") {
+        m, ok := value.(map[string]any)
+        if !ok {
+            return nil
+        }
+        value = m[name]
+    }
+
+    switch v := value.(type) {
+    case string:
+        return strings.Fields(v)
+    case []any:
+        values := make([]string, 0, len(v))
+        for _, item := range v {
+            if s, ok := item.(string); ok {
+                values = append(values, s)
+            }
+        }
+        return values
+    }
+    return nil
+}
+
+// Verifier checks signature and registered claims of tokens
+type Verifier struct {
+    Keys KeySet
+    // Expected "iss" and one of "aud" values, not checked if empty
+    Issuer   string
+    Audience string
+    // Allowed clock skew of "exp", "nbf" and "iat"
+    Leeway time.Duration
+}
+
+type header struct {
+    Alg string `json:"alg"`
+    Kid string `json:"kid"`
+    Typ string `json:"typ"`
+}
+
+// Verify returns claims of the valid token. Tokens must
+// have "exp" claim, unsigned tokens are never accepted.
+func (v Verifier) Verify(token string) (Claims, error) {
+    parts := strings.Split(token, ".")
+    if len(parts) != 3 {
+        return nil, invalid("malformed token")
+    }
+
+    var h header
+    if err := decodeSegment(parts[0], &h); err != nil {
+        return nil, invalid("malformed header")
+    }
+    signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+    if err != nil {
+        return nil, invalid("malformed signature")
+    }
+
+    signed := []byte(parts[0] + "." + parts[1])
+    if err := v.verifySignature(h, signed, signature); err != nil {
+        return nil, err
+    }
+
+    var claims Claims
+    if err := decodeSegment(parts[1], &claims); err != nil {
+        return nil, invalid("malformed claims")
+    }
+    if err := v.verifyClaims(claims, time.Now()); err != nil {
+        return nil, err
+    }
+
+    return claims, nil
+}
+
+// verifySignature tries keys of the token key id, or all
+// keys if the token has none, that suit the algorithm
+func (v Verifier) verifySignature(h header, signed, signature []byte) error {
+    tried := false
+    for _, key := range v.Keys {
+        if h.Kid != "" && key.Kid != "" && key.Kid != h.Kid {
+            continue
+        }
+        ok, suits := verify(h.Alg, key.Public, signed, signature)
+        if ok {
+            return nil
+        }
+        tried = tried || suits
+    }
+
+    if !tried {
+        return invalid(fmt.Sprintf("no key for algorithm %q and key id %q", h.Alg, h.Kid))
+    }
+    return invalid("signature mismatch")
+}
+
+// verify checks the signature with the key, suits
+// is false if the key cannot be used with the algorithm
+func verify(alg string, key crypto.PublicKey, signed, signature []byte) (ok bool, suits bool) {
+    var hash crypto.Hash
+    switch alg[min(2, len(alg)):] {
+    case "256":
+        hash = crypto.SHA256
+    case "384":
+        hash = crypto.SHA384
+    case "512":
+        hash = crypto.SHA512
+    }
+
+    switch k := key.(type) {
+    case *rsa.PublicKey:
+        if hash == 0 {
+            return false, false
+        }
+        digest := digest(hash, signed)
+        switch alg[:2] {
+        case "RS":
+            return rsa.VerifyPKCS1v15(k, hash, digest, signature) == nil, true
+        case "PS":
+            opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+            return rsa.VerifyPSS(k, hash, digest, signature, opts) == nil, true
+        }
+    case *ecdsa.PublicKey:
+        // Algorithm is bound to the curve (ES256 to P-256)
+        size := (k.Curve.Params().BitSize + 7) / 8
+        curveAlg := map[int]string{32: "ES256", 48: "ES384", 66: "ES512"}[size]
+        if alg != curveAlg {
+            return false, false
+        }
+        if len(signature) != 2*size {
+            return false, true
+        }
+        r := new(big.Int).SetBytes(signature[:size])
+        s := new(big.Int).SetBytes(signature[size:])
+        return ecdsa.Verify(k, digest(hash, signed), r, s), true
+    case ed25519.PublicKey:
+        if alg != "EdDSA" {
+            return false, false
+        }
+        return ed25519.Verify(k, signed, signature), true
+    case []byte:
+        if !strings.HasPrefix(alg, "HS") || hash == 0 {
+            return false, false
+        }
+        mac := hmac.New(hash.New, k)
+        mac.Write(signed)
+        return hmac.Equal(mac.Sum(nil), signature), true
+    }
+    return false, false
+}
+
+func digest(hash crypto.Hash, b []byte) []byte {
+    switch hash {
+    case crypto.SHA384:
+        sum := sha512.Sum384(b)
+        return sum[:]
+    case crypto.SHA512:
+        sum := sha512.Sum512(b)
+        return sum[:]
+    }
+    sum := sha256.Sum256(b)
+    return sum[:]
+}
+
+func (v Verifier) verifyClaims(claims Claims, now time.Time) error {
+    exp, ok := numericDate(claims["exp"])
+    if !ok {
+        return invalid("exp claim is missing")
+    }
+    if now.After(exp.Add(v.Leeway)) {
+        return invalid("token is expired")
+    }
+    if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(v.Leeway).Before(nbf) {
+        return invalid("token is not valid yet")
+    }
+    if iat, ok := numericDate(claims["iat"]); ok && now.Add(v.Leeway).Before(iat) {
+        return invalid("token is issued in the future")
+    }
+
+    if v.Issuer != "" {
+        if iss, _ := claims["iss"].(string); iss != v.Issuer {
+            return invalid("unexpected issuer")
+        }
+    }
+    if v.Audience != "" {
+        // Audience is a string or an array of strings
+        audience := claims.Strings("aud")
+        if aud, ok := claims["aud"].(string); ok {
+            audience = []string{aud}
+        }
+        if !slices.Contains(audience, v.Audience) {
+            return invalid("unexpected audience")
+        }
+    }
+
+    return nil
+}
+
+// numericDate parses seconds since epoch of the claim
+func numericDate(value any) (time.Time, bool) {
+    n, ok := value.(json.Number)
+    if !ok {
+        return time.Time{}, false
+    }
+    f, err := n.Float64()
+    if err != nil {
+        return time.Time{}, false
+    }
+    sec := int64(f)
+    return time.Unix(sec, int64((f-float64(sec))*1e9)), true
+}
+
+// decodeSegment decodes base64url JSON segment keeping numbers exact
+func decodeSegment(segment string, v any) error {
+    b, err := base64.RawURLEncoding.DecodeString(segment)
+    if err != nil {
+        return err
+    }
+    dec := json.NewDecoder(bytes.NewReader(b))
+    dec.UseNumber()
+    return dec.Decode(v)
+}
+
+func invalid(reason string) error {
+    return fmt.Errorf("%w: %s", ErrInvalid, reason)
+}
@@ -0,0 +1,196 @@
+package jwt
+
+import (
+    "crypto"
+    "crypto/ecdh"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "crypto/elliptic"
+    "crypto/rsa"
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/json"
+    "encoding/pem"
+    "errors"
+    "fmt"
+    "math/big"
+    "os"
+)
+
+// Key is the verification key, Kid may be empty
+type Key struct {
+    Kid string
+    // *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey
+    // or []byte secret of HMAC algorithms
+    Public crypto.PublicKey
+}
+
+// KeySet is the set of keys tokens are verified with
+type KeySet []Key
+
+// jwk is the JSON Web Key of the RSA, EC, OKP or oct type
+type jwk struct {
+    Kty string `json:"kty"`
+    Kid string `json:"kid"`
+    Use string `json:"use"`
+    Crv string `json:"crv"`
+    N   string `json:"n"`
+    E   string `json:"e"`
+    X   string `json:"x"`
+    Y   string `json:"y"`
+    K   string `json:"k"`
+}
+
+// LoadJWKS reads keys of the JWKS file ({"keys": [...]}),
+// encryption keys are skipped
+func LoadJWKS(path string) (KeySet, error) {
+    b, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+
+    var doc struct {
+        Keys []jwk `json:"keys"`
+    }
+    if err := json.Unmarshal(b, &doc); err != nil {
+        return nil, fmt.Errorf("invalid jwks: %w", err)
+    }
+
+    var keys KeySet
+    for i, k := range doc.Keys {
+        if k.Use == "enc" {
+            continue
+        }
+        public, err := k.publicKey()
+        if err != nil {
+            return nil, fmt.Errorf("jwks key %d: %w", i, err)
+        }
+        keys = append(keys, Key{Kid: k.Kid, Public: public})
+    }
+    if len(keys) == 0 {
+        return nil, errors.New("jwks has no signing keys")
+    }
+    return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+    decode := base64.RawURLEncoding.DecodeString
+
+    switch k.Kty {
+    case "RSA":
+        n, err := decode(k.N)
+        if err != nil {
+            return nil, err
+        }
+        e, err := decode(k.E)
+        if err != nil {
+            return nil, err
+        }
+        exponent := new(big.Int).SetBytes(e)
+        if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+            return nil, errors.New("invalid rsa exponent")
+        }
+        return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+    case "EC":
+        var curve elliptic.Curve
+        var ecdhCurve ecdh.Curve
+        switch k.Crv {
+        case "P-256":
+            curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+        case "P-384":
+            curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+        case "P-521":
+            curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+        default:
+            return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+        }
+        x, err := decode(k.X)
+        if err != nil {
+            return nil, err
+        }
+        y, err := decode(k.Y)
+        if err != nil {
+            return nil, err
+        }
+        // Parsing the uncompressed point validates it is on the curve
+        size := (curve.Params().BitSize + 7) / 8
+        if len(x) > size || len(y) > size {
+            return nil, errors.New("invalid ec point")
+        }
+        point := make([]byte, 1+2*size)
+        point[0] = 4
+        copy(point[1+size-len(x):], x)
+        copy(point[1+2*size-len(y):], y)
+        if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+            return nil, err
+        }
+        return &ecdsa.PublicKey{
+            Curve: curve,
+            X:     new(big.Int).SetBytes(x),
+            Y:     new(big.Int).SetBytes(y),
+        }, nil
+    case "OKP":
+        if k.Crv != "Ed25519" {
+            return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+        }
+        x, err := decode(k.X)
+        if err != nil {
+            return nil, err
+        }
+        if len(x) != ed25519.PublicKeySize {
+            return nil, errors.New("invalid ed25519 key")
+        }
+        return ed25519.PublicKey(x), nil
+    case "oct":
+        secret, err := decode(k.K)
+        if err != nil {
+            return nil, err
+        }
+        if len(secret) == 0 {
+            return nil, errors.New("empty secret")
+        }
+        return secret, nil
+    }
+    return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// LoadPEM reads public keys and certificates of the PEM file,
+// key ids are not known, so every key is tried for tokens
+func LoadPEM(path string) (KeySet, error) {
+    b, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+
+    var keys KeySet
+    for {
+        var block *pem.Block
+        block, b = pem.Decode(b)
+        if block == nil {
+            break
+        }
+
+        var public crypto.PublicKey
+        switch block.Type {
+        case "PUBLIC KEY":
+            public, err = x509.ParsePKIXPublicKey(block.Bytes)
+        case "RSA PUBLIC KEY":
+            public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+        case "CERTIFICATE":
+            var cert *x509.Certificate
+            if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+                public = cert.PublicKey
+            }
+        default:
+            continue
+        }
+        if err != nil {
+            return nil, fmt.Errorf("pem %s: %w", block.Type, err)
+        }
+        keys = append(keys, Key{Public: public})
+    }
+    if len(keys) == 0 {
+        return nil, errors.New("pem file has no public keys")
+    }
+    return keys, nil
+}
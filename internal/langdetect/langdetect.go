@@ -0,0 +1,210 @@
+package langdetect
+
+import (
+    "embed"
+    "math"
+    "path"
+    "sort"
+    "strings"
+    "unicode"
+)
+
+// Embedded sample texts that n-gram profiles are built from.
+// New language is added by putting <bcp47 tag>.txt into corpus directory.
+//
+//go:embed corpus/*.txt
+var corpus embed.FS
+
+const (
+    // Number of the most frequent n-grams kept in a profile
+    profileSize = 400
+    // Longest n-gram length
+    maxN = 3
+    // Texts with fewer letters are not detected
+    minLetters = 20
+)
+
+// Result is the detected language with confidence from 0 to 1
+type Result struct {
+    Lang       string
+    Confidence float64
+}
+
+// profile maps n-gram to its frequency rank
+type profile map[string]int
+
+type language struct {
+    tag     string
+    script  *unicode.RangeTable
+    profile profile
+}
+
+// Detector is the offline language detector
+// that compares n-gram profiles (Cavnar-Trenkle)
+type Detector struct {
+    languages []language
+}
+
+// New is the constructor for Detector that builds
+// profiles from the embedded corpus
+func New() (*Detector, error) {
+    files, err := corpus.ReadDir("corpus")
+    if err != nil {
+        return nil, err
+    }
+
+    d := &Detector{}
+    for _, file := range files {
+        b, err := corpus.ReadFile(path.Join("corpus", file.Name()))
+        if err != nil {
+            return nil, err
+        }
+        text := string(b)
+        d.languages = append(d.languages, language{
+            tag:     strings.TrimSuffix(file.Name(), ".txt"),
+            script:  dominantScript(text),
+            profile: newProfile(text),
+        })
+    }
+
+    return d, nil
+}
+
+// Languages returns tags of all supported languages
+func (d *Detector) Languages() []string {
+    tags := make([]string, len(d.languages))
+    for i, lang := range d.languages {
+        tags[i] = lang.tag
+    }
+    return tags
+}
+
+// Detect returns the most probable language of the text.
+// Returns false if text is too short or written
+// in the script no supported language uses.
+func (d *Detector) Detect(text string) (Result, bool) {
+    letters := 0
+    for _, r := range text {
+        if unicode.IsLetter(r) {
+            letters++
+        }
+    }
+    if letters < minLetters {
+        return Result{}, false
+    }
+
+    script := dominantScript(text)
+    doc := newProfile(text)
+
+    type candidate struct {
+        tag      string
+        distance float64
+    }
+    var candidates []candidate
+    for _, lang := range d.languages {
+        if lang.script != script {
+            continue
+        }
+        candidates = append(candidates, candidate{lang.tag, distance(doc, lang.profile)})
+    }
+    if len(candidates) == 0 {
+        return Result{}, false
+    }
+
+    sort.Slice(candidates, func(i, j int) bool {
+        return candidates[i].distance < candidates[j].distance
+    })
+
+    // Softmax over negative distances, sharpened
+    // because normalized distances are close to each other
+    const sharpness = 50
+    var sum float64
+    for _, c := range candidates {
+        sum += math.Exp(-sharpness * (c.distance - candidates[0].distance))
+    }
+
+    return Result{Lang: candidates[0].tag, Confidence: 1 / sum}, true
+}
+
+// newProfile builds n-gram frequency ranks of the text
+func newProfile(text string) profile {
+    counts := map[string]int{}
+    for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+        return !unicode.IsLetter(r) && r != '\'' && r != '’'
+    }) {
+        runes := []rune(" " + word + " ")
+        for n := 1; n <= maxN; n++ {
+            for i := 0; i+n <= len(runes); i++ {
+                gram := string(runes[i : i+n])
+                if gram != " " {
+                    counts[gram]++
+                }
+            }
+        }
+    }
+
+    grams := make([]string, 0, len(counts))
+    for gram := range counts {
+        grams = append(grams, gram)
+    }
+    sort.Slice(grams, func(i, j int) bool {
+        if counts[grams[i]] != counts[grams[j]] {
+            return counts[grams[i]] > counts[grams[j]]
+        }
+        return grams[i] < grams[j]
+    })
+    if len(grams) > profileSize {
+        grams = grams[:profileSize]
+    }
+
+    p := make(profile, len(grams))
+    for i, gram := range grams {
+        p[gram] = i
+    }
+    return p
+}
+
+// distance is the out-of-place measure normalized to [0, 1]
+func distance(doc, lang profile) float64 {
+    if len(doc) == 0 {
+        return 1
+    }
+    var sum int
+    for gram, rank := range doc {
+        if langRank, ok := lang[gram]; ok {
+            sum += abs(rank - langRank)
+        } else {
+            sum += profileSize
+        }
+    }
+    return float64(sum) / float64(len(doc)*profileSize)
+}
+
+// dominantScript returns the script most letters of the text belong to
+func dominantScript(text string) *unicode.RangeTable {
+    scripts := []*unicode.RangeTable{unicode.Latin, unicode.Cyrillic, unicode.Greek, unicode.Arabic, unicode.Han}
+    counts := make([]int, len(scripts))
+    for _, r := range text {
+        for i, script := range scripts {
+            if unicode.Is(script, r) {
+                counts[i]++
+                break
+            }
+        }
+    }
+
+    best := 0
+    for i := range counts {
+        if counts[i] > counts[best] {
+            best = i
+        }
+    }
+    return scripts[best]
+}
+
+func abs(n int) int {
+    if n < 0 {
+        return -n
+    }
+    return n
+}
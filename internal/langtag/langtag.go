@@ -0,0 +1,109 @@
+package langtag
+
+import (
+    "errors"
+    "strings"
+)
+
+// ErrInvalid is returned for tags that are not well-formed BCP 47
+var ErrInvalid = errors.New("invalid BCP 47 language tag")
+
+// Parse checks that s is the well-formed BCP 47 language tag
+// (language[-extlang][-script][-region][-variant]*[-extension]*[-privateuse])
+// and returns it in canonical case ("ru", "ru-Latn", "en-US", "sr-Cyrl-RS").
+func Parse(s string) (string, error) {
+    subtags := strings.Split(strings.ReplaceAll(s, "_", "-"), "-")
+    for i, subtag := range subtags {
+        if subtag == "" || len(subtag) > 8 || !isAlnum(subtag) {
+            return "", ErrInvalid
+        }
+        subtags[i] = strings.ToLower(subtag)
+    }
+
+    // Private use only tag (x-whatever)
+    if subtags[0] == "x" {
+        return strings.Join(subtags, "-"), nil
+    }
+
+    // Primary language subtag
+    if n := len(subtags[0]); n < 2 || n > 8 || n == 4 || !isAlpha(subtags[0]) {
+        return "", ErrInvalid
+    }
+    i := 1
+
+    // Up to three extended language subtags
+    for ext := 0; i < len(subtags) && ext < 3 && len(subtags[0]) <= 3 &&
+        len(subtags[i]) == 3 && isAlpha(subtags[i]); ext++ {
+        i++
+    }
+
+    // Script subtag in title case (Latn, Cyrl)
+    if i < len(subtags) && len(subtags[i]) == 4 && isAlpha(subtags[i]) {
+        subtags[i] = strings.ToUpper(subtags[i][:1]) + subtags[i][1:]
+        i++
+    }
+
+    // Region subtag in upper case (US, 419)
+    if i < len(subtags) && (len(subtags[i]) == 2 && isAlpha(subtags[i]) ||
+        len(subtags[i]) == 3 && isDigit(subtags[i])) {
+        subtags[i] = strings.ToUpper(subtags[i])
+        i++
+    }
+
+    // Variant subtags
+    for i < len(subtags) && (len(subtags[i]) >= 5 ||
+        len(subtags[i]) == 4 && isDigit(subtags[i][:1])) {
+        i++
+    }
+
+    // Extensions (u-co-phonebk) and private use (x-...)
+    for i < len(subtags) {
+        if len(subtags[i]) != 1 {
+            return "", ErrInvalid
+        }
+        private := subtags[i] == "x"
+        i++
+        start := i
+        for i < len(subtags) && (private || len(subtags[i]) > 1) {
+            i++
+        }
+        if i == start {
+            return "", ErrInvalid
+        }
+    }
+
+    return strings.Join(subtags, "-"), nil
+}
+
+// Base returns primary language subtag of the tag ("ru" for "ru-Latn")
+func Base(tag string) string {
+    base, _, _ := strings.Cut(tag, "-")
+    return strings.ToLower(base)
+}
+
+func isAlpha(s string) bool {
+    for _, r := range s {
+        if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+            return false
+        }
+    }
+    return true
+}
+
+func isDigit(s string) bool {
+    for _, r := range s {
+        if r < '0' || r > '9' {
+            return false
+        }
+    }
+    return true
+}
+
+func isAlnum(s string) bool {
+    for _, r := range s {
+        if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+            return false
+        }
+    }
+    return true
+}
@@ -0,0 +1,378 @@
+package lrc
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Timestamp is the position of a line or a word
+// from the beginning of the song
+type Timestamp time.Duration
+
+// MarshalJSON encodes timestamp in the LRC "mm:ss.xx" form
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+    return []byte(strconv.Quote(t.String())), nil
+}
+
+func (t Timestamp) String() string {
+    d := time.Duration(t)
+    if d < 0 {
+        d = 0
+    }
+    minutes := int(d / time.Minute)
+    seconds := int((d % time.Minute) / time.Second)
+    hundredths := int((d % time.Second) / (10 * time.Millisecond))
+    return fmt.Sprintf("%02d:%02d.%02d", minutes, seconds, hundredths)
+}
+
+// Word is the single word of an enhanced LRC line
+type Word struct {
+    Time Timestamp `json:"time"`
+    Text string    `json:"text"`
+}
+
+// Line is the single timed line of lyrics
+type Line struct {
+    Time  Timestamp `json:"time"`
+    Text  string    `json:"text"`
+    Words []Word    `json:"words,omitempty"`
+}
+
+// Lyrics is the parsed LRC or enhanced LRC document
+type Lyrics struct {
+    Tags  map[string]string `json:"tags,omitempty"`
+    Lines []Line            `json:"lines"`
+}
+
+// ParseError describes the invalid line of LRC document
+type ParseError struct {
+    Line int
+    Msg  string
+}
+
+func (e *ParseError) Error() string {
+    return fmt.Sprintf("lrc: line %d: %s", e.Line, e.Msg)
+}
+
+// ErrNoLines is returned when document has no timed lines
+var ErrNoLines = errors.New("lrc: no timed lines")
+
+// Parse parses and validates LRC or enhanced LRC document.
+// Lines are sorted by time, the [offset:] tag is applied.
+func Parse(s string) (Lyrics, error) {
+    lyrics := Lyrics{Tags: map[string]string{}}
+    var offset time.Duration
+
+    s = strings.TrimPrefix(s, "\ufeff")
+    for i, raw := range strings.Split(s, "\n") {
+        n := i + 1
+        raw = strings.TrimSpace(strings.TrimSuffix(raw, "\r"))
+        if raw == "" {
+            continue
+        }
+        if !strings.HasPrefix(raw, "[") {
+            return Lyrics{}, &ParseError{Line: n, Msg: "line does not start with a tag"}
+        }
+
+        // Collecting leading tags ([00:12.00][00:45.00]text or [ar:Artist])
+        var times []time.Duration
+        rest := raw
+        for strings.HasPrefix(rest, "[") {
+            end := strings.IndexByte(rest, ']')
+            if end < 0 {
+                return Lyrics{}, &ParseError{Line: n, Msg: "unclosed tag"}
+            }
+            tag := rest[1:end]
+            rest = rest[end+1:]
+
+            if t, err := parseTime(tag); err == nil {
+                times = append(times, t)
+                continue
+            }
+
+            key, value, ok := strings.Cut(tag, ":")
+            if !ok || !isTagKey(key) {
+                return Lyrics{}, &ParseError{Line: n, Msg: fmt.Sprintf("invalid tag %q", tag)}
+            }
+            if len(times) > 0 {
+                return Lyrics{}, &ParseError{Line: n, Msg: "metadata tag after timestamp"}
+            }
+            key = strings.ToLower(key)
+            value = strings.TrimSpace(value)
+            if key == "offset" {
+                ms, err := strconv.Atoi(strings.TrimPrefix(value, "+"))
+                if err != nil {
+                    return Lyrics{}, &ParseError{Line: n, Msg: "invalid offset"}
+                }
+                offset = time.Duration(ms) * time.Millisecond
+            }
+            lyrics.Tags[key] = value
+        }
+
+        if len(times) == 0 {
+            if strings.TrimSpace(rest) != "" {
+                return Lyrics{}, &ParseError{Line: n, Msg: "text after metadata tag"}
+            }
+            continue
+        }
+
+        text, words, err := parseWords(rest)
+        if err != nil {
+            return Lyrics{}, &ParseError{Line: n, Msg: err.Error()}
+        }
+
+        for _, t := range times {
+            line := Line{Time: Timestamp(t), Text: text}
+            if len(words) > 0 {
+                if words[0].Time < line.Time {
+                    return Lyrics{}, &ParseError{Line: n, Msg: "word timestamp before line timestamp"}
+                }
+                line.Words = words
+            }
+            lyrics.Lines = append(lyrics.Lines, line)
+        }
+    }
+
+    if len(lyrics.Lines) == 0 {
+        return Lyrics{}, ErrNoLines
+    }
+
+    // Positive offset shifts lyrics up (shows them earlier)
+    if offset != 0 {
+        shift := func(t Timestamp) Timestamp {
+            return Timestamp(max(time.Duration(t)-offset, 0))
+        }
+        for i := range lyrics.Lines {
+            lyrics.Lines[i].Time = shift(lyrics.Lines[i].Time)
+            for j := range lyrics.Lines[i].Words {
+                lyrics.Lines[i].Words[j].Time = shift(lyrics.Lines[i].Words[j].Time)
+            }
+        }
+    }
+
+    sort.SliceStable(lyrics.Lines, func(i, j int) bool {
+        return lyrics.Lines[i].Time < lyrics.Lines[j].Time
+    })
+
+    if len(lyrics.Tags) == 0 {
+        lyrics.Tags = nil
+    }
+
+    return lyrics, nil
+}
+
+// LineAt returns index of the line that is active at t.
+// Returns false if t is before the first line.
+func (l Lyrics) LineAt(t time.Duration) (int, bool) {
+    i := sort.Search(len(l.Lines), func(i int) bool {
+        return time.Duration(l.Lines[i].Time) > t
+    })
+    if i == 0 {
+        return -1, false
+    }
+    return i - 1, true
+}
+
+// PlainText converts timed lines back to the plain song text.
+// Lines with empty text are treated as separators between verses.
+func (l Lyrics) PlainText() string {
+    var verses []string
+    var verse []string
+    for _, line := range l.Lines {
+        if line.Text == "" {
+            if len(verse) > 0 {
+                verses = append(verses, strings.Join(verse, "\n"))
+                verse = nil
+            }
+            continue
+        }
+        verse = append(verse, line.Text)
+    }
+    if len(verse) > 0 {
+        verses = append(verses, strings.Join(verse, "\n"))
+    }
+    return strings.Join(verses, "\n\n")
+}
+
+// String encodes lyrics to the LRC document
+func (l Lyrics) String() string {
+    var b strings.Builder
+
+    keys := make([]string, 0, len(l.Tags))
+    for key := range l.Tags {
+        // Offset is already applied to the lines
+        if key != "offset" {
+            keys = append(keys, key)
+        }
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        fmt.Fprintf(&b, "[%s:%s]\n", key, l.Tags[key])
+    }
+
+    for _, line := range l.Lines {
+        fmt.Fprintf(&b, "[%s]", line.Time)
+        if len(line.Words) == 0 {
+            b.WriteString(line.Text)
+        }
+        for i, word := range line.Words {
+            if i > 0 {
+                b.WriteByte(' ')
+            }
+            fmt.Fprintf(&b, "<%s>%s", word.Time, word.Text)
+        }
+        b.WriteByte('\n')
+    }
+
+    return b.String()
+}
+
+// Skeleton converts plain song text to the LRC document
+// with zero timestamps that is ready for timing.
+// Verses are separated by lines with empty text.
+func Skeleton(text string, tags map[string]string) Lyrics {
+    lyrics := Lyrics{Tags: tags}
+    for i, verse := range strings.Split(text, "\n\n") {
+        if i > 0 {
+            lyrics.Lines = append(lyrics.Lines, Line{})
+        }
+        for _, line := range strings.Split(verse, "\n") {
+            if line = strings.TrimSpace(line); line != "" {
+                lyrics.Lines = append(lyrics.Lines, Line{Text: line})
+            }
+        }
+    }
+    return lyrics
+}
+
+// ParseOffset parses playback position given as seconds ("12.5"),
+// duration ("1m2.5s") or LRC timestamp ("01:02.50")
+func ParseOffset(s string) (time.Duration, error) {
+    if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+        if math.IsNaN(seconds) || math.IsInf(seconds, 0) || seconds*float64(time.Second) > math.MaxInt64 {
+            return 0, errors.New("lrc: offset is not finite")
+        }
+        if seconds < 0 {
+            return 0, errors.New("lrc: negative offset")
+        }
+        return time.Duration(seconds * float64(time.Second)), nil
+    }
+    if t, err := parseTime(s); err == nil {
+        return t, nil
+    }
+    d, err := time.ParseDuration(s)
+    if err != nil {
+        return 0, err
+    }
+    if d < 0 {
+        return 0, errors.New("lrc: negative offset")
+    }
+    return d, nil
+}
+
+// parseTime parses "mm:ss", "mm:ss.xx", "mm:ss.xxx" and "mm:ss:xx" timestamps
+func parseTime(s string) (time.Duration, error) {
+    minutes, rest, ok := strings.Cut(s, ":")
+    if !ok || minutes == "" || !isDigits(minutes) {
+        return 0, errors.New("invalid timestamp")
+    }
+
+    seconds, fraction := rest, ""
+    if i := strings.IndexAny(rest, ".:"); i >= 0 {
+        seconds, fraction = rest[:i], rest[i+1:]
+        if fraction == "" || len(fraction) > 3 || !isDigits(fraction) {
+            return 0, errors.New("invalid timestamp")
+        }
+    }
+    if len(seconds) != 2 || !isDigits(seconds) {
+        return 0, errors.New("invalid timestamp")
+    }
+
+    m, _ := strconv.Atoi(minutes)
+    sec, _ := strconv.Atoi(seconds)
+    if sec >= 60 {
+        return 0, errors.New("invalid timestamp")
+    }
+
+    t := time.Duration(m)*time.Minute + time.Duration(sec)*time.Second
+    if fraction != "" {
+        f, _ := strconv.Atoi(fraction)
+        for i := len(fraction); i < 3; i++ {
+            f *= 10
+        }
+        t += time.Duration(f) * time.Millisecond
+    }
+
+    return t, nil
+}
+
+// parseWords extracts enhanced LRC word timestamps (<mm:ss.xx>word)
+// from the line text and returns text without them
+func parseWords(s string) (string, []Word, error) {
+    if !strings.Contains(s, "<") {
+        return strings.TrimSpace(s), nil, nil
+    }
+
+    var words []Word
+    var text []string
+    rest := strings.TrimSpace(s)
+    if !strings.HasPrefix(rest, "<") {
+        return "", nil, errors.New("text before first word timestamp")
+    }
+    for rest != "" {
+        end := strings.IndexByte(rest, '>')
+        if !strings.HasPrefix(rest, "<") || end < 0 {
+            return "", nil, errors.New("invalid word timestamp")
+        }
+        t, err := parseTime(rest[1:end])
+        if err != nil {
+            return "", nil, err
+        }
+        rest = rest[end+1:]
+
+        next := strings.IndexByte(rest, '<')
+        if next < 0 {
+            next = len(rest)
+        }
+        word := strings.TrimSpace(rest[:next])
+        rest = rest[next:]
+
+        if len(words) > 0 && Timestamp(t) < words[len(words)-1].Time {
+            return "", nil, errors.New("word timestamps are not ascending")
+        }
+        // Trailing timestamp only marks the end of the last word
+        if word == "" {
+            continue
+        }
+        words = append(words, Word{Time: Timestamp(t), Text: word})
+        text = append(text, word)
+    }
+
+    return strings.Join(text, " "), words, nil
+}
+
+func isTagKey(s string) bool {
+    if s == "" {
+        return false
+    }
+    for _, r := range s {
+        if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '#') {
+            return false
+        }
+    }
+    return true
+}
+
+func isDigits(s string) bool {
+    for _, r := range s {
+        if r < '0' || r > '9' {
+            return false
+        }
+    }
+    return s != ""
+}
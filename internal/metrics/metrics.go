@@ -0,0 +1,279 @@
+// Package metrics keeps counters, histograms and gauges and
+// writes them in Prometheus text exposition format (0.0.4).
+package metrics
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "math"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+// DefaultBuckets are the upper bounds of latency
+// histograms in seconds, from 5ms to 10s
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// collector writes the samples of one metric family
+type collector interface {
+    write(w *bufio.Writer)
+}
+
+// Registry is the set of metrics served
+// together, it is safe for concurrent use
+type Registry struct {
+    mu         sync.Mutex
+    collectors []collector
+}
+
+func NewRegistry() *Registry {
+    return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.collectors = append(r.collectors, c)
+}
+
+// Counter registers the counter with the label names
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+    c := &CounterVec{desc: desc{name, help, "counter", labels}, values: map[string]*counterValue{}}
+    r.register(c)
+    return c
+}
+
+// Histogram registers the histogram with
+// the bucket upper bounds and the label names
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+    h := &HistogramVec{
+        desc:    desc{name, help, "histogram", labels},
+        buckets: buckets,
+        values:  map[string]*histogramValue{},
+    }
+    r.register(h)
+    return h
+}
+
+// GaugeFunc registers the gauge whose value
+// is read by the function on every scrape
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+    r.register(&funcMetric{desc: desc{name, help, "gauge", nil}, fn: fn})
+}
+
+// CounterFunc registers the counter whose value
+// is read by the function on every scrape
+func (r *Registry) CounterFunc(name, help string, fn func() float64) {
+    r.register(&funcMetric{desc: desc{name, help, "counter", nil}, fn: fn})
+}
+
+// WriteTo writes all metrics in the text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+    r.mu.Lock()
+    collectors := append([]collector(nil), r.collectors...)
+    r.mu.Unlock()
+
+    cw := &countingWriter{w: w}
+    bw := bufio.NewWriter(cw)
+    for _, c := range collectors {
+        c.write(bw)
+    }
+    err := bw.Flush()
+    return cw.n, err
+}
+
+// ServeHTTP serves the metrics to the scraper
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+    w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+    r.WriteTo(w)
+}
+
+// desc is the name, help, type and label names of the metric family
+type desc struct {
+    name   string
+    help   string
+    kind   string
+    labels []string
+}
+
+func (d desc) writeHeader(w *bufio.Writer) {
+    fmt.Fprintf(w, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+    fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.kind)
+}
+
+// key joins label values to the key of the series
+func (d desc) key(values []string) string {
+    if len(values) != len(d.labels) {
+        panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", d.name, len(d.labels), len(values)))
+    }
+    return strings.Join(values, "\xff")
+}
+
+// labelPairs formats labels of the series key with the extra
+// pair (le of histogram buckets) if its name is not empty
+func (d desc) labelPairs(key string, extraName, extraValue string) string {
+    var pairs []string
+    if len(d.labels) > 0 {
+        for i, value := range strings.Split(key, "\xff") {
+            pairs = append(pairs, d.labels[i]+`="`+escapeLabel(value)+`"`)
+        }
+    }
+    if extraName != "" {
+        pairs = append(pairs, extraName+`="`+extraValue+`"`)
+    }
+    if len(pairs) == 0 {
+        return ""
+    }
+    return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// CounterVec is the counter with labels
+type CounterVec struct {
+    desc
+    mu     sync.Mutex
+    values map[string]*counterValue
+}
+
+type counterValue struct {
+    value float64
+}
+
+// Inc adds one to the counter of the label values
+func (c *CounterVec) Inc(labelValues ...string) {
+    c.Add(1, labelValues...)
+}
+
+// Add adds the non-negative delta to the counter of the label values
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+    key := c.key(labelValues)
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    v, ok := c.values[key]
+    if !ok {
+        v = &counterValue{}
+        c.values[key] = v
+    }
+    v.value += delta
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+    c.writeHeader(w)
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    for _, key := range sortedKeys(c.values) {
+        fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelPairs(key, "", ""), formatFloat(c.values[key].value))
+    }
+}
+
+// HistogramVec is the histogram with labels
+type HistogramVec struct {
+    desc
+    buckets []float64
+    mu      sync.Mutex
+    values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+    // Observations of every bucket, not cumulative
+    counts []uint64
+    sum    float64
+    count  uint64
+}
+
+// Observe adds the value to the histogram of the label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+    key := h.key(labelValues)
+
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    v, ok := h.values[key]
+    if !ok {
+        v = &histogramValue{counts: make([]uint64, len(h.buckets))}
+        h.values[key] = v
+    }
+    if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+        v.counts[i]++
+    }
+    v.sum += value
+    v.count++
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+    h.writeHeader(w)
+
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    for _, key := range sortedKeys(h.values) {
+        v := h.values[key]
+        var cumulative uint64
+        for i, bound := range h.buckets {
+            cumulative += v.counts[i]
+            fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(key, "le", formatFloat(bound)), cumulative)
+        }
+        fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(key, "le", "+Inf"), v.count)
+        fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelPairs(key, "", ""), formatFloat(v.sum))
+        fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelPairs(key, "", ""), v.count)
+    }
+}
+
+// funcMetric is the gauge or counter without labels read on scrape
+type funcMetric struct {
+    desc
+    fn func() float64
+}
+
+func (f *funcMetric) write(w *bufio.Writer) {
+    f.writeHeader(w)
+    fmt.Fprintf(w, "%s %s\n", f.name, formatFloat(f.fn()))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+    keys := make([]string, 0, len(m))
+    for key := range m {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    return keys
+}
+
+func formatFloat(f float64) string {
+    switch {
+    case math.IsInf(f, 1):
+        return "+Inf"
+    case math.IsInf(f, -1):
+        return "-Inf"
+    case math.IsNaN(f):
+        return "NaN"
+    }
+    return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var (
+    helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+    labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+    return helpReplacer.Replace(s)
+}
+
+func escapeLabel(s string) string {
+    return labelReplacer.Replace(s)
+}
+
+type countingWriter struct {
+    w io.Writer
+    n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+    n, err := c.w.Write(p)
+    c.n += int64(n)
+    return n, err
+}
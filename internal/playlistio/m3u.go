@@ -0,0 +1,84 @@
+package playlistio
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "strings"
+)
+
+// readM3U reads plain or extended M3U, every non-comment
+// line is the location of the track described by the
+// preceding #EXTINF and #EXTART lines. Display name of
+// #EXTINF is "Artist - Title" when #EXTART is also given
+func readM3U(s string) (Playlist, error) {
+    var p Playlist
+    var track Track
+
+    for _, line := range strings.Split(s, "\n") {
+        line = strings.TrimSpace(line)
+        switch {
+        case line == "":
+        case strings.HasPrefix(line, "#PLAYLIST:"):
+            p.Name = strings.TrimSpace(strings.TrimPrefix(line, "#PLAYLIST:"))
+        case strings.HasPrefix(line, "#EXTINF:"):
+            track.Title = extinfTitle(strings.TrimPrefix(line, "#EXTINF:"))
+        case strings.HasPrefix(line, "#EXTART:"):
+            track.Artist = strings.TrimPrefix(line, "#EXTART:")
+        case strings.HasPrefix(line, "#"):
+        default:
+            if track.Artist != "" {
+                track.Title = strings.TrimPrefix(track.Title, track.Artist+" - ")
+            }
+            track.Location = line
+            p.Tracks = append(p.Tracks, track)
+            track = Track{}
+        }
+    }
+    return p, nil
+}
+
+// extinfTitle returns display name of "#EXTINF:-1 attr="a,b",Title",
+// the name follows the first comma outside of quoted attributes
+func extinfTitle(s string) string {
+    quoted := false
+    for i, c := range s {
+        switch c {
+        case '"':
+            quoted = !quoted
+        case ',':
+            if !quoted {
+                return s[i+1:]
+            }
+        }
+    }
+    return ""
+}
+
+// writeM3U writes extended M3U, tracks without
+// location cannot be played and are skipped
+func writeM3U(w io.Writer, p Playlist) error {
+    bw := bufio.NewWriter(w)
+
+    fmt.Fprintln(bw, "#EXTM3U")
+    if name := oneLine(p.Name); name != "" {
+        fmt.Fprintf(bw, "#PLAYLIST:%s\n", name)
+    }
+    for _, t := range p.Tracks {
+        if t.Location == "" {
+            continue
+        }
+        // Length is unknown
+        fmt.Fprintf(bw, "#EXTINF:-1,%s\n", oneLine(t.displayName()))
+        if artist := oneLine(t.Artist); artist != "" {
+            fmt.Fprintf(bw, "#EXTART:%s\n", artist)
+        }
+        fmt.Fprintln(bw, oneLine(t.Location))
+    }
+    return bw.Flush()
+}
+
+// oneLine replaces line breaks that would break line based formats
+func oneLine(s string) string {
+    return strings.Join(strings.Fields(s), " ")
+}
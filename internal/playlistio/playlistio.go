@@ -0,0 +1,150 @@
+// Package playlistio reads and writes playlists as extended
+// M3U8, XSPF and PLS files of media players.
+package playlistio
+
+import (
+    "fmt"
+    "io"
+    "net/url"
+    "path"
+    "strings"
+)
+
+// Formats of the playlist files
+const (
+    FormatM3U8 = "m3u8"
+    FormatXSPF = "xspf"
+    FormatPLS  = "pls"
+)
+
+// Max size of the playlist file
+const maxFileSize = 16 << 20
+
+// Playlist is the playlist of the file
+type Playlist struct {
+    Name        string
+    Description string
+    Tracks      []Track
+}
+
+// Track is the entry of the playlist file. Players often
+// keep only the "Artist - Title" display name, it is split
+// into artist and title on reading.
+type Track struct {
+    Title    string
+    Artist   string
+    Location string
+}
+
+// ParseFormat returns format by its name or file extension
+func ParseFormat(s string) (string, error) {
+    switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+    case "m3u8", "m3u":
+        return FormatM3U8, nil
+    case "xspf":
+        return FormatXSPF, nil
+    case "pls":
+        return FormatPLS, nil
+    }
+    return "", fmt.Errorf("unknown format %q", s)
+}
+
+// ContentType returns media type of the format
+func ContentType(format string) string {
+    switch format {
+    case FormatM3U8:
+        return "audio/x-mpegurl"
+    case FormatXSPF:
+        return "application/xspf+xml"
+    case FormatPLS:
+        return "audio/x-scpls"
+    }
+    return "application/octet-stream"
+}
+
+// Read reads the whole playlist file of the format
+func Read(r io.Reader, format string) (Playlist, error) {
+    b, err := io.ReadAll(io.LimitReader(r, maxFileSize+1))
+    if err != nil {
+        return Playlist{}, err
+    }
+    if len(b) > maxFileSize {
+        return Playlist{}, fmt.Errorf("playlist file is larger than %d bytes", maxFileSize)
+    }
+    s := strings.TrimPrefix(string(b), "\ufeff")
+
+    var p Playlist
+    switch format {
+    case FormatM3U8:
+        p, err = readM3U(s)
+    case FormatXSPF:
+        p, err = readXSPF(s)
+    case FormatPLS:
+        p, err = readPLS(s)
+    default:
+        return Playlist{}, fmt.Errorf("unknown format %q", format)
+    }
+    if err != nil {
+        return Playlist{}, err
+    }
+
+    for i := range p.Tracks {
+        p.Tracks[i] = p.Tracks[i].complete()
+    }
+    return p, nil
+}
+
+// Write writes the playlist in the format
+func Write(w io.Writer, format string, p Playlist) error {
+    switch format {
+    case FormatM3U8:
+        return writeM3U(w, p)
+    case FormatXSPF:
+        return writeXSPF(w, p)
+    case FormatPLS:
+        return writePLS(w, p)
+    }
+    return fmt.Errorf("unknown format %q", format)
+}
+
+// displayName returns "Artist - Title" name of the track
+func (t Track) displayName() string {
+    if t.Artist == "" {
+        return t.Title
+    }
+    return t.Artist + " - " + t.Title
+}
+
+// complete fills missing artist and title from the
+// display name or, if it is empty, from the file name
+func (t Track) complete() Track {
+    t.Title, t.Artist = strings.TrimSpace(t.Title), strings.TrimSpace(t.Artist)
+    if t.Artist != "" {
+        return t
+    }
+
+    name := t.Title
+    if name == "" {
+        name = fileName(t.Location)
+    }
+    if artist, title, ok := strings.Cut(name, " - "); ok {
+        t.Artist, t.Title = strings.TrimSpace(artist), strings.TrimSpace(title)
+    } else if t.Title == "" {
+        t.Title = strings.TrimSpace(name)
+    }
+    return t
+}
+
+// fileName returns base name of the file path
+// or URL without extension
+func fileName(location string) string {
+    p := location
+    if u, err := url.Parse(location); err == nil && u.Scheme != "" && len(u.Scheme) > 1 {
+        p = u.Path
+    }
+    p = path.Base(strings.ReplaceAll(p, `\`, "/"))
+    if p == "." || p == "/" {
+        return ""
+    }
+    return strings.TrimSuffix(p, path.Ext(p))
+}
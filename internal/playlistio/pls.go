@@ -0,0 +1,92 @@
+package playlistio
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "io"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// readPLS reads "[playlist]" section with FileN and TitleN keys
+func readPLS(s string) (Playlist, error) {
+    byNumber := map[int]*Track{}
+    section, found := false, false
+
+    for _, line := range strings.Split(s, "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" || strings.HasPrefix(line, ";") {
+            continue
+        }
+        if strings.HasPrefix(line, "[") {
+            section = strings.EqualFold(line, "[playlist]")
+            found = found || section
+            continue
+        }
+        if !section {
+            continue
+        }
+
+        key, value, ok := strings.Cut(line, "=")
+        if !ok {
+            continue
+        }
+        key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+        for _, prefix := range []string{"file", "title"} {
+            n, err := strconv.Atoi(strings.TrimPrefix(key, prefix))
+            if !strings.HasPrefix(key, prefix) || err != nil {
+                continue
+            }
+            if byNumber[n] == nil {
+                byNumber[n] = &Track{}
+            }
+            if prefix == "file" {
+                byNumber[n].Location = value
+            } else {
+                byNumber[n].Title = value
+            }
+        }
+    }
+    if !found {
+        return Playlist{}, errors.New("pls playlist section is missing")
+    }
+
+    numbers := make([]int, 0, len(byNumber))
+    for n := range byNumber {
+        numbers = append(numbers, n)
+    }
+    sort.Ints(numbers)
+
+    var p Playlist
+    for _, n := range numbers {
+        // Title without file is not a track
+        if byNumber[n].Location != "" {
+            p.Tracks = append(p.Tracks, *byNumber[n])
+        }
+    }
+    return p, nil
+}
+
+// writePLS writes PLS version 2, tracks without
+// location cannot be played and are skipped
+func writePLS(w io.Writer, p Playlist) error {
+    bw := bufio.NewWriter(w)
+
+    fmt.Fprintln(bw, "[playlist]")
+    n := 0
+    for _, t := range p.Tracks {
+        if t.Location == "" {
+            continue
+        }
+        n++
+        fmt.Fprintf(bw, "File%d=%s\n", n, oneLine(t.Location))
+        fmt.Fprintf(bw, "Title%d=%s\n", n, oneLine(t.displayName()))
+        fmt.Fprintf(bw, "Length%d=-1\n", n)
+    }
+    fmt.Fprintf(bw, "NumberOfEntries=%d\n", n)
+    fmt.Fprintln(bw, "Version=2")
+    return bw.Flush()
+}
@@ -0,0 +1,79 @@
+package playlistio
+
+import (
+    "encoding/xml"
+    "errors"
+    "io"
+    "strings"
+)
+
+const xspfNamespace = "http://xspf.org/ns/0/"
+
+type xspfPlaylist struct {
+    XMLName    xml.Name    `xml:"playlist"`
+    Version    string      `xml:"version,attr"`
+    Namespace  string      `xml:"xmlns,attr"`
+    Title      string      `xml:"title,omitempty"`
+    Annotation string      `xml:"annotation,omitempty"`
+    Tracks     []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+    // Several locations are alternatives of the same track
+    Locations []string `xml:"location"`
+    Title     string   `xml:"title,omitempty"`
+    Creator   string   `xml:"creator,omitempty"`
+}
+
+func readXSPF(s string) (Playlist, error) {
+    var doc xspfPlaylist
+    if err := xml.Unmarshal([]byte(s), &doc); err != nil {
+        return Playlist{}, err
+    }
+    if doc.XMLName.Local != "playlist" {
+        return Playlist{}, errors.New("xspf playlist element is missing")
+    }
+
+    p := Playlist{
+        Name:        strings.TrimSpace(doc.Title),
+        Description: strings.TrimSpace(doc.Annotation),
+    }
+    for _, t := range doc.Tracks {
+        track := Track{Title: t.Title, Artist: t.Creator}
+        if len(t.Locations) > 0 {
+            track.Location = strings.TrimSpace(t.Locations[0])
+        }
+        p.Tracks = append(p.Tracks, track)
+    }
+    return p, nil
+}
+
+// writeXSPF writes XSPF version 1, tracks without location
+// are kept as players may resolve them by title and creator
+func writeXSPF(w io.Writer, p Playlist) error {
+    doc := xspfPlaylist{
+        Version:    "1",
+        Namespace:  xspfNamespace,
+        Title:      p.Name,
+        Annotation: p.Description,
+        Tracks:     make([]xspfTrack, 0, len(p.Tracks)),
+    }
+    for _, t := range p.Tracks {
+        track := xspfTrack{Title: t.Title, Creator: t.Artist}
+        if t.Location != "" {
+            track.Locations = []string{t.Location}
+        }
+        doc.Tracks = append(doc.Tracks, track)
+    }
+
+    if _, err := io.WriteString(w, xml.Header); err != nil {
+        return err
+    }
+    enc := xml.NewEncoder(w)
+    enc.Indent("", "  ")
+    if err := enc.Encode(doc); err != nil {
+        return err
+    }
+    _, err := io.WriteString(w, "\n")
+    return err
+}
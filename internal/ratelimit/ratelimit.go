@@ -0,0 +1,128 @@
+// Package ratelimit limits requests of clients with
+// token buckets kept per client key.
+package ratelimit
+
+import (
+    "math"
+    "sync"
+    "time"
+)
+
+// How often buckets of idle clients are dropped
+const sweepInterval = time.Minute
+
+// Most buckets kept at once, keys of made up clients
+// (random tokens) cannot grow the buckets without bound
+const maxBuckets = 100_000
+
+// Limit is the bucket of Burst tokens refilled with Rate
+// tokens per second, every request takes one token
+type Limit struct {
+    Rate  float64
+    Burst int
+}
+
+// Result is the state of the client bucket after the request
+type Result struct {
+    Allowed bool
+    // Burst of the limit and whole tokens left in the bucket
+    Limit     int
+    Remaining int
+    // Time until the bucket is full again
+    Reset time.Duration
+    // Time until the next request is allowed, zero if this one is
+    RetryAfter time.Duration
+}
+
+type bucket struct {
+    tokens  float64
+    updated time.Time
+}
+
+// Limiter keeps the bucket of every client key,
+// it is safe for concurrent use
+type Limiter struct {
+    limit Limit
+
+    mu      sync.Mutex
+    buckets map[string]*bucket
+    swept   time.Time
+}
+
+func New(limit Limit) *Limiter {
+    return &Limiter{
+        limit:   limit,
+        buckets: map[string]*bucket{},
+        swept:   time.Now(),
+    }
+}
+
+// Allow takes a token from the bucket of the key,
+// new clients start with the full bucket
+func (l *Limiter) Allow(key string) Result {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    now := time.Now()
+    if now.Sub(l.swept) > sweepInterval {
+        l.sweep(now)
+    }
+
+    burst := float64(l.limit.Burst)
+    b, ok := l.buckets[key]
+    if !ok {
+        if len(l.buckets) >= maxBuckets {
+            l.evict()
+        }
+        b = &bucket{tokens: burst}
+        l.buckets[key] = b
+    } else {
+        b.tokens = l.refill(b, now)
+    }
+    b.updated = now
+
+    result := Result{Limit: l.limit.Burst}
+    if b.tokens >= 1 {
+        b.tokens--
+        result.Allowed = true
+    } else {
+        result.RetryAfter = l.duration(1 - b.tokens)
+    }
+    result.Remaining = int(math.Floor(b.tokens))
+    result.Reset = l.duration(burst - b.tokens)
+
+    return result
+}
+
+// refill returns tokens of the bucket at the time
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+    tokens := b.tokens + now.Sub(b.updated).Seconds()*l.limit.Rate
+    return math.Min(tokens, float64(l.limit.Burst))
+}
+
+// duration returns time the number of tokens is refilled in
+func (l *Limiter) duration(tokens float64) time.Duration {
+    if tokens <= 0 {
+        return 0
+    }
+    return time.Duration(tokens / l.limit.Rate * float64(time.Second))
+}
+
+// evict makes room for the new bucket by dropping an arbitrary
+// one, its client starts with the full bucket again
+func (l *Limiter) evict() {
+    for key := range l.buckets {
+        delete(l.buckets, key)
+        return
+    }
+}
+
+// sweep drops full buckets, they are the same as new ones
+func (l *Limiter) sweep(now time.Time) {
+    for key, b := range l.buckets {
+        if l.refill(b, now) >= float64(l.limit.Burst) {
+            delete(l.buckets, key)
+        }
+    }
+    l.swept = now
+}
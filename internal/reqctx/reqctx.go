@@ -0,0 +1,90 @@
+// Package reqctx keeps data of the HTTP request in its
+// context, so services can tell who made the request
+// and log entries of the request can be correlated.
+package reqctx
+
+import (
+    "context"
+    "github.com/vasch3nko/songlibrary/internal/tracing"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+)
+
+type (
+    principalKey  struct{}
+    remoteAddrKey struct{}
+    requestIdKey  struct{}
+)
+
+// WithPrincipal returns the context with the authenticated caller
+func WithPrincipal(ctx context.Context, p types.Principal) context.Context {
+    return context.WithValue(ctx, principalKey{}, p)
+}
+
+// Principal returns the authenticated caller of the request
+func Principal(ctx context.Context) (types.Principal, bool) {
+    p, ok := ctx.Value(principalKey{}).(types.Principal)
+    return p, ok
+}
+
+// Subject returns subject of the caller,
+// empty string for anonymous requests
+func Subject(ctx context.Context) string {
+    p, _ := Principal(ctx)
+    return p.Subject
+}
+
+// WithRemoteAddr returns the context with address of the client
+func WithRemoteAddr(ctx context.Context, addr string) context.Context {
+    return context.WithValue(ctx, remoteAddrKey{}, addr)
+}
+
+// RemoteAddr returns address of the client, empty
+// string if the change is not made by a request
+func RemoteAddr(ctx context.Context) string {
+    addr, _ := ctx.Value(remoteAddrKey{}).(string)
+    return addr
+}
+
+// WithRequestId returns the context with id of the request
+func WithRequestId(ctx context.Context, id string) context.Context {
+    return context.WithValue(ctx, requestIdKey{}, id)
+}
+
+// RequestId returns id of the request, empty string if it has none
+func RequestId(ctx context.Context) string {
+    id, _ := ctx.Value(requestIdKey{}).(string)
+    return id
+}
+
+// Handler is the slog.Handler that adds subject of the caller,
+// id of the request and id of the trace to the entries logged
+// with the context (InfoContext, ErrorContext and others)
+type Handler struct {
+    slog.Handler
+}
+
+func NewHandler(h slog.Handler) Handler {
+    return Handler{Handler: h}
+}
+
+func (h Handler) Handle(ctx context.Context, r slog.Record) error {
+    if subject := Subject(ctx); subject != "" {
+        r.AddAttrs(slog.String("subject", subject))
+    }
+    if id := RequestId(ctx); id != "" {
+        r.AddAttrs(slog.String("request_id", id))
+    }
+    if sc := tracing.SpanContextFromContext(ctx); sc.Sampled {
+        r.AddAttrs(slog.String("trace_id", sc.TraceId.String()))
+    }
+    return h.Handler.Handle(ctx, r)
+}
+
+func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+    return Handler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h Handler) WithGroup(name string) slog.Handler {
+    return Handler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+    "context"
+    "github.com/vasch3nko/songlibrary/internal/explicit"
+    "github.com/vasch3nko/songlibrary/internal/langdetect"
+    "github.com/vasch3nko/songlibrary/internal/textstats"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+)
+
+// Analyzer computes data that is stored along with
+// the song text: language, explicit flag and word statistics
+type Analyzer struct {
+    detector  *langdetect.Detector
+    scanner   *explicit.Scanner
+    stopWords textstats.StopWords
+    log       *slog.Logger
+}
+
+func NewAnalyzer(
+    detector *langdetect.Detector,
+    scanner *explicit.Scanner,
+    stopWords textstats.StopWords,
+    logger *slog.Logger,
+) Analyzer {
+    log := logger.With("component", "services/analyzer")
+
+    return Analyzer{
+        detector:  detector,
+        scanner:   scanner,
+        stopWords: stopWords,
+        log:       log,
+    }
+}
+
+// Analyze computes analysis of the song text
+func (a Analyzer) Analyze(ctx context.Context, text string) types.SongAnalysis {
+    entry := a.log.With(slog.String("method", "analyze"))
+
+    var analysis types.SongAnalysis
+
+    if result, ok := a.detector.Detect(text); ok {
+        analysis.Language = &result.Lang
+        analysis.LanguageConfidence = &result.Confidence
+        entry.DebugContext(ctx, "Language detected",
+            slog.String("language", result.Lang),
+            slog.Float64("confidence", result.Confidence),
+        )
+    } else {
+        entry.DebugContext(ctx, "Language not detected")
+    }
+
+    // Explicit words are searched with the list of the detected language
+    result := a.scanner.Scan(text, deref(analysis.Language))
+    analysis.Explicit = result.Explicit
+    analysis.ExplicitVerses = result.Verses
+    entry.DebugContext(ctx, "Text scanned for explicit content",
+        slog.Bool("explicit", result.Explicit),
+        slog.Any("verses", result.Verses),
+    )
+
+    // Word frequencies exclude stop words of the detected language
+    stats := textstats.Compute(text, a.stopWords.For(deref(analysis.Language)))
+    analysis.Stats = types.SongStats{
+        WordCount:      stats.WordCount,
+        UniqueWords:    stats.UniqueWords,
+        VerseCount:     stats.VerseCount,
+        AvgVerseLength: stats.AvgVerseLength,
+        WordFrequency:  stats.Frequencies,
+    }
+    entry.DebugContext(ctx, "Word statistics computed",
+        slog.Int("word_count", stats.WordCount),
+        slog.Int("unique_words", stats.UniqueWords),
+    )
+
+    return analysis
+}
+
+// Mask replaces explicit words of the text with asterisks
+func (a Analyzer) Mask(text, lang string) string {
+    return a.scanner.Mask(text, lang)
+}
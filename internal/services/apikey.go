@@ -0,0 +1,147 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/sha256"
+    "database/sql"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/storage"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+    "strings"
+)
+
+// ErrInvalidToken is returned for unknown and revoked keys
+var ErrInvalidToken = errors.New("invalid bearer token")
+
+// Keys are "sl_" followed by 32 random bytes in base64url,
+// the first characters after "sl_" are kept as the prefix
+const (
+    apiKeyPrefix    = "sl_"
+    apiKeyBytes     = 32
+    apiKeyPrefixLen = 8
+)
+
+// ApiKeyService issues, revokes and checks API keys.
+// Keys are shown once on creation, only their hashes are stored.
+type ApiKeyService struct {
+    store storage.ApiKeyStorage
+    log   *slog.Logger
+}
+
+func NewApiKeyService(store storage.ApiKeyStorage, logger *slog.Logger) ApiKeyService {
+    log := logger.With("component", "services/apikey")
+
+    return ApiKeyService{store: store, log: log}
+}
+
+func (s ApiKeyService) GetApiKeys(ctx context.Context) ([]types.ApiKey, error) {
+    entry := s.log.With(slog.String("method", "get api keys"))
+
+    keys, err := s.store.GetApiKeys(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Api keys received successfully")
+
+    return keys, nil
+}
+
+// CreateApiKey returns the new key and its stored record
+func (s ApiKeyService) CreateApiKey(ctx context.Context, req types.CreateApiKey) (string, types.ApiKey, error) {
+    entry := s.log.With(slog.String("method", "create api key"))
+
+    req.Name = strings.TrimSpace(req.Name)
+    if req.Name == "" {
+        err := errors.New("api key name is empty")
+        entry.ErrorContext(ctx, "Invalid api key", slog.Any("error", err))
+        return "", types.ApiKey{}, err
+    }
+    if len(req.Scopes) == 0 {
+        err := errors.New("api key has no scopes")
+        entry.ErrorContext(ctx, "Invalid api key", slog.Any("error", err))
+        return "", types.ApiKey{}, err
+    }
+    for _, scope := range req.Scopes {
+        if _, err := types.ParseScope(string(scope)); err != nil {
+            entry.ErrorContext(ctx, "Invalid api key", slog.Any("error", err))
+            return "", types.ApiKey{}, err
+        }
+    }
+
+    b := make([]byte, apiKeyBytes)
+    if _, err := rand.Read(b); err != nil {
+        entry.ErrorContext(ctx, "Failed to generate api key", slog.Any("error", err))
+        return "", types.ApiKey{}, err
+    }
+    secret := base64.RawURLEncoding.EncodeToString(b)
+    key := apiKeyPrefix + secret
+
+    created, err := s.store.CreateApiKey(ctx, req, secret[:apiKeyPrefixLen], hashApiKey(key))
+    if err != nil {
+        return "", types.ApiKey{}, err
+    }
+
+    entry.InfoContext(ctx, "Api key created successfully", slog.Int("id", created.Id))
+
+    return key, created, nil
+}
+
+func (s ApiKeyService) RevokeApiKey(ctx context.Context, id int) error {
+    entry := s.log.With(slog.String("method", "revoke api key"))
+
+    if err := s.store.RevokeApiKey(ctx, id); err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return fmt.Errorf("no active api key %d", id)
+        }
+        return err
+    }
+
+    entry.InfoContext(ctx, "Api key revoked successfully", slog.Int("id", id))
+
+    return nil
+}
+
+// Authenticate returns the principal of the active key
+// and records that the key was used
+func (s ApiKeyService) Authenticate(ctx context.Context, token string) (types.Principal, error) {
+    entry := s.log.With(slog.String("method", "authenticate api key"))
+
+    if !strings.HasPrefix(token, apiKeyPrefix) {
+        return types.Principal{}, ErrInvalidToken
+    }
+
+    key, err := s.store.GetApiKeyByHash(ctx, hashApiKey(token))
+    if errors.Is(err, sql.ErrNoRows) {
+        return types.Principal{}, ErrInvalidToken
+    }
+    if err != nil {
+        return types.Principal{}, err
+    }
+    if key.RevokedAt != nil {
+        entry.WarnContext(ctx, "Revoked api key used", slog.Int("id", key.Id))
+        return types.Principal{}, ErrInvalidToken
+    }
+
+    // Failed bookkeeping does not fail the request
+    if err := s.store.TouchApiKey(ctx, key.Id); err != nil {
+        entry.WarnContext(ctx, "Failed to record api key use", slog.Int("id", key.Id), slog.Any("error", err))
+    }
+
+    return types.Principal{
+        Subject: fmt.Sprintf("apikey:%d", key.Id),
+        Scopes:  key.Scopes,
+    }, nil
+}
+
+// hashApiKey returns hex SHA-256 of the key. Keys are random,
+// so a fast unsalted hash is enough to keep them secret.
+func hashApiKey(key string) string {
+    sum := sha256.Sum256([]byte(key))
+    return hex.EncodeToString(sum[:])
+}
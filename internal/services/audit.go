@@ -0,0 +1,64 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "github.com/vasch3nko/songlibrary/internal/storage"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+    "time"
+)
+
+// AuditService reads the audit log of song changes
+// and deletes events older than the retention period
+type AuditService struct {
+    store storage.AuditStorage
+    log   *slog.Logger
+}
+
+func NewAuditService(store storage.AuditStorage, logger *slog.Logger) AuditService {
+    log := logger.With("component", "services/audit")
+
+    return AuditService{store: store, log: log}
+}
+
+func (s AuditService) GetAuditEvents(ctx context.Context, filter types.GetAuditEvents, page int, limit int) ([]types.AuditEvent, error) {
+    entry := s.log.With(slog.String("method", "get audit events"))
+
+    if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+        err := errors.New("audit time range is empty")
+        entry.ErrorContext(ctx, "Invalid audit filter", slog.Any("error", err))
+        return nil, err
+    }
+
+    events, err := s.store.GetAuditEvents(ctx, filter, (page-1)*limit, limit)
+    if err != nil {
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Audit events received successfully")
+
+    return events, nil
+}
+
+// PruneAuditEvents deletes events older than the retention
+// period, zero retention keeps events forever
+func (s AuditService) PruneAuditEvents(ctx context.Context, retention time.Duration) (int64, error) {
+    entry := s.log.With(slog.String("method", "prune audit events"))
+
+    if retention <= 0 {
+        return 0, nil
+    }
+
+    deleted, err := s.store.DeleteAuditEventsBefore(ctx, time.Now().Add(-retention))
+    if err != nil {
+        return 0, err
+    }
+
+    entry.InfoContext(ctx, "Audit events pruned successfully",
+        slog.Int64("deleted", deleted),
+        slog.Duration("retention", retention),
+    )
+
+    return deleted, nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/tracing"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+    "sync"
+)
+
+// ErrBatchTooLarge is returned when batch has
+// more songs than the batch limits allow
+var ErrBatchTooLarge = errors.New("batch is too large")
+
+// BatchLimits describes how many songs can be
+// created at once and how many of them are enriched
+// from external API concurrently
+type BatchLimits struct {
+    MaxSize     int
+    Concurrency int
+}
+
+func (l BatchLimits) Validate() error {
+    if l.MaxSize < 1 {
+        return fmt.Errorf("invalid batch max size %d", l.MaxSize)
+    }
+    if l.Concurrency < 1 {
+        return fmt.Errorf("invalid batch concurrency %d", l.Concurrency)
+    }
+    return nil
+}
+
+// CreateSongs enriches and creates songs of the batch.
+// Atomic batch is created in a single transaction and
+// nothing is created if any song fails, otherwise every
+// song is created on its own. Songs of the batch are not
+// checked for duplicates of each other.
+func (s SongService) CreateSongs(ctx context.Context, reqs []types.CreateSong, onConflict types.OnConflict, atomic bool) ([]types.BatchItemResult, error) {
+    ctx, span := tracing.Start(ctx, "SongService.CreateSongs", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "create songs"))
+
+    if err := validateOnConflict(onConflict); err != nil {
+        entry.ErrorContext(ctx, "Invalid on conflict mode", slog.Any("error", err))
+        return nil, err
+    }
+
+    if len(reqs) > s.batch.MaxSize {
+        err := fmt.Errorf("%w: %d songs, at most %d allowed", ErrBatchTooLarge, len(reqs), s.batch.MaxSize)
+        entry.ErrorContext(ctx, "Batch rejected", slog.Any("error", err))
+        return nil, err
+    }
+
+    results := make([]types.BatchItemResult, len(reqs))
+    prepared := make([]types.CreateSong, len(reqs))
+
+    // Enriching songs with bounded concurrency
+    var wg sync.WaitGroup
+    sem := make(chan struct{}, s.batch.Concurrency)
+    for i, req := range reqs {
+        wg.Add(1)
+        sem <- struct{}{}
+        go func() {
+            defer wg.Done()
+            defer func() { <-sem }()
+
+            song, result, err := s.prepareSong(ctx, req, onConflict)
+            results[i] = types.BatchItemResult{
+                Index:      i,
+                Duplicates: result.Duplicates,
+                Warning:    result.Warning,
+            }
+            if err != nil {
+                results[i].Status = types.SongFailed
+                results[i].Error = err.Error()
+                return
+            }
+            prepared[i] = song
+        }()
+    }
+    wg.Wait()
+
+    // Indices of the songs ready to be created
+    var ready []int
+    for i := range results {
+        if results[i].Status != types.SongFailed {
+            ready = append(ready, i)
+        }
+    }
+
+    if atomic {
+        s.createAtomic(ctx, prepared, ready, results, onConflict)
+    } else {
+        for _, i := range ready {
+            created, err := s.store.CreateSong(ctx, prepared[i], onConflict)
+            if err != nil {
+                results[i].Status = types.SongFailed
+                results[i].Error = err.Error()
+                continue
+            }
+            results[i].Id = created.Id
+            results[i].Status = created.Status
+            s.indexCreatedSong(ctx, prepared[i], created)
+        }
+    }
+
+    counts := map[string]int{}
+    for _, result := range results {
+        counts[result.Status]++
+    }
+    entry.InfoContext(ctx, "Batch processed",
+        slog.Int("size", len(reqs)),
+        slog.Bool("atomic", atomic),
+        slog.Any("statuses", counts),
+    )
+
+    return results, nil
+}
+
+// createAtomic creates ready songs in a single transaction
+// if none of the batch songs failed, otherwise ready
+// songs are marked as skipped
+func (s SongService) createAtomic(ctx context.Context, prepared []types.CreateSong, ready []int, results []types.BatchItemResult, onConflict types.OnConflict) {
+    skipAll := func() {
+        for _, i := range ready {
+            if results[i].Status == "" {
+                results[i].Status = types.SongSkipped
+            }
+        }
+    }
+
+    if len(ready) < len(results) {
+        skipAll()
+        return
+    }
+
+    songs := make([]types.CreateSong, len(ready))
+    for n, i := range ready {
+        songs[n] = prepared[i]
+    }
+
+    created, err := s.store.CreateSongs(ctx, songs, onConflict)
+    if err != nil {
+        // Songs before the failed one were rolled back
+        if len(created) < len(ready) {
+            failed := ready[len(created)]
+            results[failed].Status = types.SongFailed
+            results[failed].Error = err.Error()
+        }
+        skipAll()
+        return
+    }
+
+    for n, i := range ready {
+        results[i].Id = created[n].Id
+        results[i].Status = created[n].Status
+        s.indexCreatedSong(ctx, prepared[i], created[n])
+    }
+}
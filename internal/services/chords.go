@@ -0,0 +1,88 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "github.com/vasch3nko/songlibrary/internal/chordpro"
+    "github.com/vasch3nko/songlibrary/internal/tracing"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+)
+
+// GetChordSheet returns parsed chord sheet of the song
+// transposed and paged according to options
+func (s SongService) GetChordSheet(ctx context.Context, id int, opts types.ChordSheetOptions) (chordpro.Document, error) {
+    ctx, span := tracing.Start(ctx, "SongService.GetChordSheet", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "get chord sheet"))
+
+    sheet, err := s.store.GetSongChordSheet(ctx, id)
+    if err != nil {
+        return chordpro.Document{}, err
+    }
+
+    if sheet == "" {
+        err := errors.New("song has no chord sheet")
+        entry.ErrorContext(ctx, "Chord sheet not found",
+            slog.Int("id", id),
+            slog.Any("error", err),
+        )
+        return chordpro.Document{}, err
+    }
+
+    doc, err := chordpro.Parse(sheet)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to parse stored chord sheet",
+            slog.Int("id", id),
+            slog.Any("error", err),
+        )
+        return chordpro.Document{}, err
+    }
+
+    // Paging by sections the same way as the song text is paged by verses
+    if opts.Page > 0 {
+        doc, err = doc.Page(opts.Page)
+        if err != nil {
+            entry.ErrorContext(ctx, "Invalid parameter page",
+                slog.Any("error", err),
+            )
+            return chordpro.Document{}, err
+        }
+        entry.DebugContext(ctx, "Page validated successfully", slog.Int("page", opts.Page))
+    }
+
+    if opts.Transpose != 0 || opts.Flats {
+        doc = doc.Transpose(opts.Transpose, opts.Flats)
+        entry.DebugContext(ctx, "Chord sheet transposed",
+            slog.Int("semitones", opts.Transpose),
+            slog.Bool("flats", opts.Flats),
+        )
+    }
+
+    entry.InfoContext(ctx, "Chord sheet received successfully")
+
+    return doc, nil
+}
+
+// SetChordSheet validates and stores ChordPro chord sheet
+// of the song, empty document removes the chord sheet
+func (s SongService) SetChordSheet(ctx context.Context, id int, req types.SetChordSheet) error {
+    ctx, span := tracing.Start(ctx, "SongService.SetChordSheet", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "set chord sheet"))
+
+    if err := s.UpdateSong(ctx, id, types.UpdateSong{ChordSheet: &req.ChordPro}); err != nil {
+        return err
+    }
+
+    if req.ChordPro == "" {
+        entry.InfoContext(ctx, "Chord sheet removed successfully", slog.Int("id", id))
+        return nil
+    }
+
+    entry.InfoContext(ctx, "Chord sheet updated successfully", slog.Int("id", id))
+
+    return nil
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/similarity"
+    "github.com/vasch3nko/songlibrary/internal/tracing"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+    "regexp"
+    "strings"
+    "unicode"
+)
+
+// Duplicate policies of the song creation
+const (
+    DuplicateReject = "reject"
+    DuplicateWarn   = "warn"
+    DuplicateAllow  = "allow"
+)
+
+// DuplicatePolicy describes how duplicates
+// are detected and handled on song creation
+type DuplicatePolicy struct {
+    Mode string
+    // Lyrics similarity from 0 to 1 that counts as the same lyrics
+    LyricsThreshold float64
+}
+
+func (p DuplicatePolicy) Validate() error {
+    switch p.Mode {
+    case DuplicateReject, DuplicateWarn, DuplicateAllow:
+    default:
+        return fmt.Errorf("invalid duplicate policy %q", p.Mode)
+    }
+    if p.LyricsThreshold <= 0 || p.LyricsThreshold > 1 {
+        return fmt.Errorf("invalid lyrics similarity threshold %v", p.LyricsThreshold)
+    }
+    return nil
+}
+
+// DuplicateError is returned when created song
+// is the duplicate and policy rejects duplicates
+type DuplicateError struct {
+    Ids []int
+}
+
+func (e *DuplicateError) Error() string {
+    return fmt.Sprintf("song is a duplicate of %v", e.Ids)
+}
+
+// Parts of the titles that do not make songs different
+// ("(feat. Somebody)", "[Remastered 2011]", "- Live")
+var titleNoise = regexp.MustCompile(`(?i)\s*(\(.*?\)|\[.*?\]|\s-\s.*$|\bfeat\..*$|\bft\..*$)`)
+
+// normalizeTitle brings song or group name to the form
+// that ignores case, punctuation, decorations and leading "the"
+func normalizeTitle(s string) string {
+    s = titleNoise.ReplaceAllString(s, "")
+    s = strings.Map(func(r rune) rune {
+        switch {
+        case unicode.IsLetter(r) || unicode.IsDigit(r):
+            return unicode.ToLower(r)
+        case unicode.IsSpace(r) || unicode.IsPunct(r):
+            return ' '
+        }
+        return -1
+    }, s)
+    s = strings.Join(strings.Fields(s), " ")
+    s = strings.TrimPrefix(s, "the ")
+    s = strings.ReplaceAll(s, " and ", " ")
+    return s
+}
+
+// isDuplicate decides whether two songs are the same track:
+// at least two of normalized name, normalized group
+// and lyrics must match
+func (s SongService) isDuplicate(a, b similarity.Meta, score float64) bool {
+    signals := 0
+    if normalizeTitle(a.Song) == normalizeTitle(b.Song) {
+        signals++
+    }
+    if normalizeTitle(a.Group) == normalizeTitle(b.Group) {
+        signals++
+    }
+    if score >= s.duplicates.LyricsThreshold {
+        signals++
+    }
+    return signals >= 2
+}
+
+// sameKey reports whether songs collide on the unique
+// group and name key, which is case-insensitive and trimmed
+func sameKey(a, b similarity.Meta) bool {
+    return strings.EqualFold(strings.TrimSpace(a.Song), strings.TrimSpace(b.Song)) &&
+        strings.EqualFold(strings.TrimSpace(a.Group), strings.TrimSpace(b.Group))
+}
+
+// TitleKeys are the keys of the song in the similarity index:
+// normalized name and normalized group, duplicates are
+// looked for only among songs sharing one of them
+func TitleKeys(meta similarity.Meta) []string {
+    return []string{"song:" + normalizeTitle(meta.Song), "group:" + normalizeTitle(meta.Group)}
+}
+
+// findDuplicates returns ids of indexed songs that are duplicates
+// of the song with the analysis. Lyrics alone are not enough, so
+// only songs sharing normalized name or group are compared.
+// Unless conflicts are errors, songs with the same key
+// are resolved by the conflict mode and are not reported.
+func (s SongService) findDuplicates(song types.CreateSong, onConflict types.OnConflict) []int {
+    meta := similarity.Meta{Song: song.Song, Group: song.Group}
+    candidates := s.index.Candidates(TitleKeys(meta))
+
+    // Number of matching titles of every candidate
+    signals := make(map[int]int, len(candidates))
+    var compare []int
+    for _, doc := range candidates {
+        if onConflict != types.OnConflictError && sameKey(meta, doc) {
+            continue
+        }
+        if normalizeTitle(doc.Song) == normalizeTitle(song.Song) {
+            signals[doc.Id]++
+        }
+        if normalizeTitle(doc.Group) == normalizeTitle(song.Group) {
+            signals[doc.Id]++
+        }
+        // Lyrics decide for the songs with one matching title
+        if signals[doc.Id] < 2 {
+            compare = append(compare, doc.Id)
+        }
+    }
+    scores := s.index.ScoreTerms(song.Analysis.Stats.WordFrequency, compare)
+
+    var ids []int
+    for _, doc := range candidates {
+        if n, ok := signals[doc.Id]; ok && (n >= 2 || scores[doc.Id] >= s.duplicates.LyricsThreshold) {
+            ids = append(ids, doc.Id)
+        }
+    }
+    return ids
+}
+
+// GetDuplicates returns groups of songs that are
+// most likely the same track entered several times
+func (s SongService) GetDuplicates(ctx context.Context) ([]types.DuplicateGroup, error) {
+    ctx, span := tracing.Start(ctx, "SongService.GetDuplicates", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "get duplicates"))
+
+    // Vectors of the lyrics are computed once for all pairs
+    vectors := s.index.Vectors()
+    docs := vectors.Documents()
+
+    // Only songs sharing normalized name or group are compared,
+    // lyrics alone are not enough to be a duplicate
+    buckets := map[string][]int{}
+    for i, doc := range docs {
+        song, group := "song:"+normalizeTitle(doc.Song), "group:"+normalizeTitle(doc.Group)
+        buckets[song] = append(buckets[song], i)
+        buckets[group] = append(buckets[group], i)
+    }
+
+    // Union-find of duplicate songs
+    parent := make([]int, len(docs))
+    for i := range parent {
+        parent[i] = i
+    }
+    var find func(int) int
+    find = func(i int) int {
+        if parent[i] != i {
+            parent[i] = find(parent[i])
+        }
+        return parent[i]
+    }
+
+    for _, bucket := range buckets {
+        for a := 0; a < len(bucket); a++ {
+            for b := a + 1; b < len(bucket); b++ {
+                i, j := bucket[a], bucket[b]
+                if find(i) == find(j) {
+                    continue
+                }
+                if s.isDuplicate(docs[i], docs[j], vectors.Score(docs[i].Id, docs[j].Id)) {
+                    parent[find(j)] = find(i)
+                }
+            }
+        }
+    }
+
+    byRoot := map[int][]types.DuplicateSong{}
+    var roots []int
+    for i, doc := range docs {
+        root := find(i)
+        if _, ok := byRoot[root]; !ok {
+            roots = append(roots, root)
+        }
+        byRoot[root] = append(byRoot[root], types.DuplicateSong{Id: doc.Id, Song: doc.Song, Group: doc.Group})
+    }
+
+    var groups []types.DuplicateGroup
+    for _, root := range roots {
+        if len(byRoot[root]) > 1 {
+            groups = append(groups, types.DuplicateGroup{Songs: byRoot[root]})
+        }
+    }
+
+    entry.InfoContext(ctx, "Duplicates received successfully", slog.Int("groups", len(groups)))
+
+    return groups, nil
+}
+
+// MergeSongs folds the source song into the target one.
+// Empty fields of the target are filled from the source,
+// translations the target lacks, tags and playlist entries are moved,
+// the source is deleted.
+func (s SongService) MergeSongs(ctx context.Context, targetId int, req types.MergeSongs) error {
+    ctx, span := tracing.Start(ctx, "SongService.MergeSongs", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "merge songs"))
+
+    if targetId == req.SourceId {
+        err := errors.New("song cannot be merged into itself")
+        entry.ErrorContext(ctx, "Invalid merge source", slog.Any("error", err))
+        return err
+    }
+
+    // Text of the target could be taken from the source,
+    // it is analyzed in the transaction of the merge
+    analysis, err := s.store.MergeSongs(ctx, targetId, req.SourceId, func(text string) types.SongAnalysis {
+        return s.analyzer.Analyze(ctx, text)
+    })
+    if err != nil {
+        return err
+    }
+
+    s.index.Remove(req.SourceId)
+    s.reindexSong(ctx, targetId, analysis)
+
+    entry.InfoContext(ctx, "Songs merged successfully",
+        slog.Int("target_id", targetId),
+        slog.Int("source_id", req.SourceId),
+    )
+
+    return nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+    "github.com/vasch3nko/songlibrary/internal/metrics"
+    "time"
+)
+
+// Outcomes of the song detail requests to the external API
+const (
+    enrichmentSuccess         = "success"
+    enrichmentError           = "error"
+    enrichmentBadStatus       = "bad_status"
+    enrichmentInvalidResponse = "invalid_response"
+)
+
+// enrichmentMetrics count and time the song
+// detail requests to the external API by outcome
+type enrichmentMetrics struct {
+    requests *metrics.CounterVec
+    duration *metrics.HistogramVec
+}
+
+func newEnrichmentMetrics(registry *metrics.Registry) enrichmentMetrics {
+    return enrichmentMetrics{
+        requests: registry.Counter("songlibrary_enrichment_requests_total",
+            "Song detail requests to the external API by outcome.",
+            "outcome"),
+        duration: registry.Histogram("songlibrary_enrichment_request_duration_seconds",
+            "Duration of the song detail requests to the external API by outcome.",
+            metrics.DefaultBuckets, "outcome"),
+    }
+}
+
+// observe records the request started at start
+func (m enrichmentMetrics) observe(outcome string, start time.Time) {
+    m.requests.Inc(outcome)
+    m.duration.Observe(time.Since(start).Seconds(), outcome)
+}
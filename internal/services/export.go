@@ -0,0 +1,49 @@
+package services
+
+import (
+    "context"
+    "github.com/vasch3nko/songlibrary/internal/songio"
+    "github.com/vasch3nko/songlibrary/internal/tracing"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "io"
+    "log/slog"
+)
+
+// Export writes every song matching the filter to w in the
+// format readable by Import. Songs are streamed, so nothing
+// but the current batch of songs is kept in memory.
+// Export is lossy: only the fields of the import format are
+// written, synced lyrics, chord sheets, translations, tags
+// and explicit overrides are not.
+func (s SongService) Export(ctx context.Context, w io.Writer, format string, filter types.GetSongs) (int, error) {
+    ctx, span := tracing.Start(ctx, "SongService.Export", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "export"))
+
+    writer, err := songio.NewWriter(w, format)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to start export", slog.Any("error", err))
+        return 0, err
+    }
+
+    count := 0
+    err = s.store.ForEachSong(ctx, filter, func(song types.Song) error {
+        count++
+        return writer.Write(songio.NewRecord(song))
+    })
+    if err == nil {
+        err = writer.Close()
+    }
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to export songs", slog.Int("written", count), slog.Any("error", err))
+        return count, err
+    }
+
+    entry.InfoContext(ctx, "Songs exported successfully",
+        slog.String("format", format),
+        slog.Int("count", count),
+    )
+
+    return count, nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/storage"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+    "net/http"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// Names of the checked dependencies
+const (
+    checkDatabase   = "database"
+    checkMigrations = "migrations"
+    checkEnrichment = "enrichment"
+)
+
+// HealthChecks describes what readiness depends on
+type HealthChecks struct {
+    // Version of the latest migration the database must be at
+    LatestMigration int64
+    // Song details API is checked if the url is not empty
+    SongDetailApiUrl string
+    // Longest time of every check
+    Timeout time.Duration
+}
+
+func (c HealthChecks) Validate() error {
+    if c.Timeout <= 0 {
+        return errors.New("health check timeout must be positive")
+    }
+    return nil
+}
+
+// HealthService checks dependencies of the service. It is
+// shared by the server and its shutdown, so it is a pointer.
+type HealthService struct {
+    store    storage.HealthStorage
+    checks   HealthChecks
+    client   *http.Client
+    draining atomic.Bool
+    log      *slog.Logger
+
+    // Last status of every dependency, changes are logged
+    mu     sync.Mutex
+    status map[string]string
+}
+
+func NewHealthService(store storage.HealthStorage, checks HealthChecks, logger *slog.Logger) *HealthService {
+    log := logger.With("component", "services/health")
+
+    return &HealthService{
+        store:  store,
+        checks: checks,
+        client: &http.Client{},
+        log:    log,
+        status: map[string]string{},
+    }
+}
+
+// Drain makes the service not ready, so no new
+// requests are routed to it while it shuts down
+func (s *HealthService) Drain() {
+    s.draining.Store(true)
+}
+
+// Ready checks the dependencies concurrently
+func (s *HealthService) Ready(ctx context.Context) types.Readiness {
+    entry := s.log.With(slog.String("method", "ready"))
+
+    ctx, cancel := context.WithTimeout(ctx, s.checks.Timeout)
+    defer cancel()
+
+    checks := map[string]func(context.Context) error{
+        checkDatabase:   s.store.Ping,
+        checkMigrations: s.checkMigrations,
+    }
+    if s.checks.SongDetailApiUrl != "" {
+        checks[checkEnrichment] = s.checkEnrichment
+    }
+
+    readiness := types.Readiness{Status: types.HealthUp, Checks: map[string]types.HealthCheck{}}
+    var mu sync.Mutex
+    var wg sync.WaitGroup
+    for name, check := range checks {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            result := runCheck(ctx, check)
+
+            mu.Lock()
+            defer mu.Unlock()
+            readiness.Checks[name] = result
+            if result.Status != types.HealthUp {
+                readiness.Status = types.HealthDown
+            }
+        }()
+    }
+    wg.Wait()
+
+    s.logChanges(ctx, entry, readiness.Checks)
+
+    if s.draining.Load() {
+        readiness.Status = types.HealthDraining
+    }
+
+    return readiness
+}
+
+// logChanges logs the dependencies whose status differs from the
+// previous check, so failing probes do not repeat the same warning
+func (s *HealthService) logChanges(ctx context.Context, entry *slog.Logger, checks map[string]types.HealthCheck) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    for name, check := range checks {
+        previous, ok := s.status[name]
+        if previous == check.Status {
+            continue
+        }
+        s.status[name] = check.Status
+
+        if check.Status != types.HealthUp {
+            entry.WarnContext(ctx, "Dependency is down",
+                slog.String("dependency", name),
+                slog.String("error", check.Error),
+            )
+        } else if ok {
+            entry.InfoContext(ctx, "Dependency is up again", slog.String("dependency", name))
+        }
+    }
+}
+
+// runCheck times the check
+func runCheck(ctx context.Context, check func(context.Context) error) types.HealthCheck {
+    start := time.Now()
+    err := check(ctx)
+    result := types.HealthCheck{
+        Status:    types.HealthUp,
+        LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+    }
+    if err != nil {
+        result.Status = types.HealthDown
+        result.Error = err.Error()
+    }
+    return result
+}
+
+// checkMigrations checks that the database is at the latest migration
+func (s *HealthService) checkMigrations(ctx context.Context) error {
+    current, err := s.store.MigrationVersion(ctx)
+    if err != nil {
+        return err
+    }
+    if current != s.checks.LatestMigration {
+        return fmt.Errorf("database is at version %d, latest migration is %d", current, s.checks.LatestMigration)
+    }
+    return nil
+}
+
+// checkEnrichment checks that the song details API responds,
+// any status counts since the request has no song
+func (s *HealthService) checkEnrichment(ctx context.Context) error {
+    req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.checks.SongDetailApiUrl+"/info", nil)
+    if err != nil {
+        return err
+    }
+    resp, err := s.client.Do(req)
+    if err != nil {
+        return err
+    }
+    return resp.Body.Close()
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/songio"
+    "github.com/vasch3nko/songlibrary/internal/storage"
+    "github.com/vasch3nko/songlibrary/internal/tracing"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "io"
+    "log/slog"
+    "sort"
+    "strings"
+    "sync"
+)
+
+// Number of rows written to the storage at once
+const importChunkSize = 1000
+
+// Import reads songs file and writes valid rows to the storage.
+// Rows with invalid fields, rows repeating previous ones and rows
+// that failed enrichment are rejected and reported with the reason.
+func (s SongService) Import(ctx context.Context, r io.Reader, opts types.ImportOptions) (types.ImportResult, error) {
+    ctx, span := tracing.Start(ctx, "SongService.Import", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "import"))
+
+    result := types.ImportResult{Rejected: []types.ImportRejectedRow{}}
+
+    if err := validateOnConflict(opts.OnConflict); err != nil {
+        entry.ErrorContext(ctx, "Invalid on conflict mode", slog.Any("error", err))
+        return result, err
+    }
+
+    reader, err := songio.NewReader(r, opts.Format, opts.Mapping)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to read songs file", slog.Any("error", err))
+        return result, err
+    }
+
+    // First row of every group and name of the file
+    seen := map[string]int{}
+    var chunk []songio.Record
+    for {
+        record, err := reader.Read()
+        if errors.Is(err, io.EOF) {
+            break
+        }
+        var rowErr *songio.RowError
+        if errors.As(err, &rowErr) {
+            result.Rows++
+            reject(&result, rowErr.Row, rowErr.Raw, rowErr.Err)
+            continue
+        }
+        if err != nil {
+            entry.ErrorContext(ctx, "Failed to read songs file", slog.Any("error", err))
+            return result, err
+        }
+        result.Rows++
+
+        key := strings.ToLower(strings.TrimSpace(record.Group)) + "\x00" + strings.ToLower(strings.TrimSpace(record.Song))
+        if row, ok := seen[key]; ok {
+            reject(&result, record.Row, record.Raw, fmt.Errorf("same song as row %d", row))
+            continue
+        }
+        seen[key] = record.Row
+
+        chunk = append(chunk, record)
+        if len(chunk) == importChunkSize {
+            if err := s.importChunk(ctx, chunk, opts, &result); err != nil {
+                return result, err
+            }
+            chunk = chunk[:0]
+        }
+    }
+    if len(chunk) > 0 {
+        if err := s.importChunk(ctx, chunk, opts, &result); err != nil {
+            return result, err
+        }
+    }
+
+    sort.Slice(result.Rejected, func(i, j int) bool {
+        return result.Rejected[i].Row < result.Rejected[j].Row
+    })
+
+    // Imported songs are added to the similarity index
+    if result.Created+result.Updated > 0 {
+        if err := s.BuildIndex(ctx); err != nil {
+            entry.ErrorContext(ctx, "Failed to index imported songs", slog.Any("error", err))
+        }
+    }
+
+    entry.InfoContext(ctx, "Songs file imported",
+        slog.Int("rows", result.Rows),
+        slog.Int("created", result.Created),
+        slog.Int("existing", result.Existing),
+        slog.Int("updated", result.Updated),
+        slog.Int("rejected", len(result.Rejected)),
+    )
+
+    return result, nil
+}
+
+// importChunk prepares records with bounded concurrency
+// and writes the valid ones to the storage
+func (s SongService) importChunk(ctx context.Context, records []songio.Record, opts types.ImportOptions, result *types.ImportResult) error {
+    songs := make([]types.CreateSong, len(records))
+    errs := make([]error, len(records))
+
+    var wg sync.WaitGroup
+    sem := make(chan struct{}, s.batch.Concurrency)
+    for i, record := range records {
+        wg.Add(1)
+        sem <- struct{}{}
+        go func() {
+            defer wg.Done()
+            defer func() { <-sem }()
+            songs[i], errs[i] = s.prepareRecord(ctx, record, opts)
+        }()
+    }
+    wg.Wait()
+
+    var valid []types.CreateSong
+    var validRecords []songio.Record
+    for i, record := range records {
+        if errs[i] != nil {
+            reject(result, record.Row, record.Raw, errs[i])
+            continue
+        }
+        valid = append(valid, songs[i])
+        validRecords = append(validRecords, record)
+    }
+    if len(valid) == 0 {
+        return nil
+    }
+
+    statuses, err := s.store.ImportSongs(ctx, valid, opts.OnConflict)
+    if err != nil {
+        return err
+    }
+
+    for i, status := range statuses {
+        switch status {
+        case types.SongCreated:
+            result.Created++
+        case types.SongUpdated:
+            result.Updated++
+        case types.SongExisting:
+            if opts.OnConflict == types.OnConflictError {
+                reject(result, validRecords[i].Row, validRecords[i].Raw, storage.ErrConflict)
+                continue
+            }
+            result.Existing++
+        }
+    }
+
+    return nil
+}
+
+// prepareRecord validates the record, requests missing
+// details from external API if enabled, analyzes the text
+// and rejects duplicates if the policy rejects them
+func (s SongService) prepareRecord(ctx context.Context, record songio.Record, opts types.ImportOptions) (types.CreateSong, error) {
+    req := types.CreateSong{
+        Song:  strings.TrimSpace(record.Song),
+        Group: strings.TrimSpace(record.Group),
+    }
+    if req.Song == "" {
+        return req, errors.New("song is missing")
+    }
+    if req.Group == "" {
+        return req, errors.New("group is missing")
+    }
+    req.Text = record.Text
+    req.Link = record.Link
+
+    hasDate := strings.TrimSpace(record.ReleaseDate) != ""
+    if hasDate {
+        if err := req.ReleaseDate.Scan(strings.TrimSpace(record.ReleaseDate)); err != nil {
+            return req, fmt.Errorf("invalid release date %q", record.ReleaseDate)
+        }
+    }
+
+    // Filling only the details missing in the file
+    if opts.Enrich && (req.Text == "" || req.Link == "" || !hasDate) {
+        detail, err := s.fetchSongDetail(ctx, req.Song, req.Group)
+        if err != nil {
+            return req, fmt.Errorf("enrichment failed: %w", err)
+        }
+        if req.Text == "" {
+            req.Text = detail.Text
+        }
+        if req.Link == "" {
+            req.Link = detail.Link
+        }
+        if !hasDate {
+            req.ReleaseDate = detail.ReleaseDate
+            hasDate = true
+        }
+    }
+    if !hasDate {
+        return req, errors.New("release date is missing")
+    }
+
+    req.Analysis = s.analyzer.Analyze(ctx, req.Text)
+
+    if s.duplicates.Mode == DuplicateReject {
+        if ids := s.findDuplicates(req, opts.OnConflict); len(ids) > 0 {
+            return req, &DuplicateError{Ids: ids}
+        }
+    }
+
+    return req, nil
+}
+
+func reject(result *types.ImportResult, row int, record string, err error) {
+    result.Rejected = append(result.Rejected, types.ImportRejectedRow{
+        Row:    row,
+        Reason: err.Error(),
+        Record: record,
+    })
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/jwt"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+)
+
+// JwtRoles maps values of the roles claim to the scopes.
+// Claim is a dotted path ("realm_access.roles"), its value
+// is an array of strings or a space separated string.
+type JwtRoles struct {
+    Claim  string
+    Viewer string
+    Editor string
+    Admin  string
+}
+
+// scopes returns scopes of the role values, unknown values are ignored
+func (r JwtRoles) scopes(roles []string) []types.Scope {
+    scopeByRole := map[string]types.Scope{
+        r.Viewer: types.ScopeRead,
+        r.Editor: types.ScopeWrite,
+        r.Admin:  types.ScopeAdmin,
+    }
+
+    var scopes []types.Scope
+    for _, role := range roles {
+        if scope, ok := scopeByRole[role]; ok && role != "" {
+            scopes = append(scopes, scope)
+        }
+    }
+    return scopes
+}
+
+// JwtAuthenticator checks JWTs issued by the platform
+// and maps their roles claim to the scopes
+type JwtAuthenticator struct {
+    verifier jwt.Verifier
+    roles    JwtRoles
+    log      *slog.Logger
+}
+
+func NewJwtAuthenticator(verifier jwt.Verifier, roles JwtRoles, logger *slog.Logger) JwtAuthenticator {
+    log := logger.With("component", "services/jwt")
+
+    return JwtAuthenticator{verifier: verifier, roles: roles, log: log}
+}
+
+// Authenticate returns the principal of the valid token,
+// subject of the principal is "user:" followed by "sub" claim
+func (a JwtAuthenticator) Authenticate(ctx context.Context, token string) (types.Principal, error) {
+    entry := a.log.With(slog.String("method", "authenticate jwt"))
+
+    claims, err := a.verifier.Verify(token)
+    if errors.Is(err, jwt.ErrInvalid) {
+        entry.DebugContext(ctx, "Token rejected", slog.Any("error", err))
+        return types.Principal{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+    }
+    if err != nil {
+        return types.Principal{}, err
+    }
+
+    sub := claims.Subject()
+    if sub == "" {
+        entry.DebugContext(ctx, "Token has no subject")
+        return types.Principal{}, fmt.Errorf("%w: sub claim is missing", ErrInvalidToken)
+    }
+
+    return types.Principal{
+        Subject: "user:" + sub,
+        Scopes:  a.roles.scopes(claims.Strings(a.roles.Claim)),
+    }, nil
+}
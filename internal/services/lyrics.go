@@ -0,0 +1,132 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "github.com/vasch3nko/songlibrary/internal/lrc"
+    "github.com/vasch3nko/songlibrary/internal/tracing"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+    "time"
+)
+
+// GetSyncedLyrics returns parsed time-synced lyrics of the song
+func (s SongService) GetSyncedLyrics(ctx context.Context, id int) (lrc.Lyrics, error) {
+    ctx, span := tracing.Start(ctx, "SongService.GetSyncedLyrics", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "get synced lyrics"))
+
+    doc, err := s.store.GetSongSyncedLyrics(ctx, id)
+    if err != nil {
+        return lrc.Lyrics{}, err
+    }
+
+    if doc == "" {
+        err := errors.New("song has no synced lyrics")
+        entry.ErrorContext(ctx, "Synced lyrics not found",
+            slog.Int("id", id),
+            slog.Any("error", err),
+        )
+        return lrc.Lyrics{}, err
+    }
+
+    lyrics, err := lrc.Parse(doc)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to parse stored synced lyrics",
+            slog.Int("id", id),
+            slog.Any("error", err),
+        )
+        return lrc.Lyrics{}, err
+    }
+
+    entry.InfoContext(ctx, "Synced lyrics received successfully")
+
+    return lyrics, nil
+}
+
+// GetSyncedLyricsLine returns index of the line that is active
+// at the playback position t and the lyrics it belongs to.
+// Index is -1 if t is before the first line.
+func (s SongService) GetSyncedLyricsLine(ctx context.Context, id int, t time.Duration) (int, lrc.Lyrics, error) {
+    ctx, span := tracing.Start(ctx, "SongService.GetSyncedLyricsLine", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "get synced lyrics line"))
+
+    lyrics, err := s.GetSyncedLyrics(ctx, id)
+    if err != nil {
+        return -1, lrc.Lyrics{}, err
+    }
+
+    i, ok := lyrics.LineAt(t)
+    if !ok {
+        entry.DebugContext(ctx, "No active line", slog.Duration("t", t))
+        return -1, lyrics, nil
+    }
+
+    entry.DebugContext(ctx, "Active line found", slog.Duration("t", t), slog.Int("line", i))
+
+    return i, lyrics, nil
+}
+
+// SetSyncedLyrics validates and stores LRC document of the song.
+// Optionally replaces plain text of the song with text of timed lines.
+// Empty document removes synced lyrics of the song.
+func (s SongService) SetSyncedLyrics(ctx context.Context, id int, req types.SetSyncedLyrics) error {
+    ctx, span := tracing.Start(ctx, "SongService.SetSyncedLyrics", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "set synced lyrics"))
+
+    // Empty document removes synced lyrics, the text is kept
+    if req.LRC == "" {
+        if err := s.UpdateSong(ctx, id, types.UpdateSong{SyncedLyrics: &req.LRC}); err != nil {
+            return err
+        }
+
+        entry.InfoContext(ctx, "Synced lyrics removed successfully", slog.Int("id", id))
+
+        return nil
+    }
+
+    lyrics, err := lrc.Parse(req.LRC)
+    if err != nil {
+        entry.ErrorContext(ctx, "Invalid synced lyrics", slog.Any("error", err))
+        return err
+    }
+
+    entry.DebugContext(ctx, "Synced lyrics validated successfully", slog.Int("lines", len(lyrics.Lines)))
+
+    update := types.UpdateSong{SyncedLyrics: &req.LRC}
+    if req.SyncText {
+        text := lyrics.PlainText()
+        update.Text = &text
+    }
+
+    if err := s.UpdateSong(ctx, id, update); err != nil {
+        return err
+    }
+
+    entry.InfoContext(ctx, "Synced lyrics updated successfully", slog.Int("id", id))
+
+    return nil
+}
+
+// GetLyricsSkeleton exports plain text of the song
+// as LRC document with zero timestamps
+func (s SongService) GetLyricsSkeleton(ctx context.Context, id int) (string, error) {
+    ctx, span := tracing.Start(ctx, "SongService.GetLyricsSkeleton", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "get lyrics skeleton"))
+
+    text, err := s.store.GetSongText(ctx, id)
+    if err != nil {
+        return "", err
+    }
+
+    entry.InfoContext(ctx, "Lyrics skeleton created successfully")
+
+    return lrc.Skeleton(text, nil).String(), nil
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/storage"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+    "strings"
+    "unicode/utf8"
+)
+
+// What happens to playlist entries of the deleted song
+const (
+    DeletedSongRemove = "remove"
+    DeletedSongFlag   = "flag"
+)
+
+// PlaylistPolicy describes how playlists
+// follow deletion of their songs
+type PlaylistPolicy struct {
+    DeletedSongs string
+}
+
+func (p PlaylistPolicy) Validate() error {
+    switch p.DeletedSongs {
+    case DeletedSongRemove, DeletedSongFlag:
+        return nil
+    }
+    return fmt.Errorf("invalid deleted songs policy %q", p.DeletedSongs)
+}
+
+// Max length of the playlist name column
+const maxPlaylistName = 255
+
+// PlaylistService manages playlists and order of their songs
+type PlaylistService struct {
+    store storage.PlaylistStorage
+    log   *slog.Logger
+}
+
+func NewPlaylistService(store storage.PlaylistStorage, logger *slog.Logger) PlaylistService {
+    log := logger.With("component", "services/playlist")
+
+    return PlaylistService{store: store, log: log}
+}
+
+func (s PlaylistService) GetPlaylists(ctx context.Context, page int, limit int) ([]types.Playlist, error) {
+    entry := s.log.With(slog.String("method", "get playlists"))
+
+    playlists, err := s.store.GetPlaylists(ctx, (page-1)*limit, limit)
+    if err != nil {
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Playlists received successfully")
+
+    return playlists, nil
+}
+
+func (s PlaylistService) GetPlaylist(ctx context.Context, id int) (types.Playlist, error) {
+    entry := s.log.With(slog.String("method", "get playlist"))
+
+    playlist, err := s.store.GetPlaylist(ctx, id)
+    if err != nil {
+        return types.Playlist{}, err
+    }
+
+    entry.InfoContext(ctx, "Playlist received successfully", slog.Int("id", id))
+
+    return playlist, nil
+}
+
+func (s PlaylistService) CreatePlaylist(ctx context.Context, req types.CreatePlaylist) (int, error) {
+    entry := s.log.With(slog.String("method", "create playlist"))
+
+    req.Name = strings.TrimSpace(req.Name)
+    if err := validatePlaylistName(req.Name); err != nil {
+        entry.ErrorContext(ctx, "Invalid playlist", slog.Any("error", err))
+        return -1, err
+    }
+
+    id, err := s.store.CreatePlaylist(ctx, req)
+    if err != nil {
+        return -1, err
+    }
+
+    entry.InfoContext(ctx, "Playlist created successfully", slog.Int("id", id))
+
+    return id, nil
+}
+
+func (s PlaylistService) UpdatePlaylist(ctx context.Context, id int, req types.UpdatePlaylist) error {
+    entry := s.log.With(slog.String("method", "update playlist"))
+
+    if req.Name != nil {
+        name := strings.TrimSpace(*req.Name)
+        if err := validatePlaylistName(name); err != nil {
+            entry.ErrorContext(ctx, "Invalid playlist", slog.Any("error", err))
+            return err
+        }
+        req.Name = &name
+    }
+
+    if err := s.store.UpdatePlaylist(ctx, id, req); err != nil {
+        return err
+    }
+
+    entry.InfoContext(ctx, "Playlist updated successfully", slog.Int("id", id))
+
+    return nil
+}
+
+func (s PlaylistService) DeletePlaylist(ctx context.Context, id int) error {
+    entry := s.log.With(slog.String("method", "delete playlist"))
+
+    if err := s.store.DeletePlaylist(ctx, id); err != nil {
+        return err
+    }
+
+    entry.InfoContext(ctx, "Playlist deleted successfully", slog.Int("id", id))
+
+    return nil
+}
+
+// AddEntries inserts songs at the position of the playlist,
+// or appends them if the position is not set
+func (s PlaylistService) AddEntries(ctx context.Context, id int, req types.AddPlaylistEntries) error {
+    entry := s.log.With(slog.String("method", "add playlist entries"))
+
+    if len(req.SongIds) == 0 {
+        err := errors.New("no songs to add")
+        entry.ErrorContext(ctx, "Invalid playlist entries", slog.Any("error", err))
+        return err
+    }
+
+    position := 0
+    if req.Position != nil {
+        if *req.Position < 1 {
+            entry.ErrorContext(ctx, "Invalid playlist entries", slog.Any("error", storage.ErrInvalidPosition))
+            return storage.ErrInvalidPosition
+        }
+        position = *req.Position
+    }
+
+    if err := s.store.AddPlaylistEntries(ctx, id, req.SongIds, position); err != nil {
+        return err
+    }
+
+    entry.InfoContext(ctx, "Playlist entries added successfully", slog.Int("id", id))
+
+    return nil
+}
+
+func (s PlaylistService) RemoveEntry(ctx context.Context, id, entryId int) error {
+    entry := s.log.With(slog.String("method", "remove playlist entry"))
+
+    if err := s.store.RemovePlaylistEntry(ctx, id, entryId); err != nil {
+        return err
+    }
+
+    entry.InfoContext(ctx, "Playlist entry removed successfully", slog.Int("id", id), slog.Int("entry_id", entryId))
+
+    return nil
+}
+
+func (s PlaylistService) MoveEntry(ctx context.Context, id, entryId int, req types.MovePlaylistEntry) error {
+    entry := s.log.With(slog.String("method", "move playlist entry"))
+
+    if err := s.store.MovePlaylistEntry(ctx, id, entryId, req.Position); err != nil {
+        return err
+    }
+
+    entry.InfoContext(ctx, "Playlist entry moved successfully", slog.Int("id", id), slog.Int("entry_id", entryId))
+
+    return nil
+}
+
+// Reorder sets order of all entries of the playlist,
+// every entry must be listed exactly once
+func (s PlaylistService) Reorder(ctx context.Context, id int, req types.ReorderPlaylist) error {
+    entry := s.log.With(slog.String("method", "reorder playlist"))
+
+    seen := map[int]bool{}
+    for _, entryId := range req.EntryIds {
+        if seen[entryId] {
+            err := fmt.Errorf("entry %d is listed twice", entryId)
+            entry.ErrorContext(ctx, "Invalid playlist order", slog.Any("error", err))
+            return err
+        }
+        seen[entryId] = true
+    }
+
+    if err := s.store.ReorderPlaylist(ctx, id, req.EntryIds); err != nil {
+        return err
+    }
+
+    entry.InfoContext(ctx, "Playlist reordered successfully", slog.Int("id", id))
+
+    return nil
+}
+
+func validatePlaylistName(name string) error {
+    if name == "" {
+        return errors.New("playlist name is empty")
+    }
+    if utf8.RuneCountInString(name) > maxPlaylistName {
+        return fmt.Errorf("playlist name is longer than %d characters", maxPlaylistName)
+    }
+    return nil
+}
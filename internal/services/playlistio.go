@@ -0,0 +1,129 @@
+package services
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "github.com/vasch3nko/songlibrary/internal/playlistio"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "io"
+    "log/slog"
+    "strings"
+)
+
+// Name of the imported playlist if neither
+// the request nor the file has it
+const defaultPlaylistName = "Imported playlist"
+
+// Export writes the playlist in the format, songs are
+// located by their links. Entries of deleted songs
+// keep their name and group but have no location.
+func (s PlaylistService) Export(ctx context.Context, w io.Writer, id int, format string) error {
+    entry := s.log.With(slog.String("method", "export playlist"))
+
+    playlist, err := s.store.GetPlaylist(ctx, id)
+    if err != nil {
+        return err
+    }
+
+    p := playlistio.Playlist{
+        Name:        playlist.Name,
+        Description: playlist.Description,
+        Tracks:      make([]playlistio.Track, 0, len(playlist.Entries)),
+    }
+    for _, e := range playlist.Entries {
+        p.Tracks = append(p.Tracks, playlistio.Track{
+            Title:    e.Song,
+            Artist:   e.Group,
+            Location: e.Link,
+        })
+    }
+
+    if err := playlistio.Write(w, format, p); err != nil {
+        entry.ErrorContext(ctx, "Failed to write playlist", slog.Int("id", id), slog.Any("error", err))
+        return err
+    }
+
+    entry.InfoContext(ctx, "Playlist exported successfully",
+        slog.Int("id", id),
+        slog.String("format", format),
+    )
+
+    return nil
+}
+
+// Import creates the playlist of the file. Entries are matched
+// to the songs by title and artist, the rest are reported as
+// unmatched. Name overrides the name of the file playlist.
+func (s PlaylistService) Import(ctx context.Context, r io.Reader, format string, name string) (types.PlaylistImportResult, error) {
+    entry := s.log.With(slog.String("method", "import playlist"))
+
+    p, err := playlistio.Read(r, format)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to read playlist", slog.Any("error", err))
+        return types.PlaylistImportResult{}, err
+    }
+
+    req := types.CreatePlaylist{
+        Name:        strings.TrimSpace(name),
+        Description: p.Description,
+    }
+    if req.Name == "" {
+        req.Name = p.Name
+    }
+    if req.Name == "" {
+        req.Name = defaultPlaylistName
+    }
+    if err := validatePlaylistName(req.Name); err != nil {
+        entry.ErrorContext(ctx, "Invalid playlist", slog.Any("error", err))
+        return types.PlaylistImportResult{}, err
+    }
+
+    result := types.PlaylistImportResult{
+        Name:      req.Name,
+        Entries:   len(p.Tracks),
+        Unmatched: []types.UnmatchedPlaylistEntry{},
+    }
+
+    songIds := make([]int, 0, len(p.Tracks))
+    for i, t := range p.Tracks {
+        unmatched := func(reason string) {
+            result.Unmatched = append(result.Unmatched, types.UnmatchedPlaylistEntry{
+                Position: i + 1,
+                Title:    t.Title,
+                Artist:   t.Artist,
+                Location: t.Location,
+                Reason:   reason,
+            })
+        }
+
+        if t.Title == "" || t.Artist == "" {
+            unmatched("title or artist is missing")
+            continue
+        }
+
+        song, err := s.store.FindSong(ctx, t.Artist, t.Title)
+        if errors.Is(err, sql.ErrNoRows) {
+            unmatched("song not found")
+            continue
+        }
+        if err != nil {
+            return types.PlaylistImportResult{}, err
+        }
+        songIds = append(songIds, song.Id)
+    }
+    result.Matched = len(songIds)
+
+    if result.Id, err = s.store.ImportPlaylist(ctx, req, songIds); err != nil {
+        return types.PlaylistImportResult{}, err
+    }
+
+    entry.InfoContext(ctx, "Playlist imported successfully",
+        slog.Int("id", result.Id),
+        slog.Int("entries", result.Entries),
+        slog.Int("matched", result.Matched),
+        slog.Int("unmatched", len(result.Unmatched)),
+    )
+
+    return result, nil
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "github.com/vasch3nko/songlibrary/internal/audiotag"
+    "github.com/vasch3nko/songlibrary/internal/tracing"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "io/fs"
+    "log/slog"
+    "net/url"
+    "path/filepath"
+    "strings"
+    "time"
+)
+
+// Max length of the song link column
+const maxLinkLength = 255
+
+// Scan walks the directory and creates songs from tags of the
+// audio files or updates lyrics, release date and empty link of
+// existing songs. Dry run reports the same without writing.
+func (s SongService) Scan(ctx context.Context, dir string, dryRun bool) (types.ScanResult, error) {
+    ctx, span := tracing.Start(ctx, "SongService.Scan", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "scan"))
+
+    result := types.ScanResult{DryRun: dryRun, Items: []types.ScanItem{}}
+
+    // First file of every group and name
+    seen := map[string]string{}
+    err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        if d.IsDir() || !audiotag.Extensions[strings.ToLower(filepath.Ext(path))] {
+            return nil
+        }
+        result.Files++
+
+        item := s.scanFile(ctx, path, dryRun, seen)
+        switch item.Status {
+        case types.SongCreated:
+            result.Created++
+        case types.SongUpdated:
+            result.Updated++
+        case types.SongUnchanged:
+            result.Unchanged++
+        case types.SongSkipped:
+            result.Skipped++
+        case types.SongFailed:
+            result.Failed++
+        }
+        result.Items = append(result.Items, item)
+
+        return nil
+    })
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to walk directory", slog.String("dir", dir), slog.Any("error", err))
+        return result, err
+    }
+
+    entry.InfoContext(ctx, "Directory scanned",
+        slog.String("dir", dir),
+        slog.Bool("dry_run", dryRun),
+        slog.Int("files", result.Files),
+        slog.Int("created", result.Created),
+        slog.Int("updated", result.Updated),
+        slog.Int("unchanged", result.Unchanged),
+        slog.Int("skipped", result.Skipped),
+        slog.Int("failed", result.Failed),
+    )
+
+    return result, nil
+}
+
+// scanFile reads tags of the file and creates or updates its song
+func (s SongService) scanFile(ctx context.Context, path string, dryRun bool, seen map[string]string) types.ScanItem {
+    entry := s.log.With(slog.String("method", "scan file"), slog.String("path", path))
+
+    item := types.ScanItem{Path: path}
+    skip := func(reason string) types.ScanItem {
+        item.Status, item.Reason = types.SongSkipped, reason
+        entry.DebugContext(ctx, "File skipped", slog.String("reason", reason))
+        return item
+    }
+    fail := func(err error) types.ScanItem {
+        item.Status, item.Reason = types.SongFailed, err.Error()
+        entry.ErrorContext(ctx, "Failed to scan file", slog.Any("error", err))
+        return item
+    }
+
+    tags, err := audiotag.ReadFile(path)
+    if err != nil {
+        return skip(err.Error())
+    }
+    item.Song = strings.TrimSpace(tags.Title)
+    item.Group = strings.TrimSpace(tags.Artist)
+    item.Album = tags.Album
+    if item.Song == "" || item.Group == "" {
+        return skip("title or artist tag is missing")
+    }
+
+    key := strings.ToLower(item.Group) + "\x00" + strings.ToLower(item.Song)
+    if first, ok := seen[key]; ok {
+        return skip("same song as " + first)
+    }
+    seen[key] = path
+
+    releaseDate, hasDate := audiotag.ParseDate(tags.Date)
+
+    song, err := s.store.FindSong(ctx, item.Group, item.Song)
+    if errors.Is(err, sql.ErrNoRows) {
+        if !hasDate {
+            return skip("date tag is missing")
+        }
+
+        req := types.CreateSong{
+            Song:  item.Song,
+            Group: item.Group,
+            SongDetail: types.SongDetail{
+                Text:        tags.Lyrics,
+                Link:        fileLink(path),
+                ReleaseDate: types.Date(releaseDate),
+            },
+            Detailed: true,
+        }
+
+        item.Status = types.SongCreated
+        if dryRun {
+            // Same checks as the creation, without it
+            if _, _, err := s.analyzeSong(ctx, req, types.OnConflictError); err != nil {
+                return fail(err)
+            }
+            return item
+        }
+
+        created, err := s.CreateSong(ctx, req, types.OnConflictError)
+        if err != nil {
+            return fail(err)
+        }
+        item.Id = created.Id
+
+        entry.InfoContext(ctx, "Song created from file", slog.Int("id", created.Id))
+
+        return item
+    }
+    if err != nil {
+        return fail(err)
+    }
+    item.Id = song.Id
+
+    // Only details that the file knows better are changed
+    var req types.UpdateSong
+    if tags.Lyrics != "" && tags.Lyrics != strings.TrimSpace(song.Text) {
+        req.Text = &tags.Lyrics
+        item.Changes = append(item.Changes, "text")
+    }
+    if hasDate && !sameDate(time.Time(song.ReleaseDate), tags.Date) {
+        date := types.Date(releaseDate)
+        req.ReleaseDate = &date
+        item.Changes = append(item.Changes, "releaseDate")
+    }
+    if link := fileLink(path); song.Link == "" && link != "" {
+        req.Link = &link
+        item.Changes = append(item.Changes, "link")
+    }
+
+    if len(item.Changes) == 0 {
+        item.Status = types.SongUnchanged
+        return item
+    }
+
+    item.Status = types.SongUpdated
+    if dryRun {
+        return item
+    }
+
+    if err := s.UpdateSong(ctx, song.Id, req); err != nil {
+        return fail(err)
+    }
+
+    entry.InfoContext(ctx, "Song updated from file",
+        slog.Int("id", song.Id),
+        slog.Any("changes", item.Changes),
+    )
+
+    return item
+}
+
+// sameDate compares the date with the tag date
+// at the precision of the tag (year, month or day)
+func sameDate(date time.Time, tagDate string) bool {
+    tagDate = strings.TrimSpace(tagDate)
+    formatted := date.Format("2006-01-02")
+    for _, n := range []int{10, 7, 4} {
+        if len(tagDate) >= n {
+            if _, ok := audiotag.ParseDate(tagDate[:n]); ok {
+                return formatted[:n] == tagDate[:n]
+            }
+        }
+    }
+    return false
+}
+
+// fileLink returns file URL of the path, or empty
+// string if it does not fit the link column
+func fileLink(path string) string {
+    abs, err := filepath.Abs(path)
+    if err != nil {
+        return ""
+    }
+    link := (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+    if len(link) > maxLinkLength {
+        return ""
+    }
+    return link
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+    "context"
+    "github.com/vasch3nko/songlibrary/internal/similarity"
+    "github.com/vasch3nko/songlibrary/internal/tracing"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+    "time"
+)
+
+// BuildIndex loads word frequencies of all songs to the similarity index
+func (s SongService) BuildIndex(ctx context.Context) error {
+    ctx, span := tracing.Start(ctx, "SongService.BuildIndex", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "build index"))
+
+    songs, err := s.store.GetSongWords(ctx)
+    if err != nil {
+        return err
+    }
+
+    for _, song := range songs {
+        s.index.Upsert(similarity.Meta{
+            Id:          song.Id,
+            Song:        song.Song,
+            Group:       song.Group,
+            Language:    deref(song.Language),
+            ReleaseDate: time.Time(song.ReleaseDate),
+        }, song.WordFrequency)
+    }
+
+    entry.InfoContext(ctx, "Similarity index built successfully", slog.Int("songs", s.index.Len()))
+
+    return nil
+}
+
+// GetSimilarSongs returns up to n songs with the most similar lyrics
+func (s SongService) GetSimilarSongs(ctx context.Context, id int, n int, filter types.SimilarSongsFilter) ([]types.SimilarSong, error) {
+    ctx, span := tracing.Start(ctx, "SongService.GetSimilarSongs", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "get similar songs"))
+
+    matches, err := s.index.Similar(id, n, func(meta similarity.Meta) bool {
+        if filter.Group != nil && meta.Group != *filter.Group {
+            return false
+        }
+        if filter.Language != nil && meta.Language != *filter.Language {
+            return false
+        }
+        if filter.ReleaseFrom != nil && meta.ReleaseDate.Before(time.Time(*filter.ReleaseFrom)) {
+            return false
+        }
+        if filter.ReleaseTo != nil && meta.ReleaseDate.After(time.Time(*filter.ReleaseTo)) {
+            return false
+        }
+        return true
+    })
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to find similar songs",
+            slog.Int("id", id),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+
+    songs := make([]types.SimilarSong, len(matches))
+    for i, match := range matches {
+        songs[i] = types.SimilarSong{
+            Id:          match.Id,
+            Song:        match.Song,
+            Group:       match.Group,
+            ReleaseDate: types.Date(match.ReleaseDate),
+            Score:       match.Score,
+        }
+        if match.Language != "" {
+            songs[i].Language = &match.Language
+        }
+    }
+
+    entry.InfoContext(ctx, "Similar songs received successfully", slog.Int("count", len(songs)))
+
+    return songs, nil
+}
+
+// reindexSong updates song in the similarity index after write.
+// Terms are replaced only if the text was analyzed again.
+func (s SongService) reindexSong(ctx context.Context, id int, analysis *types.SongAnalysis) {
+    entry := s.log.With(slog.String("method", "reindex song"))
+
+    song, err := s.store.GetSong(ctx, id)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to reindex song", slog.Int("id", id), slog.Any("error", err))
+        return
+    }
+
+    meta := songMeta(song)
+    if analysis != nil {
+        s.index.Upsert(meta, analysis.Stats.WordFrequency)
+    } else {
+        s.index.UpdateMeta(meta)
+    }
+
+    entry.DebugContext(ctx, "Song reindexed successfully", slog.Int("id", id))
+}
+
+func songMeta(song types.Song) similarity.Meta {
+    return similarity.Meta{
+        Id:          song.Id,
+        Song:        song.Song,
+        Group:       song.Group,
+        Language:    deref(song.Language),
+        ReleaseDate: time.Time(song.ReleaseDate),
+    }
+}
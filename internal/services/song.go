@@ -1,156 +1,483 @@
 package services
 
 import (
+    "context"
     "encoding/json"
     "errors"
     "fmt"
+    "github.com/vasch3nko/songlibrary/internal/chordpro"
+    "github.com/vasch3nko/songlibrary/internal/lrc"
+    "github.com/vasch3nko/songlibrary/internal/metrics"
+    "github.com/vasch3nko/songlibrary/internal/reqctx"
+    "github.com/vasch3nko/songlibrary/internal/similarity"
     "github.com/vasch3nko/songlibrary/internal/storage"
+    "github.com/vasch3nko/songlibrary/internal/tracing"
     "github.com/vasch3nko/songlibrary/internal/types"
     "io"
     "log/slog"
     "net/http"
     "net/url"
     "strings"
+    "time"
 )
 
 type SongService struct {
     store            storage.Storage
     songDetailApiUrl string
+    client           *http.Client
+    analyzer         Analyzer
+    index            *similarity.Index
+    duplicates       DuplicatePolicy
+    batch            BatchLimits
+    playlists        PlaylistPolicy
+    enrichment       enrichmentMetrics
     log              *slog.Logger
 }
 
-func NewSongService(store storage.Storage, songDetailApiUrl string, logger *slog.Logger) SongService {
+func NewSongService(
+    store storage.Storage,
+    songDetailApiUrl string,
+    songDetailApiTimeout time.Duration,
+    analyzer Analyzer,
+    index *similarity.Index,
+    duplicates DuplicatePolicy,
+    batch BatchLimits,
+    playlists PlaylistPolicy,
+    registry *metrics.Registry,
+    logger *slog.Logger,
+) SongService {
     log := logger.With("component", "services/song")
 
-    return SongService{store: store, songDetailApiUrl: songDetailApiUrl, log: log}
+    return SongService{
+        store:            store,
+        songDetailApiUrl: songDetailApiUrl,
+        client:           &http.Client{Timeout: songDetailApiTimeout},
+        analyzer:         analyzer,
+        index:            index,
+        duplicates:       duplicates,
+        batch:            batch,
+        playlists:        playlists,
+        enrichment:       newEnrichmentMetrics(registry),
+        log:              log,
+    }
 }
 
-func (s SongService) GetSongs(req types.GetSongs, page int, limit int) ([]types.Song, error) {
+func (s SongService) GetSongs(ctx context.Context, req types.GetSongs, page int, limit int) ([]types.Song, error) {
+    ctx, span := tracing.Start(ctx, "SongService.GetSongs", tracing.KindInternal)
+    defer span.End()
+
     entry := s.log.With(slog.String("method", "get songs"))
 
     // Getting songs from storage
-    songs, err := s.store.GetSongs(req, (page-1)*limit, limit)
+    songs, err := s.store.GetSongs(ctx, req, (page-1)*limit, limit)
     if err != nil {
         return nil, err
     }
 
-    entry.Info("Songs received successfully")
+    entry.InfoContext(ctx, "Songs received successfully")
 
     return songs, nil
 }
 
-func (s SongService) GetSongText(id int, page int) (string, error) {
+// GetSongText returns verse of the song text.
+// Explicit words are masked if mask is set.
+func (s SongService) GetSongText(ctx context.Context, id int, page int, mask bool) (string, error) {
+    ctx, span := tracing.Start(ctx, "SongService.GetSongText", tracing.KindInternal)
+    defer span.End()
+
     entry := s.log.With(slog.String("method", "get song text"))
 
     // Getting song's text by id from storage
-    text, err := s.store.GetSongText(id)
+    text, err := s.store.GetSongText(ctx, id)
     if err != nil {
         return "", err
     }
 
     // Splitting text by verses
-    verses := strings.Split(text, "\n\n")
+    verses := splitVerses(text)
 
     // Validating the page parameter
     if len(verses) < page || page < 1 {
         err := errors.New("page out of range")
-        entry.Error("Invalid parameter page",
+        entry.ErrorContext(ctx, "Invalid parameter page",
             slog.Any("error", err),
         )
         return "", err
     }
 
-    entry.Debug("Page validated successfully", slog.Int("page", page))
-    entry.Info("Song text received successfully")
+    entry.DebugContext(ctx, "Page validated successfully", slog.Int("page", page))
 
-    return verses[page-1], nil
+    verse := verses[page-1]
+    if mask {
+        song, err := s.store.GetSong(ctx, id)
+        if err != nil {
+            return "", err
+        }
+        verse = s.analyzer.Mask(verse, deref(song.Language))
+        entry.DebugContext(ctx, "Explicit words masked")
+    }
+
+    entry.InfoContext(ctx, "Song text received successfully")
+
+    return verse, nil
 }
 
-func (s SongService) CreateSong(req types.CreateSong) (int, error) {
+// CreateSong enriches song with details from external API,
+// unless they are known, and creates it. Duplicates are rejected or reported
+// according to the duplicate policy, the song with the same
+// group and name is resolved according to onConflict.
+func (s SongService) CreateSong(ctx context.Context, req types.CreateSong, onConflict types.OnConflict) (types.CreateSongResult, error) {
+    ctx, span := tracing.Start(ctx, "SongService.CreateSong", tracing.KindInternal)
+    defer span.End()
+
     entry := s.log.With(slog.String("method", "create song"))
 
+    if err := validateOnConflict(onConflict); err != nil {
+        entry.ErrorContext(ctx, "Invalid on conflict mode", slog.Any("error", err))
+        return types.CreateSongResult{}, err
+    }
+
+    req, result, err := s.prepareSong(ctx, req, onConflict)
+    if err != nil {
+        return types.CreateSongResult{}, err
+    }
+
+    // Creating song in the storage
+    created, err := s.store.CreateSong(ctx, req, onConflict)
+    if err != nil {
+        return types.CreateSongResult{}, err
+    }
+    result.Id = created.Id
+    result.Status = created.Status
+
+    s.indexCreatedSong(ctx, req, created)
+
+    entry.DebugContext(ctx, "Song created successfully",
+        slog.Int("id", created.Id),
+        slog.String("status", created.Status),
+    )
+
+    return result, nil
+}
+
+func validateOnConflict(onConflict types.OnConflict) error {
+    switch onConflict {
+    case types.OnConflictError, types.OnConflictIgnore, types.OnConflictUpdate:
+        return nil
+    }
+    return fmt.Errorf("invalid on conflict mode %q", onConflict)
+}
+
+// prepareSong adds song details from external API
+// to the song, analyzes and checks it for duplicates
+func (s SongService) prepareSong(ctx context.Context, req types.CreateSong, onConflict types.OnConflict) (types.CreateSong, types.CreateSongResult, error) {
+    // Adding song details (text, link, release date)
+    // from external API response
+    if !req.Detailed {
+        songDetail, err := s.fetchSongDetail(ctx, req.Song, req.Group)
+        if err != nil {
+            return req, types.CreateSongResult{}, err
+        }
+        req.SongDetail = songDetail
+    }
+
+    return s.analyzeSong(ctx, req, onConflict)
+}
+
+// analyzeSong adds analysis of the text to the song with details
+// and checks it for duplicates. Result has duplicates and
+// warning set if the policy only warns.
+func (s SongService) analyzeSong(ctx context.Context, req types.CreateSong, onConflict types.OnConflict) (types.CreateSong, types.CreateSongResult, error) {
+    entry := s.log.With(slog.String("method", "analyze song"))
+
+    req.Analysis = s.analyzer.Analyze(ctx, req.Text)
+
+    // Checking for the same track entered before
+    var result types.CreateSongResult
+    if s.duplicates.Mode != DuplicateAllow {
+        result.Duplicates = s.findDuplicates(req, onConflict)
+    }
+    if len(result.Duplicates) > 0 {
+        if s.duplicates.Mode == DuplicateReject {
+            err := &DuplicateError{Ids: result.Duplicates}
+            entry.ErrorContext(ctx, "Song rejected as a duplicate",
+                slog.Any("duplicates", result.Duplicates),
+                slog.Any("error", err),
+            )
+            return req, types.CreateSongResult{}, err
+        }
+        result.Warning = "song looks like a duplicate of existing songs"
+        entry.WarnContext(ctx, "Song looks like a duplicate", slog.Any("duplicates", result.Duplicates))
+    }
+
+    return req, result, nil
+}
+
+// indexCreatedSong adds created song to the similarity index,
+// or reindexes the existing song if it was updated
+func (s SongService) indexCreatedSong(ctx context.Context, req types.CreateSong, created types.CreateSongResult) {
+    switch created.Status {
+    case types.SongCreated:
+        s.index.Upsert(similarity.Meta{
+            Id:          created.Id,
+            Song:        req.Song,
+            Group:       req.Group,
+            Language:    deref(req.Analysis.Language),
+            ReleaseDate: time.Time(req.ReleaseDate),
+        }, req.Analysis.Stats.WordFrequency)
+    case types.SongUpdated:
+        s.reindexSong(ctx, created.Id, &req.Analysis)
+    }
+}
+
+// EnrichSong requests song details from external API again
+// and replaces text, link and release date of the song
+func (s SongService) EnrichSong(ctx context.Context, id int) error {
+    ctx, span := tracing.Start(ctx, "SongService.EnrichSong", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "enrich song"))
+
+    song, err := s.store.GetSong(ctx, id)
+    if err != nil {
+        return err
+    }
+
+    songDetail, err := s.fetchSongDetail(ctx, song.Song, song.Group)
+    if err != nil {
+        return err
+    }
+
+    if err := s.updateSong(ctx, id, types.UpdateSong{
+        Text:        &songDetail.Text,
+        Link:        &songDetail.Link,
+        ReleaseDate: &songDetail.ReleaseDate,
+    }, types.AuditEnrich); err != nil {
+        return err
+    }
+
+    entry.InfoContext(ctx, "Song enriched successfully", slog.Int("id", id))
+
+    return nil
+}
+
+// fetchSongDetail requests song details from external API
+func (s SongService) fetchSongDetail(ctx context.Context, song, group string) (types.SongDetail, error) {
+    entry := s.log.With(slog.String("method", "fetch song detail"))
+
+    // Observing and tracing the request with the outcome of the return
+    infoURL := s.songDetailApiUrl + "/info"
+    ctx, span := tracing.Start(ctx, "GET /info", tracing.KindClient,
+        tracing.String("http.request.method", http.MethodGet),
+        tracing.String("url.full", infoURL),
+    )
+    start := time.Now()
+    outcome := enrichmentError
+    defer func() {
+        s.enrichment.observe(outcome, start)
+        if outcome != enrichmentSuccess {
+            span.SetError(outcome)
+        }
+        span.End()
+    }()
+
     // Adding request params
     params := url.Values{}
-    params.Add("song", req.Song)
-    params.Add("group", req.Group)
+    params.Add("song", song)
+    params.Add("group", group)
 
     // Requesting external API
-    fullURL := fmt.Sprintf("%s/info?%s", s.songDetailApiUrl, params.Encode())
-    resp, err := http.Get(fullURL)
+    fullURL := fmt.Sprintf("%s?%s", infoURL, params.Encode())
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+    if err != nil {
+        return types.SongDetail{}, err
+    }
+
+    // External API logs can be correlated with the request
+    // and its spans are the children of the request span
+    if id := reqctx.RequestId(ctx); id != "" {
+        req.Header.Set("X-Request-ID", id)
+    }
+    tracing.Inject(ctx, req.Header)
+
+    resp, err := s.client.Do(req)
     if err != nil {
-        entry.Error("Failed to get response from external API",
+        entry.ErrorContext(ctx, "Failed to get response from external API",
             slog.String("url", fullURL),
             slog.String("error", err.Error()),
         )
-        return -1, err
+        return types.SongDetail{}, err
     }
     defer resp.Body.Close()
 
-    entry.Debug("Got response from external API successfully")
+    entry.DebugContext(ctx, "Got response from external API successfully")
+    span.SetAttributes(tracing.Int("http.response.status_code", resp.StatusCode))
 
     if resp.StatusCode != http.StatusOK {
-        entry.Error("Response status from external API is not OK",
+        err := fmt.Errorf("external API responded with status %d", resp.StatusCode)
+        entry.ErrorContext(ctx, "Response status from external API is not OK",
             slog.Int("status_code", resp.StatusCode),
         )
-        return -1, err
+        outcome = enrichmentBadStatus
+        return types.SongDetail{}, err
     }
 
-    entry.Debug("Response status from external API is OK")
+    entry.DebugContext(ctx, "Response status from external API is OK")
 
     body, err := io.ReadAll(resp.Body)
     if err != nil {
-        entry.Error("Failed to read response from external API",
+        entry.ErrorContext(ctx, "Failed to read response from external API",
             slog.String("error", err.Error()),
         )
-        return -1, err
+        return types.SongDetail{}, err
     }
 
-    entry.Debug("Response body read from external API successfully")
+    entry.DebugContext(ctx, "Response body read from external API successfully")
 
-    // Adding song details (text, link, release date)
-    // from external API response
     songDetail := types.SongDetail{}
     if err = json.Unmarshal(body, &songDetail); err != nil {
-        entry.Error("Failed to unmarshal response from external API",
+        entry.ErrorContext(ctx, "Failed to unmarshal response from external API",
             slog.String("error", err.Error()),
         )
-        return -1, err
+        outcome = enrichmentInvalidResponse
+        return types.SongDetail{}, err
     }
-    req.SongDetail = songDetail
 
-    entry.Debug("Response body unmarshalled successfully")
+    entry.DebugContext(ctx, "Response body unmarshalled successfully")
+    outcome = enrichmentSuccess
 
-    // Creating song in the storage
-    id, err := s.store.CreateSong(req)
-    if err != nil {
-        return -1, err
-    }
+    return songDetail, nil
+}
 
-    entry.Debug("Song created successfully", slog.Int("id", id))
+func (s SongService) UpdateSong(ctx context.Context, id int, req types.UpdateSong) error {
+    ctx, span := tracing.Start(ctx, "SongService.UpdateSong", tracing.KindInternal)
+    defer span.End()
 
-    return id, nil
+    return s.updateSong(ctx, id, req, types.AuditUpdate)
 }
 
-func (s SongService) UpdateSong(id int, req types.UpdateSong) error {
+// updateSong updates the song and records the change
+// as the audit event of the action
+func (s SongService) updateSong(ctx context.Context, id int, req types.UpdateSong, action string) error {
     entry := s.log.With(slog.String("method", "update song"))
 
-    if err := s.store.UpdateSong(id, req); err != nil {
+    // Validating synced lyrics before saving, empty ones are removed
+    if req.SyncedLyrics != nil && *req.SyncedLyrics != "" {
+        if _, err := lrc.Parse(*req.SyncedLyrics); err != nil {
+            entry.ErrorContext(ctx, "Invalid synced lyrics", slog.Any("error", err))
+            return err
+        }
+    }
+
+    // Validating chord sheet before saving, empty one is removed
+    if req.ChordSheet != nil && *req.ChordSheet != "" {
+        if _, err := chordpro.Parse(*req.ChordSheet); err != nil {
+            entry.ErrorContext(ctx, "Invalid chord sheet", slog.Any("error", err))
+            return err
+        }
+    }
+
+    // Recomputing analysis of the changed text
+    if req.Text != nil {
+        analysis := s.analyzer.Analyze(ctx, *req.Text)
+        req.Analysis = &analysis
+    }
+
+    if err := s.store.UpdateSong(ctx, id, req, action); err != nil {
+        return err
+    }
+
+    s.reindexSong(ctx, id, req.Analysis)
+
+    entry.InfoContext(ctx, "Song updated successfully", slog.Int("id", id))
+
+    return nil
+}
+
+// SetExplicitOverride sets manual explicit flag of the song
+// that takes precedence over the scanned one
+func (s SongService) SetExplicitOverride(ctx context.Context, id int, req types.SetExplicitOverride) error {
+    ctx, span := tracing.Start(ctx, "SongService.SetExplicitOverride", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "set explicit override"))
+
+    if err := s.store.SetSongExplicitOverride(ctx, id, req.Explicit); err != nil {
         return err
     }
 
-    entry.Info("Song updated successfully", slog.Int("id", id))
+    entry.InfoContext(ctx, "Explicit override set successfully", slog.Int("id", id))
+
+    return nil
+}
+
+// BackfillStats computes analysis of the songs
+// that were created before word statistics existed
+func (s SongService) BackfillStats(ctx context.Context) error {
+    ctx, span := tracing.Start(ctx, "SongService.BackfillStats", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "backfill stats"))
+
+    const batchSize = 100
+    total := 0
+    for {
+        songs, err := s.store.GetSongsWithoutStats(ctx, batchSize)
+        if err != nil {
+            return err
+        }
+        if len(songs) == 0 {
+            break
+        }
+
+        for _, song := range songs {
+            analysis := s.analyzer.Analyze(ctx, song.Text)
+            if err := s.store.UpdateSong(ctx, song.Id, types.UpdateSong{Analysis: &analysis}, types.AuditUpdate); err != nil {
+                return err
+            }
+            // Language of the song is detected by the analysis
+            meta := songMeta(song)
+            meta.Language = deref(analysis.Language)
+            s.index.Upsert(meta, analysis.Stats.WordFrequency)
+        }
+        total += len(songs)
+        entry.DebugContext(ctx, "Batch of songs analyzed", slog.Int("count", len(songs)))
+    }
+
+    entry.InfoContext(ctx, "Stats backfilled successfully", slog.Int("songs", total))
 
     return nil
 }
 
-func (s SongService) DeleteSong(id int) error {
+// DeleteSong deletes the song, its playlist entries are
+// removed or flagged according to the playlist policy
+func (s SongService) DeleteSong(ctx context.Context, id int) error {
+    ctx, span := tracing.Start(ctx, "SongService.DeleteSong", tracing.KindInternal)
+    defer span.End()
+
     entry := s.log.With(slog.String("method", "delete song"))
 
-    if err := s.store.DeleteSong(id); err != nil {
+    remove := s.playlists.DeletedSongs == DeletedSongRemove
+    if err := s.store.DeleteSong(ctx, id, remove); err != nil {
         return err
     }
 
-    entry.Info("Song deleted successfully", slog.Int("id", id))
+    s.index.Remove(id)
+
+    entry.InfoContext(ctx, "Song deleted successfully", slog.Int("id", id))
 
     return nil
 }
+
+// splitVerses splits song text by verses
+func splitVerses(text string) []string {
+    return strings.Split(text, "\n\n")
+}
+
+// deref returns value of the string pointer or empty string for nil
+func deref(s *string) string {
+    if s == nil {
+        return ""
+    }
+    return *s
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+    "context"
+    "github.com/vasch3nko/songlibrary/internal/storage"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+)
+
+// StatsService serves lyrics statistics
+// that are precomputed on every song write
+type StatsService struct {
+    store storage.StatsStorage
+    log   *slog.Logger
+}
+
+func NewStatsService(store storage.StatsStorage, logger *slog.Logger) StatsService {
+    log := logger.With("component", "services/stats")
+
+    return StatsService{store: store, log: log}
+}
+
+func (s StatsService) GetLibraryStats(ctx context.Context, filter types.GetSongs) (types.LibraryStats, error) {
+    entry := s.log.With(slog.String("method", "get library stats"))
+
+    stats, err := s.store.GetLibraryStats(ctx, filter)
+    if err != nil {
+        return types.LibraryStats{}, err
+    }
+
+    entry.InfoContext(ctx, "Library stats received successfully")
+
+    return stats, nil
+}
+
+func (s StatsService) GetSongStats(ctx context.Context, filter types.GetSongs, page int, limit int) ([]types.SongStatsEntry, error) {
+    entry := s.log.With(slog.String("method", "get song stats"))
+
+    stats, err := s.store.GetSongStats(ctx, filter, (page-1)*limit, limit)
+    if err != nil {
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Song stats received successfully")
+
+    return stats, nil
+}
+
+// GetTopWords returns n most used words of every artist
+func (s StatsService) GetTopWords(ctx context.Context, filter types.GetSongs, n int) ([]types.ArtistTopWords, error) {
+    entry := s.log.With(slog.String("method", "get top words"))
+
+    artists, err := s.store.GetTopWords(ctx, filter, n)
+    if err != nil {
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Top words received successfully")
+
+    return artists, nil
+}
+
+// GetVocabularyGrowth returns number of new words by release year
+func (s StatsService) GetVocabularyGrowth(ctx context.Context, filter types.GetSongs) ([]types.VocabularyYear, error) {
+    entry := s.log.With(slog.String("method", "get vocabulary growth"))
+
+    years, err := s.store.GetVocabularyGrowth(ctx, filter)
+    if err != nil {
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Vocabulary growth received successfully")
+
+    return years, nil
+}
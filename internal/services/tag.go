@@ -0,0 +1,102 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/storage"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+)
+
+// TagService manages genres, moods and custom tags of songs
+type TagService struct {
+    store storage.TagStorage
+    log   *slog.Logger
+}
+
+func NewTagService(store storage.TagStorage, logger *slog.Logger) TagService {
+    log := logger.With("component", "services/tag")
+
+    return TagService{store: store, log: log}
+}
+
+// GetTags returns tags of the kind with numbers of
+// their songs, empty kind returns tags of all kinds
+func (s TagService) GetTags(ctx context.Context, kind string) ([]types.TagCount, error) {
+    entry := s.log.With(slog.String("method", "get tags"))
+
+    switch kind {
+    case "", types.TagKindGenre, types.TagKindMood, types.TagKindTag:
+    default:
+        err := fmt.Errorf("unknown tag kind %q", kind)
+        entry.ErrorContext(ctx, "Invalid tag kind", slog.Any("error", err))
+        return nil, err
+    }
+
+    tags, err := s.store.GetTags(ctx, kind)
+    if err != nil {
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Tags received successfully")
+
+    return tags, nil
+}
+
+// GetTagFacets returns tag counts of the songs that match the filter
+func (s TagService) GetTagFacets(ctx context.Context, filter types.GetSongs) (types.TagFacets, error) {
+    entry := s.log.With(slog.String("method", "get tag facets"))
+
+    facets, err := s.store.GetTagFacets(ctx, filter)
+    if err != nil {
+        return types.TagFacets{}, err
+    }
+
+    entry.InfoContext(ctx, "Tag facets received successfully")
+
+    return facets, nil
+}
+
+func (s TagService) GetSongTags(ctx context.Context, songId int) ([]types.Tag, error) {
+    entry := s.log.With(slog.String("method", "get song tags"))
+
+    tags, err := s.store.GetSongTags(ctx, songId)
+    if err != nil {
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Song tags received successfully", slog.Int("song_id", songId))
+
+    return tags, nil
+}
+
+func (s TagService) TagSong(ctx context.Context, songId int, req types.SetSongTags) error {
+    entry := s.log.With(slog.String("method", "tag song"))
+
+    if len(req.Tags) == 0 {
+        err := errors.New("no tags to add")
+        entry.ErrorContext(ctx, "Invalid song tags", slog.Any("error", err))
+        return err
+    }
+
+    if err := s.store.TagSong(ctx, songId, req.Tags); err != nil {
+        return err
+    }
+
+    entry.InfoContext(ctx, "Song tagged successfully", slog.Int("song_id", songId))
+
+    return nil
+}
+
+func (s TagService) UntagSong(ctx context.Context, songId int, tag types.Tag) error {
+    entry := s.log.With(slog.String("method", "untag song"))
+
+    if err := s.store.UntagSong(ctx, songId, tag); err != nil {
+        return err
+    }
+
+    entry.InfoContext(ctx, "Song untagged successfully", slog.Int("song_id", songId), slog.String("tag", tag.String()))
+
+    return nil
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/langtag"
+    "github.com/vasch3nko/songlibrary/internal/tracing"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+)
+
+func (s SongService) GetSongTranslations(ctx context.Context, songId int) ([]types.SongTranslation, error) {
+    ctx, span := tracing.Start(ctx, "SongService.GetSongTranslations", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "get song translations"))
+
+    translations, err := s.store.GetSongTranslations(ctx, songId)
+    if err != nil {
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Song translations received successfully")
+
+    return translations, nil
+}
+
+func (s SongService) GetSongTranslation(ctx context.Context, songId int, lang string) (types.SongTranslation, error) {
+    ctx, span := tracing.Start(ctx, "SongService.GetSongTranslation", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "get song translation"))
+
+    tag, err := langtag.Parse(lang)
+    if err != nil {
+        entry.ErrorContext(ctx, "Invalid language tag", slog.String("lang", lang), slog.Any("error", err))
+        return types.SongTranslation{}, err
+    }
+
+    translation, err := s.store.GetSongTranslation(ctx, songId, tag)
+    if err != nil {
+        return types.SongTranslation{}, err
+    }
+
+    entry.InfoContext(ctx, "Song translation received successfully")
+
+    return translation, nil
+}
+
+// SetSongTranslation creates or replaces translation of the song
+// into the language identified by BCP 47 tag
+func (s SongService) SetSongTranslation(ctx context.Context, songId int, lang string, req types.SetSongTranslation) error {
+    ctx, span := tracing.Start(ctx, "SongService.SetSongTranslation", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "set song translation"))
+
+    tag, err := langtag.Parse(lang)
+    if err != nil {
+        entry.ErrorContext(ctx, "Invalid language tag", slog.String("lang", lang), slog.Any("error", err))
+        return err
+    }
+
+    entry.DebugContext(ctx, "Language tag validated successfully", slog.String("lang", tag))
+
+    if err := s.store.SetSongTranslation(ctx, types.SongTranslation{
+        SongId: songId,
+        Lang:   tag,
+        Text:   req.Text,
+    }); err != nil {
+        return err
+    }
+
+    entry.InfoContext(ctx, "Song translation set successfully", slog.Int("song_id", songId), slog.String("lang", tag))
+
+    return nil
+}
+
+func (s SongService) DeleteSongTranslation(ctx context.Context, songId int, lang string) error {
+    ctx, span := tracing.Start(ctx, "SongService.DeleteSongTranslation", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "delete song translation"))
+
+    tag, err := langtag.Parse(lang)
+    if err != nil {
+        entry.ErrorContext(ctx, "Invalid language tag", slog.String("lang", lang), slog.Any("error", err))
+        return err
+    }
+
+    if err := s.store.DeleteSongTranslation(ctx, songId, tag); err != nil {
+        return err
+    }
+
+    entry.InfoContext(ctx, "Song translation deleted successfully", slog.Int("song_id", songId), slog.String("lang", tag))
+
+    return nil
+}
+
+// GetTranslatedVerse returns verse of the song text aligned
+// with the verse of the same index of the translation.
+// Warns when the translation has different verses count.
+// Explicit words of both verses are masked if mask is set.
+func (s SongService) GetTranslatedVerse(ctx context.Context, id int, page int, lang string, mask bool) (types.SongVerse, error) {
+    ctx, span := tracing.Start(ctx, "SongService.GetTranslatedVerse", tracing.KindInternal)
+    defer span.End()
+
+    entry := s.log.With(slog.String("method", "get translated verse"))
+
+    song, err := s.store.GetSong(ctx, id)
+    if err != nil {
+        return types.SongVerse{}, err
+    }
+
+    original := splitVerses(song.Text)
+
+    // Validating the page parameter
+    if len(original) < page || page < 1 {
+        err := errors.New("page out of range")
+        entry.ErrorContext(ctx, "Invalid parameter page",
+            slog.Any("error", err),
+        )
+        return types.SongVerse{}, err
+    }
+
+    translation, err := s.GetSongTranslation(ctx, id, lang)
+    if err != nil {
+        return types.SongVerse{}, err
+    }
+
+    translated := splitVerses(translation.Text)
+
+    resp := types.SongVerse{Id: id, Page: page, Verse: original[page-1], Lang: translation.Lang}
+    if page <= len(translated) {
+        resp.Translation = &translated[page-1]
+    }
+
+    if mask {
+        resp.Verse = s.analyzer.Mask(resp.Verse, deref(song.Language))
+        if resp.Translation != nil {
+            masked := s.analyzer.Mask(*resp.Translation, translation.Lang)
+            resp.Translation = &masked
+        }
+        entry.DebugContext(ctx, "Explicit words masked")
+    }
+
+    if len(original) != len(translated) {
+        resp.Warning = fmt.Sprintf(
+            "verse count mismatch: original has %d verses, translation has %d",
+            len(original), len(translated),
+        )
+        entry.WarnContext(ctx, "Verse count mismatch",
+            slog.Int("id", id),
+            slog.String("lang", translation.Lang),
+            slog.Int("original", len(original)),
+            slog.Int("translation", len(translated)),
+        )
+    }
+
+    entry.InfoContext(ctx, "Translated verse received successfully")
+
+    return resp, nil
+}
@@ -0,0 +1,342 @@
+package similarity
+
+import (
+    "errors"
+    "math"
+    "sort"
+    "sync"
+    "time"
+)
+
+// ErrNotIndexed is returned when document is not in the index
+var ErrNotIndexed = errors.New("similarity: document is not indexed")
+
+// Meta is the song data results can be restricted by
+type Meta struct {
+    Id          int
+    Song        string
+    Group       string
+    Language    string
+    ReleaseDate time.Time
+}
+
+// Match is the similar document with cosine similarity score
+type Match struct {
+    Meta
+    Score float64
+}
+
+type document struct {
+    meta  Meta
+    terms map[string]int
+}
+
+// KeyFunc returns the keys the document is found by with Candidates
+type KeyFunc func(Meta) []string
+
+// Index is the in-memory TF-IDF index of song lyrics.
+// It is safe for concurrent use and updated incrementally.
+type Index struct {
+    mu   sync.RWMutex
+    docs map[int]*document
+    // Number of documents every term occurs in
+    df map[string]int
+    // Documents by the keys of their meta
+    keys  KeyFunc
+    byKey map[string]map[int]bool
+}
+
+// NewIndex returns the empty index, documents are found
+// by the keys of their meta (nil keys finds nothing)
+func NewIndex(keys KeyFunc) *Index {
+    return &Index{
+        docs:  map[int]*document{},
+        df:    map[string]int{},
+        keys:  keys,
+        byKey: map[string]map[int]bool{},
+    }
+}
+
+// Upsert adds document with term frequencies or replaces existing one
+func (i *Index) Upsert(meta Meta, terms map[string]int) {
+    i.mu.Lock()
+    defer i.mu.Unlock()
+
+    i.remove(meta.Id)
+
+    copied := make(map[string]int, len(terms))
+    for term, n := range terms {
+        if n > 0 {
+            copied[term] = n
+            i.df[term]++
+        }
+    }
+    i.docs[meta.Id] = &document{meta: meta, terms: copied}
+    i.addKeys(meta)
+}
+
+// UpdateMeta replaces meta of the document keeping its terms
+func (i *Index) UpdateMeta(meta Meta) {
+    i.mu.Lock()
+    defer i.mu.Unlock()
+
+    if doc, ok := i.docs[meta.Id]; ok {
+        i.removeKeys(doc.meta)
+        doc.meta = meta
+        i.addKeys(meta)
+    }
+}
+
+// Remove removes document from the index
+func (i *Index) Remove(id int) {
+    i.mu.Lock()
+    defer i.mu.Unlock()
+
+    i.remove(id)
+}
+
+func (i *Index) remove(id int) {
+    doc, ok := i.docs[id]
+    if !ok {
+        return
+    }
+    for term := range doc.terms {
+        if i.df[term]--; i.df[term] <= 0 {
+            delete(i.df, term)
+        }
+    }
+    i.removeKeys(doc.meta)
+    delete(i.docs, id)
+}
+
+func (i *Index) addKeys(meta Meta) {
+    if i.keys == nil {
+        return
+    }
+    for _, key := range i.keys(meta) {
+        if i.byKey[key] == nil {
+            i.byKey[key] = map[int]bool{}
+        }
+        i.byKey[key][meta.Id] = true
+    }
+}
+
+func (i *Index) removeKeys(meta Meta) {
+    if i.keys == nil {
+        return
+    }
+    for _, key := range i.keys(meta) {
+        if delete(i.byKey[key], meta.Id); len(i.byKey[key]) == 0 {
+            delete(i.byKey, key)
+        }
+    }
+}
+
+// Candidates returns meta of the documents found
+// by any of the keys (see KeyFunc) ordered by id
+func (i *Index) Candidates(keys []string) []Meta {
+    i.mu.RLock()
+    defer i.mu.RUnlock()
+
+    var metas []Meta
+    seen := map[int]bool{}
+    for _, key := range keys {
+        for id := range i.byKey[key] {
+            if !seen[id] {
+                seen[id] = true
+                metas = append(metas, i.docs[id].meta)
+            }
+        }
+    }
+    sort.Slice(metas, func(a, b int) bool {
+        return metas[a].Id < metas[b].Id
+    })
+    return metas
+}
+
+// ScoreTerms returns similarity scores of the documents ids
+// to the term frequencies, 0 if the document is not indexed
+func (i *Index) ScoreTerms(terms map[string]int, ids []int) map[int]float64 {
+    i.mu.RLock()
+    defer i.mu.RUnlock()
+
+    scores := make(map[int]float64, len(ids))
+    if len(ids) == 0 {
+        return scores
+    }
+
+    query := i.vector(terms)
+    for _, id := range ids {
+        if doc, ok := i.docs[id]; ok {
+            scores[id] = cosine(query, i.vector(doc.terms))
+        }
+    }
+    return scores
+}
+
+// Len returns number of indexed documents
+func (i *Index) Len() int {
+    i.mu.RLock()
+    defer i.mu.RUnlock()
+
+    return len(i.docs)
+}
+
+// Similar returns up to n documents most similar to the document id
+// that pass keep (nil keeps all). Documents with zero score are skipped.
+func (i *Index) Similar(id int, n int, keep func(Meta) bool) ([]Match, error) {
+    i.mu.RLock()
+    defer i.mu.RUnlock()
+
+    doc, ok := i.docs[id]
+    if !ok {
+        return nil, ErrNotIndexed
+    }
+
+    return i.similar(doc.terms, n, func(meta Meta) bool {
+        return meta.Id != id && (keep == nil || keep(meta))
+    }), nil
+}
+
+// Vectors returns the snapshot of the index, vectors of the
+// documents are computed once for any number of scores
+func (i *Index) Vectors() *Vectors {
+    i.mu.RLock()
+    defer i.mu.RUnlock()
+
+    v := &Vectors{
+        metas:   make([]Meta, 0, len(i.docs)),
+        vectors: make(map[int]map[string]float64, len(i.docs)),
+    }
+    for id, doc := range i.docs {
+        v.metas = append(v.metas, doc.meta)
+        v.vectors[id] = unit(i.vector(doc.terms))
+    }
+    sort.Slice(v.metas, func(a, b int) bool {
+        return v.metas[a].Id < v.metas[b].Id
+    })
+    return v
+}
+
+// Documents returns meta of all indexed documents ordered by id
+func (i *Index) Documents() []Meta {
+    i.mu.RLock()
+    defer i.mu.RUnlock()
+
+    metas := make([]Meta, 0, len(i.docs))
+    for _, doc := range i.docs {
+        metas = append(metas, doc.meta)
+    }
+    sort.Slice(metas, func(a, b int) bool {
+        return metas[a].Id < metas[b].Id
+    })
+    return metas
+}
+
+func (i *Index) similar(terms map[string]int, n int, keep func(Meta) bool) []Match {
+    query := i.vector(terms)
+
+    var matches []Match
+    for _, doc := range i.docs {
+        if keep != nil && !keep(doc.meta) {
+            continue
+        }
+        if score := cosine(query, i.vector(doc.terms)); score > 0 {
+            matches = append(matches, Match{Meta: doc.meta, Score: score})
+        }
+    }
+
+    sort.Slice(matches, func(a, b int) bool {
+        if matches[a].Score != matches[b].Score {
+            return matches[a].Score > matches[b].Score
+        }
+        return matches[a].Id < matches[b].Id
+    })
+    if len(matches) > n {
+        matches = matches[:n]
+    }
+    return matches
+}
+
+// vector computes TF-IDF weights of the term frequencies
+// using smoothed IDF of the current index state
+func (i *Index) vector(terms map[string]int) map[string]float64 {
+    return weights(terms, i.df, float64(len(i.docs)))
+}
+
+// weights computes TF-IDF weights of the term frequencies
+// with document frequencies of the terms out of total
+func weights(terms map[string]int, df map[string]int, total float64) map[string]float64 {
+    v := make(map[string]float64, len(terms))
+    for term, n := range terms {
+        if n > 0 {
+            idf := math.Log((1+total)/(1+float64(df[term]))) + 1
+            v[term] = (1 + math.Log(float64(n))) * idf
+        }
+    }
+    return v
+}
+
+// Vectors is the snapshot of the index with unit TF-IDF
+// vectors, it is not changed by updates of the index
+type Vectors struct {
+    metas   []Meta
+    vectors map[int]map[string]float64
+}
+
+// Documents returns meta of the documents ordered by id
+func (v *Vectors) Documents() []Meta {
+    return v.metas
+}
+
+// Score returns similarity score of two documents,
+// 0 if either is not in the snapshot
+func (v *Vectors) Score(a, b int) float64 {
+    return dot(v.vectors[a], v.vectors[b])
+}
+
+// unit scales the vector to length 1, so
+// cosine similarity is the dot product
+func unit(v map[string]float64) map[string]float64 {
+    var norm float64
+    for _, w := range v {
+        norm += w * w
+    }
+    if norm == 0 {
+        return v
+    }
+    norm = math.Sqrt(norm)
+    for term, w := range v {
+        v[term] = w / norm
+    }
+    return v
+}
+
+func dot(a, b map[string]float64) float64 {
+    if len(a) > len(b) {
+        a, b = b, a
+    }
+    var sum float64
+    for term, w := range a {
+        sum += w * b[term]
+    }
+    return sum
+}
+
+func cosine(a, b map[string]float64) float64 {
+    if len(a) > len(b) {
+        a, b = b, a
+    }
+    var dot, normA, normB float64
+    for term, w := range a {
+        dot += w * b[term]
+        normA += w * w
+    }
+    for _, w := range b {
+        normB += w * w
+    }
+    if normA == 0 || normB == 0 {
+        return 0
+    }
+    return dot / math.Sqrt(normA*normB)
+}
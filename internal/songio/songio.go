@@ -0,0 +1,385 @@
+// Package songio reads and writes songs as JSON, CSV
+// and JSON Lines files for import and export.
+package songio
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/csv"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "io"
+    "strings"
+)
+
+// Formats of the song files
+const (
+    FormatJSON  = "json"
+    FormatCSV   = "csv"
+    FormatJSONL = "jsonl"
+)
+
+// Fields of the song record, also default CSV header
+var Fields = []string{"song", "group", "text", "link", "releaseDate"}
+
+// Max length of the JSON Lines line
+const maxLineSize = 16 << 20
+
+// Record is the song of the file,
+// release date is kept as is ("16.07.2006")
+type Record struct {
+    // Line of the record in the file
+    Row int `json:"-"`
+    // Record as it was in the file
+    Raw string `json:"-"`
+
+    Song        string `json:"song"`
+    Group       string `json:"group"`
+    Text        string `json:"text"`
+    Link        string `json:"link"`
+    ReleaseDate string `json:"releaseDate"`
+}
+
+// RowError is returned when single record
+// cannot be read, reading can be continued
+type RowError struct {
+    Row int
+    Raw string
+    Err error
+}
+
+func (e *RowError) Error() string {
+    return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+    return e.Err
+}
+
+// Reader reads records until io.EOF
+type Reader interface {
+    Read() (Record, error)
+}
+
+// ParseFormat returns format by its name or file extension
+func ParseFormat(s string) (string, error) {
+    switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+    case "json":
+        return FormatJSON, nil
+    case "csv":
+        return FormatCSV, nil
+    case "jsonl", "ndjson":
+        return FormatJSONL, nil
+    }
+    return "", fmt.Errorf("unknown format %q", s)
+}
+
+// ParseMapping parses CSV column mapping in the form
+// "song=Title,group=Artist", where keys are record fields
+// and values are CSV header columns. Fields not mapped
+// are read from the columns named after them.
+func ParseMapping(s string) (map[string]string, error) {
+    mapping := map[string]string{}
+    for _, field := range Fields {
+        mapping[field] = field
+    }
+    if strings.TrimSpace(s) == "" {
+        return mapping, nil
+    }
+
+    for _, pair := range strings.Split(s, ",") {
+        field, column, ok := strings.Cut(pair, "=")
+        field, column = strings.TrimSpace(field), strings.TrimSpace(column)
+        if !ok || column == "" {
+            return nil, fmt.Errorf("invalid mapping %q", pair)
+        }
+        if _, known := mapping[field]; !known {
+            return nil, fmt.Errorf("unknown field %q", field)
+        }
+        mapping[field] = column
+    }
+    return mapping, nil
+}
+
+// NewRecord returns record of the song
+func NewRecord(song types.Song) Record {
+    return Record{
+        Song:        song.Song,
+        Group:       song.Group,
+        Text:        song.Text,
+        Link:        song.Link,
+        ReleaseDate: song.ReleaseDate.String(),
+    }
+}
+
+// NewReader returns reader of the format,
+// mapping is used only for CSV
+func NewReader(r io.Reader, format string, mapping map[string]string) (Reader, error) {
+    switch format {
+    case FormatJSON:
+        return newJSONReader(r)
+    case FormatCSV:
+        return newCSVReader(r, mapping)
+    case FormatJSONL:
+        scanner := bufio.NewScanner(r)
+        scanner.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+        return &jsonlReader{scanner: scanner}, nil
+    }
+    return nil, fmt.Errorf("unknown format %q", format)
+}
+
+type csvReader struct {
+    r *csv.Reader
+    // Column index of the record fields
+    columns map[string]int
+}
+
+func newCSVReader(r io.Reader, mapping map[string]string) (*csvReader, error) {
+    cr := csv.NewReader(r)
+    cr.FieldsPerRecord = -1
+
+    header, err := cr.Read()
+    if err != nil {
+        if errors.Is(err, io.EOF) {
+            return nil, errors.New("csv header is missing")
+        }
+        return nil, err
+    }
+    if len(header) > 0 {
+        header[0] = strings.TrimPrefix(header[0], "\ufeff")
+    }
+
+    byName := map[string]int{}
+    for i, name := range header {
+        byName[strings.TrimSpace(name)] = i
+    }
+
+    columns := map[string]int{}
+    for field, column := range mapping {
+        if i, ok := byName[column]; ok {
+            columns[field] = i
+        }
+    }
+    for _, field := range []string{"song", "group"} {
+        if _, ok := columns[field]; !ok {
+            return nil, fmt.Errorf("csv column %q for %s is missing", mapping[field], field)
+        }
+    }
+
+    return &csvReader{r: cr, columns: columns}, nil
+}
+
+func (r *csvReader) Read() (Record, error) {
+    values, err := r.r.Read()
+    if err != nil {
+        var parseErr *csv.ParseError
+        if errors.As(err, &parseErr) {
+            return Record{}, &RowError{Row: parseErr.StartLine, Err: parseErr.Err}
+        }
+        return Record{}, err
+    }
+    row, _ := r.r.FieldPos(0)
+
+    value := func(field string) string {
+        i, ok := r.columns[field]
+        if !ok || i >= len(values) {
+            return ""
+        }
+        return values[i]
+    }
+
+    return Record{
+        Row:         row,
+        Raw:         csvLine(values),
+        Song:        value("song"),
+        Group:       value("group"),
+        Text:        value("text"),
+        Link:        value("link"),
+        ReleaseDate: value("releaseDate"),
+    }, nil
+}
+
+// csvLine encodes values back to the CSV line
+func csvLine(values []string) string {
+    var buf bytes.Buffer
+    w := csv.NewWriter(&buf)
+    w.Write(values)
+    w.Flush()
+    return strings.TrimSuffix(buf.String(), "\n")
+}
+
+type jsonlReader struct {
+    scanner *bufio.Scanner
+    row     int
+}
+
+func (r *jsonlReader) Read() (Record, error) {
+    for r.scanner.Scan() {
+        r.row++
+        line := strings.TrimSpace(r.scanner.Text())
+        if r.row == 1 {
+            line = strings.TrimPrefix(line, "\ufeff")
+        }
+        if line == "" {
+            continue
+        }
+
+        record := Record{Row: r.row, Raw: line}
+        if err := json.Unmarshal([]byte(line), &record); err != nil {
+            return Record{}, &RowError{Row: r.row, Raw: line, Err: err}
+        }
+        return record, nil
+    }
+    if err := r.scanner.Err(); err != nil {
+        return Record{}, err
+    }
+    return Record{}, io.EOF
+}
+
+// jsonReader reads records of the JSON array one by one
+type jsonReader struct {
+    dec *json.Decoder
+    row int
+}
+
+func newJSONReader(r io.Reader) (*jsonReader, error) {
+    dec := json.NewDecoder(r)
+    token, err := dec.Token()
+    if err != nil {
+        return nil, err
+    }
+    if delim, ok := token.(json.Delim); !ok || delim != '[' {
+        return nil, errors.New("json array of songs expected")
+    }
+    return &jsonReader{dec: dec}, nil
+}
+
+func (r *jsonReader) Read() (Record, error) {
+    if !r.dec.More() {
+        return Record{}, io.EOF
+    }
+    r.row++
+
+    // Invalid JSON cannot be skipped, so only
+    // elements of the wrong shape are row errors
+    var raw json.RawMessage
+    if err := r.dec.Decode(&raw); err != nil {
+        return Record{}, err
+    }
+
+    record := Record{Row: r.row, Raw: string(raw)}
+    if err := json.Unmarshal(raw, &record); err != nil {
+        return Record{}, &RowError{Row: r.row, Raw: string(raw), Err: err}
+    }
+    return record, nil
+}
+
+// Writer writes records, Close completes the
+// document but does not close the underlying writer
+type Writer interface {
+    Write(Record) error
+    Close() error
+}
+
+// ContentType returns media type of the format
+func ContentType(format string) string {
+    switch format {
+    case FormatJSON:
+        return "application/json"
+    case FormatCSV:
+        return "text/csv"
+    case FormatJSONL:
+        return "application/x-ndjson"
+    }
+    return "application/octet-stream"
+}
+
+// NewWriter returns writer of the format,
+// CSV header is written at once
+func NewWriter(w io.Writer, format string) (Writer, error) {
+    switch format {
+    case FormatJSON:
+        return &jsonWriter{w: w}, nil
+    case FormatCSV:
+        cw := csv.NewWriter(w)
+        if err := cw.Write(Fields); err != nil {
+            return nil, err
+        }
+        return &csvWriter{w: cw}, nil
+    case FormatJSONL:
+        return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+    }
+    return nil, fmt.Errorf("unknown format %q", format)
+}
+
+type jsonWriter struct {
+    w     io.Writer
+    count int
+}
+
+func (w *jsonWriter) Write(record Record) error {
+    b, err := json.Marshal(record)
+    if err != nil {
+        return err
+    }
+
+    sep := ",\n"
+    if w.count == 0 {
+        sep = "[\n"
+    }
+    w.count++
+
+    _, err = w.w.Write(append([]byte(sep), b...))
+    return err
+}
+
+func (w *jsonWriter) Close() error {
+    end := "\n]\n"
+    if w.count == 0 {
+        end = "[]\n"
+    }
+    _, err := io.WriteString(w.w, end)
+    return err
+}
+
+type csvWriter struct {
+    w *csv.Writer
+}
+
+func (w *csvWriter) Write(record Record) error {
+    return w.w.Write([]string{record.Song, record.Group, record.Text, record.Link, record.ReleaseDate})
+}
+
+func (w *csvWriter) Close() error {
+    w.w.Flush()
+    return w.w.Error()
+}
+
+type jsonlWriter struct {
+    enc *json.Encoder
+}
+
+func (w *jsonlWriter) Write(record Record) error {
+    return w.enc.Encode(record)
+}
+
+func (w *jsonlWriter) Close() error {
+    return nil
+}
+
+// WriteRejected writes report of the rejected records as CSV
+func WriteRejected(w io.Writer, rejected []types.ImportRejectedRow) error {
+    cw := csv.NewWriter(w)
+    if err := cw.Write([]string{"row", "reason", "record"}); err != nil {
+        return err
+    }
+    for _, r := range rejected {
+        if err := cw.Write([]string{fmt.Sprint(r.Row), r.Reason, r.Record}); err != nil {
+            return err
+        }
+    }
+    cw.Flush()
+    return cw.Error()
+}
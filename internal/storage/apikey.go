@@ -0,0 +1,162 @@
+package storage
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "github.com/lib/pq"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+)
+
+// apiKeyColumns are the columns of api_key
+// table in the order that scanApiKey expects
+const apiKeyColumns = `"id", "name", "prefix", "scopes", "created_at", "last_used_at", "revoked_at"`
+
+func scanApiKey(row rowScanner, key *types.ApiKey) error {
+    var scopes pq.StringArray
+    if err := row.Scan(
+        &key.Id,
+        &key.Name,
+        &key.Prefix,
+        &scopes,
+        &key.CreatedAt,
+        &key.LastUsedAt,
+        &key.RevokedAt,
+    ); err != nil {
+        return err
+    }
+
+    key.Scopes = make([]types.Scope, len(scopes))
+    for i, scope := range scopes {
+        key.Scopes[i] = types.Scope(scope)
+    }
+
+    return nil
+}
+
+func (s *PostgresStore) GetApiKeys(ctx context.Context) ([]types.ApiKey, error) {
+    ctx, entry := s.begin(ctx, "get api keys")
+
+    query := `SELECT ` + apiKeyColumns + ` FROM api_key ORDER BY "id";`
+
+    rows, err := s.db.QueryContext(ctx, query)
+    if err != nil {
+        entry.ErrorContext(ctx, "Get api keys query failed",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+    defer rows.Close()
+
+    keys := []types.ApiKey{}
+    for rows.Next() {
+        var key types.ApiKey
+        if err := scanApiKey(rows, &key); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan api key", slog.Any("error", err))
+            return nil, err
+        }
+        keys = append(keys, key)
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to read api keys", slog.Any("error", err))
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Got api keys successfully")
+
+    return keys, nil
+}
+
+// GetApiKeyByHash returns the key with the hash,
+// sql.ErrNoRows if there is none
+func (s *PostgresStore) GetApiKeyByHash(ctx context.Context, hash string) (types.ApiKey, error) {
+    ctx, entry := s.begin(ctx, "get api key by hash")
+
+    query := `SELECT ` + apiKeyColumns + ` FROM api_key WHERE "hash" = $1;`
+
+    var key types.ApiKey
+    if err := scanApiKey(s.db.QueryRowContext(ctx, query, hash), &key); err != nil {
+        if !errors.Is(err, sql.ErrNoRows) {
+            entry.ErrorContext(ctx, "Failed to get api key",
+                slog.String("query", query),
+                slog.Any("error", err),
+            )
+        }
+        return types.ApiKey{}, err
+    }
+
+    return key, nil
+}
+
+func (s *PostgresStore) CreateApiKey(ctx context.Context, req types.CreateApiKey, prefix, hash string) (types.ApiKey, error) {
+    ctx, entry := s.begin(ctx, "create api key")
+
+    scopes := make([]string, len(req.Scopes))
+    for i, scope := range req.Scopes {
+        scopes[i] = string(scope)
+    }
+
+    query := `
+            INSERT INTO api_key ("name", "prefix", "hash", "scopes")
+            VALUES ($1, $2, $3, $4)
+            RETURNING ` + apiKeyColumns + `;`
+
+    var key types.ApiKey
+    if err := scanApiKey(s.db.QueryRowContext(ctx, query, req.Name, prefix, hash, pq.Array(scopes)), &key); err != nil {
+        entry.ErrorContext(ctx, "Failed to create api key",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return types.ApiKey{}, err
+    }
+
+    entry.InfoContext(ctx, "Api key created successfully", slog.Int("id", key.Id))
+
+    return key, nil
+}
+
+// RevokeApiKey marks the key revoked, sql.ErrNoRows
+// is returned if there is no such active key
+func (s *PostgresStore) RevokeApiKey(ctx context.Context, id int) error {
+    ctx, entry := s.begin(ctx, "revoke api key")
+
+    query := `UPDATE api_key SET "revoked_at" = now() WHERE "id" = $1 AND "revoked_at" IS NULL;`
+
+    res, err := s.db.ExecContext(ctx, query, id)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to revoke api key",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return err
+    }
+    if n, err := res.RowsAffected(); err == nil && n == 0 {
+        return sql.ErrNoRows
+    }
+
+    entry.InfoContext(ctx, "Api key revoked successfully", slog.Int("id", id))
+
+    return nil
+}
+
+// TouchApiKey sets last used time of the key. To spare writes
+// on every request the time is kept if it is less than a minute old.
+func (s *PostgresStore) TouchApiKey(ctx context.Context, id int) error {
+    ctx, entry := s.begin(ctx, "touch api key")
+
+    query := `
+            UPDATE api_key SET "last_used_at" = now()
+            WHERE "id" = $1 AND ("last_used_at" IS NULL OR "last_used_at" < now() - interval '1 minute');`
+
+    if _, err := s.db.ExecContext(ctx, query, id); err != nil {
+        entry.ErrorContext(ctx, "Failed to touch api key",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return err
+    }
+
+    return nil
+}
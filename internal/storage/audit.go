@@ -0,0 +1,231 @@
+package storage
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "github.com/lib/pq"
+    "github.com/vasch3nko/songlibrary/internal/reqctx"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+    "strings"
+    "time"
+)
+
+// auditEventColumns are the columns of audit_event
+// table in the order that scanAuditEvent expects
+const auditEventColumns = `"id", "song_id", "action", "actor", "remote_addr", "request_id", "before", "after", "created_at"`
+
+func scanAuditEvent(row rowScanner, e *types.AuditEvent) error {
+    var before, after []byte
+    if err := row.Scan(
+        &e.Id,
+        &e.SongId,
+        &e.Action,
+        &e.Actor,
+        &e.RemoteAddr,
+        &e.RequestId,
+        &before,
+        &after,
+        &e.CreatedAt,
+    ); err != nil {
+        return err
+    }
+
+    e.Before, e.After = before, after
+
+    return nil
+}
+
+// auditFilter builds WHERE clause of the audit event query
+// with positional arguments starting from $1
+func auditFilter(filter types.GetAuditEvents) (string, []interface{}) {
+    var whereClauses []string
+    var args []interface{}
+
+    add := func(clause string, arg interface{}) {
+        args = append(args, arg)
+        whereClauses = append(whereClauses, fmt.Sprintf(clause, len(args)))
+    }
+
+    if filter.SongId != nil {
+        add(`"song_id" = $%d`, *filter.SongId)
+    }
+    if filter.Actor != nil {
+        add(`"actor" = $%d`, *filter.Actor)
+    }
+    if filter.From != nil {
+        add(`"created_at" >= $%d`, *filter.From)
+    }
+    if filter.To != nil {
+        add(`"created_at" < $%d`, *filter.To)
+    }
+
+    if len(whereClauses) == 0 {
+        return "", nil
+    }
+
+    return " WHERE " + strings.Join(whereClauses, " AND "), args
+}
+
+// GetAuditEvents returns events of the filter, the newest first
+func (s *PostgresStore) GetAuditEvents(ctx context.Context, filter types.GetAuditEvents, offset, limit int) ([]types.AuditEvent, error) {
+    ctx, entry := s.begin(ctx, "get audit events")
+
+    query := `SELECT ` + auditEventColumns + ` FROM audit_event`
+
+    where, args := auditFilter(filter)
+    query += where
+
+    query += fmt.Sprintf(` ORDER BY "created_at" DESC, "id" DESC OFFSET $%d LIMIT $%d`, len(args)+1, len(args)+2)
+    args = append(args, offset, limit)
+
+    rows, err := s.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        entry.ErrorContext(ctx, "Get audit events query failed",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+    defer rows.Close()
+
+    events := []types.AuditEvent{}
+    for rows.Next() {
+        var e types.AuditEvent
+        if err := scanAuditEvent(rows, &e); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan audit event", slog.Any("error", err))
+            return nil, err
+        }
+        events = append(events, e)
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to read audit events", slog.Any("error", err))
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Got audit events successfully")
+
+    return events, nil
+}
+
+// auditEvent returns the event of the song change by the caller
+// of ctx, before and after are nil if there is no such snapshot
+func auditEvent(ctx context.Context, action string, songId int, before, after *types.Song) (types.AuditEvent, error) {
+    event := types.AuditEvent{
+        SongId:     songId,
+        Action:     action,
+        Actor:      reqctx.Subject(ctx),
+        RemoteAddr: reqctx.RemoteAddr(ctx),
+        RequestId:  reqctx.RequestId(ctx),
+    }
+
+    var err error
+    if before != nil {
+        if event.Before, err = json.Marshal(before); err != nil {
+            return types.AuditEvent{}, err
+        }
+    }
+    if after != nil {
+        if event.After, err = json.Marshal(after); err != nil {
+            return types.AuditEvent{}, err
+        }
+    }
+
+    return event, nil
+}
+
+// createAuditEvents appends the events in the transaction of the change,
+// so the change is not committed if its events cannot be recorded.
+// Ids and creation times of the events are set by the storage.
+func createAuditEvents(ctx context.Context, tx *instrumentedTx, entry *slog.Logger, events ...types.AuditEvent) error {
+    if len(events) == 0 {
+        return nil
+    }
+
+    stmt, err := tx.PrepareContext(ctx, pq.CopyIn("audit_event",
+        "song_id", "action", "actor", "remote_addr", "request_id", "before", "after",
+    ))
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to prepare audit events copy", slog.Any("error", err))
+        return err
+    }
+    defer stmt.Close()
+
+    for _, e := range events {
+        if _, err := stmt.ExecContext(ctx,
+            e.SongId,
+            e.Action,
+            e.Actor,
+            e.RemoteAddr,
+            e.RequestId,
+            nullJson(e.Before),
+            nullJson(e.After),
+        ); err != nil {
+            entry.ErrorContext(ctx, "Failed to copy audit event", slog.Int("song_id", e.SongId), slog.Any("error", err))
+            return err
+        }
+    }
+    if _, err := stmt.ExecContext(ctx); err != nil {
+        entry.ErrorContext(ctx, "Failed to create audit events", slog.Any("error", err))
+        return err
+    }
+
+    return nil
+}
+
+// auditSong records the change of the song in the transaction. Before
+// is read locked earlier in the transaction, after is read here.
+func auditSong(ctx context.Context, tx *instrumentedTx, entry *slog.Logger, action string, id int, before *types.Song) error {
+    var after *types.Song
+    if action != types.AuditDelete {
+        song, err := getSong(ctx, tx, entry, id, false)
+        if err != nil {
+            return err
+        }
+        after = &song
+    }
+
+    event, err := auditEvent(ctx, action, id, before, after)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to marshal song snapshot", slog.Any("error", err))
+        return err
+    }
+
+    return createAuditEvents(ctx, tx, entry, event)
+}
+
+// DeleteAuditEventsBefore deletes events older than the
+// time and returns the number of deleted events
+func (s *PostgresStore) DeleteAuditEventsBefore(ctx context.Context, t time.Time) (int64, error) {
+    ctx, entry := s.begin(ctx, "delete audit events before")
+
+    query := `DELETE FROM audit_event WHERE "created_at" < $1;`
+
+    res, err := s.db.ExecContext(ctx, query, t)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to delete audit events",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return 0, err
+    }
+
+    n, err := res.RowsAffected()
+    if err != nil {
+        return 0, err
+    }
+
+    entry.InfoContext(ctx, "Audit events deleted successfully", slog.Int64("deleted", n))
+
+    return n, nil
+}
+
+// nullJson returns nil for empty JSON,
+// so it is stored as NULL of jsonb column
+func nullJson(b []byte) any {
+    if len(b) == 0 {
+        return nil
+    }
+    return string(b)
+}
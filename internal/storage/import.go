@@ -0,0 +1,212 @@
+package storage
+
+import (
+    "context"
+    "github.com/lib/pq"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+)
+
+// importColumns are the song columns filled on import
+const importColumns = `
+                "name", "group", "text", "link", "release_date",
+                "language", "language_confidence", "explicit", "explicit_verses",
+                "word_count", "unique_words", "verse_count", "avg_verse_length", "word_freq"`
+
+// ImportSongs copies songs to the temporary table and
+// inserts them in a single transaction. Status of every song
+// (created / existing / updated) is returned in the same order.
+// Existing songs are kept unless conflicts are updates.
+// Created and updated songs are audited in the same transaction.
+func (s *PostgresStore) ImportSongs(ctx context.Context, songs []types.CreateSong, onConflict types.OnConflict) ([]string, error) {
+    ctx, entry := s.begin(ctx, "import songs")
+
+    tx, err := s.db.BeginTx(ctx, nil)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to begin transaction", slog.Any("error", err))
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    // Staging table has the types of song columns
+    query := `
+            CREATE TEMP TABLE song_import ON COMMIT DROP AS
+            SELECT 0 AS "idx",` + importColumns + `
+            FROM song WITH NO DATA;`
+    if _, err := tx.ExecContext(ctx, query); err != nil {
+        entry.ErrorContext(ctx, "Failed to create import table",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+
+    stmt, err := tx.PrepareContext(ctx, pq.CopyIn("song_import",
+        "idx", "name", "group", "text", "link", "release_date",
+        "language", "language_confidence", "explicit", "explicit_verses",
+        "word_count", "unique_words", "verse_count", "avg_verse_length", "word_freq",
+    ))
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to prepare copy", slog.Any("error", err))
+        return nil, err
+    }
+    defer stmt.Close()
+
+    for i, song := range songs {
+        _, err := stmt.ExecContext(ctx,
+            i,
+            song.Song,
+            song.Group,
+            song.Text,
+            song.Link,
+            song.ReleaseDate,
+            song.Analysis.Language,
+            song.Analysis.LanguageConfidence,
+            song.Analysis.Explicit,
+            intArray(song.Analysis.ExplicitVerses),
+            song.Analysis.Stats.WordCount,
+            song.Analysis.Stats.UniqueWords,
+            song.Analysis.Stats.VerseCount,
+            song.Analysis.Stats.AvgVerseLength,
+            wordFrequency(song.Analysis.Stats.WordFrequency),
+        )
+        if err != nil {
+            entry.ErrorContext(ctx, "Failed to copy song", slog.Int("idx", i), slog.Any("error", err))
+            return nil, err
+        }
+    }
+    if _, err := stmt.ExecContext(ctx); err != nil {
+        entry.ErrorContext(ctx, "Failed to flush copy", slog.Any("error", err))
+        return nil, err
+    }
+
+    statuses := make([]string, len(songs))
+    for i := range statuses {
+        statuses[i] = types.SongCreated
+    }
+
+    // Songs having the same group and name as existing ones,
+    // they are locked, so updates are audited with the state they replace
+    query = `
+            SELECT i."idx", s."id" FROM song_import i
+            JOIN song s ON lower(btrim(s."group")) = lower(btrim(i."group"))
+                AND lower(btrim(s."name")) = lower(btrim(i."name"))
+            FOR UPDATE OF s;`
+    rows, err := tx.QueryContext(ctx, query)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to find existing songs",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+    defer rows.Close()
+
+    var existing []int
+    for rows.Next() {
+        var i, id int
+        if err := rows.Scan(&i, &id); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan existing song", slog.Any("error", err))
+            return nil, err
+        }
+        existing = append(existing, id)
+        statuses[i] = types.SongExisting
+        if onConflict == types.OnConflictUpdate {
+            statuses[i] = types.SongUpdated
+        }
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to read existing songs", slog.Any("error", err))
+        return nil, err
+    }
+    rows.Close()
+
+    var before map[int]types.Song
+    if onConflict == types.OnConflictUpdate {
+        if before, err = getSongs(ctx, tx, entry, existing); err != nil {
+            return nil, err
+        }
+    }
+
+    query = `
+            INSERT INTO song (` + importColumns + `
+            )
+            SELECT` + importColumns + `
+            FROM song_import ORDER BY "idx"` + onConflictKey
+    if onConflict == types.OnConflictUpdate {
+        query += onConflictUpdate
+    } else {
+        query += `
+            DO NOTHING`
+    }
+    // Row inserted by this statement has zero xmax
+    query += `
+            RETURNING "id", (xmax = 0);`
+
+    rows, err = tx.QueryContext(ctx, query)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to insert imported songs",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+    defer rows.Close()
+
+    var changed []int
+    inserted := map[int]bool{}
+    for rows.Next() {
+        var id int
+        var isInserted bool
+        if err := rows.Scan(&id, &isInserted); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan imported song", slog.Any("error", err))
+            return nil, err
+        }
+        changed = append(changed, id)
+        inserted[id] = isInserted
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to read imported songs", slog.Any("error", err))
+        return nil, err
+    }
+    rows.Close()
+
+    after, err := getSongs(ctx, tx, entry, changed)
+    if err != nil {
+        return nil, err
+    }
+
+    events := make([]types.AuditEvent, 0, len(changed))
+    for _, id := range changed {
+        action := types.AuditUpdate
+        if inserted[id] {
+            action = types.AuditCreate
+        }
+
+        // Songs inserted by concurrent imports after the lock have no state before
+        var old *types.Song
+        if song, ok := before[id]; ok {
+            old = &song
+        }
+        song := after[id]
+
+        event, err := auditEvent(ctx, action, id, old, &song)
+        if err != nil {
+            entry.ErrorContext(ctx, "Failed to marshal song snapshot", slog.Any("error", err))
+            return nil, err
+        }
+        events = append(events, event)
+    }
+    if err := createAuditEvents(ctx, tx, entry, events...); err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        entry.ErrorContext(ctx, "Failed to commit transaction", slog.Any("error", err))
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Songs successfully imported", slog.Int("count", len(songs)))
+
+    return statuses, nil
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "github.com/vasch3nko/songlibrary/internal/metrics"
+    "github.com/vasch3nko/songlibrary/internal/tracing"
+    "log/slog"
+    "strings"
+    "time"
+)
+
+// operationKey is the context key of the storage method name
+type operationKey struct{}
+
+// begin returns ctx that labels queries with the operation
+// and the logger of the operation
+func (s *PostgresStore) begin(ctx context.Context, operation string) (context.Context, *slog.Logger) {
+    ctx = context.WithValue(ctx, operationKey{}, operation)
+    return ctx, s.log.With(slog.String("method", operation))
+}
+
+// operation returns the storage method name of ctx
+func operation(ctx context.Context) string {
+    if operation, ok := ctx.Value(operationKey{}).(string); ok {
+        return operation
+    }
+    return "unknown"
+}
+
+// queryMetrics observes durations of the queries and traces them
+type queryMetrics struct {
+    duration *metrics.HistogramVec
+}
+
+func newQueryMetrics(registry *metrics.Registry, db *sql.DB) *queryMetrics {
+    stats := func(fn func(sql.DBStats) float64) func() float64 {
+        return func() float64 { return fn(db.Stats()) }
+    }
+
+    registry.GaugeFunc("songlibrary_db_open_connections",
+        "Established connections both in use and idle.",
+        stats(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+    registry.GaugeFunc("songlibrary_db_in_use_connections",
+        "Connections currently in use.",
+        stats(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+    registry.GaugeFunc("songlibrary_db_idle_connections",
+        "Idle connections.",
+        stats(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+    registry.GaugeFunc("songlibrary_db_max_open_connections",
+        "Maximum number of open connections, 0 is unlimited.",
+        stats(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+    registry.CounterFunc("songlibrary_db_wait_count_total",
+        "Connections waited for.",
+        stats(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+    registry.CounterFunc("songlibrary_db_wait_duration_seconds_total",
+        "Time blocked waiting for a new connection.",
+        stats(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+    registry.CounterFunc("songlibrary_db_max_idle_closed_total",
+        "Connections closed due to the idle connections limit.",
+        stats(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }))
+    registry.CounterFunc("songlibrary_db_max_lifetime_closed_total",
+        "Connections closed due to the connection lifetime limit.",
+        stats(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }))
+
+    return &queryMetrics{
+        duration: registry.Histogram("songlibrary_db_query_duration_seconds",
+            "Duration of the database queries by storage operation.",
+            metrics.DefaultBuckets, "operation", "status"),
+    }
+}
+
+// start starts observing the query in the span of the
+// operation, the statement is traced without arguments.
+// done ends it with the query error, no rows is not an error.
+func (m *queryMetrics) start(ctx context.Context, query string) (context.Context, func(error)) {
+    start := time.Now()
+    ctx, span := tracing.Start(ctx, operation(ctx), tracing.KindClient,
+        tracing.String("db.system", "postgresql"),
+        tracing.String("db.statement", strings.Join(strings.Fields(query), " ")),
+    )
+
+    return ctx, func(err error) {
+        status := "ok"
+        if err != nil && !errors.Is(err, sql.ErrNoRows) {
+            status = "error"
+            span.RecordError(err)
+        }
+        m.duration.Observe(time.Since(start).Seconds(), operation(ctx), status)
+        span.End()
+    }
+}
+
+// instrumentedDB is sql.DB that observes and traces the queries
+type instrumentedDB struct {
+    *sql.DB
+    metrics *queryMetrics
+}
+
+func (db *instrumentedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+    ctx, done := db.metrics.start(ctx, query)
+    rows, err := db.DB.QueryContext(ctx, query, args...)
+    done(err)
+    return rows, err
+}
+
+func (db *instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+    ctx, done := db.metrics.start(ctx, query)
+    row := db.DB.QueryRowContext(ctx, query, args...)
+    done(row.Err())
+    return row
+}
+
+func (db *instrumentedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+    ctx, done := db.metrics.start(ctx, query)
+    res, err := db.DB.ExecContext(ctx, query, args...)
+    done(err)
+    return res, err
+}
+
+func (db *instrumentedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*instrumentedTx, error) {
+    tx, err := db.DB.BeginTx(ctx, opts)
+    if err != nil {
+        return nil, err
+    }
+    return &instrumentedTx{Tx: tx, metrics: db.metrics}, nil
+}
+
+// instrumentedTx is sql.Tx that observes and traces the queries.
+// Statements prepared in the transaction are not observed.
+type instrumentedTx struct {
+    *sql.Tx
+    metrics *queryMetrics
+}
+
+func (tx *instrumentedTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+    ctx, done := tx.metrics.start(ctx, query)
+    rows, err := tx.Tx.QueryContext(ctx, query, args...)
+    done(err)
+    return rows, err
+}
+
+func (tx *instrumentedTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+    ctx, done := tx.metrics.start(ctx, query)
+    row := tx.Tx.QueryRowContext(ctx, query, args...)
+    done(row.Err())
+    return row
+}
+
+func (tx *instrumentedTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+    ctx, done := tx.metrics.start(ctx, query)
+    res, err := tx.Tx.ExecContext(ctx, query, args...)
+    done(err)
+    return res, err
+}
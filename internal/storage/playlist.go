@@ -0,0 +1,466 @@
+package storage
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+    "strings"
+)
+
+// ErrInvalidPosition is returned when playlist
+// position is out of the playlist entries range
+var ErrInvalidPosition = errors.New("position is out of range")
+
+func (s *PostgresStore) GetPlaylists(ctx context.Context, offset, limit int) ([]types.Playlist, error) {
+    ctx, entry := s.begin(ctx, "get playlists")
+
+    query := `
+            SELECT p."id", p."name", p."description", p."created_at", p."updated_at",
+                (SELECT count(*) FROM playlist_entry e WHERE e."playlist_id" = p."id")
+            FROM playlist p
+            ORDER BY p."id" OFFSET $1 LIMIT $2;`
+
+    rows, err := s.db.QueryContext(ctx, query, offset, limit)
+    if err != nil {
+        entry.ErrorContext(ctx, "Get playlists query failed",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+    defer rows.Close()
+
+    playlists := []types.Playlist{}
+    for rows.Next() {
+        var p types.Playlist
+        if err := rows.Scan(&p.Id, &p.Name, &p.Description, &p.CreatedAt, &p.UpdatedAt, &p.SongCount); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan playlist", slog.Any("error", err))
+            return nil, err
+        }
+        playlists = append(playlists, p)
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to read playlists", slog.Any("error", err))
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Got playlists successfully")
+
+    return playlists, nil
+}
+
+// GetPlaylist returns the playlist with its entries in order.
+// Entries of deleted songs have the name and group they had.
+func (s *PostgresStore) GetPlaylist(ctx context.Context, id int) (types.Playlist, error) {
+    ctx, entry := s.begin(ctx, "get playlist")
+
+    query := `SELECT "id", "name", "description", "created_at", "updated_at" FROM playlist WHERE "id" = $1;`
+
+    var p types.Playlist
+    if err := s.db.QueryRowContext(ctx, query, id).Scan(&p.Id, &p.Name, &p.Description, &p.CreatedAt, &p.UpdatedAt); err != nil {
+        entry.ErrorContext(ctx, "Failed to get playlist",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return types.Playlist{}, err
+    }
+
+    query = `
+            SELECT e."id", e."position", e."song_id",
+                coalesce(s."name", e."song_name", ''), coalesce(s."group", e."song_group", ''),
+                coalesce(s."link", '')
+            FROM playlist_entry e
+            LEFT JOIN song s ON s."id" = e."song_id"
+            WHERE e."playlist_id" = $1
+            ORDER BY e."position";`
+
+    rows, err := s.db.QueryContext(ctx, query, id)
+    if err != nil {
+        entry.ErrorContext(ctx, "Get playlist entries query failed",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return types.Playlist{}, err
+    }
+    defer rows.Close()
+
+    p.Entries = []types.PlaylistEntry{}
+    for rows.Next() {
+        var e types.PlaylistEntry
+        if err := rows.Scan(&e.Id, &e.Position, &e.SongId, &e.Song, &e.Group, &e.Link); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan playlist entry", slog.Any("error", err))
+            return types.Playlist{}, err
+        }
+        e.Missing = e.SongId == nil
+        p.Entries = append(p.Entries, e)
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to read playlist entries", slog.Any("error", err))
+        return types.Playlist{}, err
+    }
+    p.SongCount = len(p.Entries)
+
+    entry.InfoContext(ctx, "Got playlist successfully", slog.Int("id", id))
+
+    return p, nil
+}
+
+func (s *PostgresStore) CreatePlaylist(ctx context.Context, playlist types.CreatePlaylist) (int, error) {
+    ctx, entry := s.begin(ctx, "create playlist")
+
+    query := `INSERT INTO playlist ("name", "description") VALUES ($1, $2) RETURNING "id";`
+
+    var id int
+    if err := s.db.QueryRowContext(ctx, query, playlist.Name, playlist.Description).Scan(&id); err != nil {
+        entry.ErrorContext(ctx, "Failed to create playlist",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return -1, err
+    }
+
+    entry.InfoContext(ctx, "Playlist successfully created", slog.Int("id", id))
+
+    return id, nil
+}
+
+func (s *PostgresStore) UpdatePlaylist(ctx context.Context, id int, playlist types.UpdatePlaylist) error {
+    ctx, entry := s.begin(ctx, "update playlist")
+
+    fields := []string{`"updated_at" = now()`}
+    args := []interface{}{id}
+    if playlist.Name != nil {
+        args = append(args, *playlist.Name)
+        fields = append(fields, fmt.Sprintf(`"name" = $%d`, len(args)))
+    }
+    if playlist.Description != nil {
+        args = append(args, *playlist.Description)
+        fields = append(fields, fmt.Sprintf(`"description" = $%d`, len(args)))
+    }
+
+    query := fmt.Sprintf(`UPDATE playlist SET %s WHERE "id" = $1;`, strings.Join(fields, ", "))
+
+    res, err := s.db.ExecContext(ctx, query, args...)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to update playlist",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return err
+    }
+    if n, err := res.RowsAffected(); err == nil && n == 0 {
+        return sql.ErrNoRows
+    }
+
+    entry.InfoContext(ctx, "Playlist updated successfully", slog.Int("id", id))
+
+    return nil
+}
+
+func (s *PostgresStore) DeletePlaylist(ctx context.Context, id int) error {
+    ctx, entry := s.begin(ctx, "delete playlist")
+
+    query := `DELETE FROM playlist WHERE "id" = $1;`
+
+    if _, err := s.db.ExecContext(ctx, query, id); err != nil {
+        entry.ErrorContext(ctx, "Failed to delete playlist",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return err
+    }
+
+    entry.InfoContext(ctx, "Playlist deleted successfully", slog.Int("id", id))
+
+    return nil
+}
+
+// editPlaylist runs fn in a transaction with the playlist row
+// locked and passes number of its entries to fn.
+// sql.ErrNoRows is returned if there is no such playlist.
+func (s *PostgresStore) editPlaylist(ctx context.Context, id int, fn func(tx *instrumentedTx, count int) error) error {
+    tx, err := s.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    res, err := tx.ExecContext(ctx, `UPDATE playlist SET "updated_at" = now() WHERE "id" = $1;`, id)
+    if err != nil {
+        return err
+    }
+    if n, err := res.RowsAffected(); err != nil || n == 0 {
+        return sql.ErrNoRows
+    }
+
+    var count int
+    query := `SELECT count(*) FROM playlist_entry WHERE "playlist_id" = $1;`
+    if err := tx.QueryRowContext(ctx, query, id).Scan(&count); err != nil {
+        return err
+    }
+
+    if err := fn(tx, count); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// AddPlaylistEntries inserts songs at the position in the given
+// order, entries from the position on are moved down.
+// Position 0 appends songs to the end of the playlist.
+func (s *PostgresStore) AddPlaylistEntries(ctx context.Context, id int, songIds []int, position int) error {
+    ctx, entry := s.begin(ctx, "add playlist entries")
+
+    err := s.editPlaylist(ctx, id, func(tx *instrumentedTx, count int) error {
+        if position == 0 {
+            position = count + 1
+        }
+        if position < 1 || position > count+1 {
+            return ErrInvalidPosition
+        }
+
+        query := `UPDATE playlist_entry SET "position" = "position" + $3 WHERE "playlist_id" = $1 AND "position" >= $2;`
+        if _, err := tx.ExecContext(ctx, query, id, position, len(songIds)); err != nil {
+            return err
+        }
+
+        query = `
+            INSERT INTO playlist_entry ("playlist_id", "position", "song_id")
+            SELECT $1, $2 + t.ord - 1, t.song_id
+            FROM unnest($3::integer[]) WITH ORDINALITY AS t(song_id, ord);`
+        _, err := tx.ExecContext(ctx, query, id, position, intArray(songIds))
+        return err
+    })
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to add playlist entries", slog.Int("id", id), slog.Any("error", err))
+        return err
+    }
+
+    entry.InfoContext(ctx, "Playlist entries added successfully", slog.Int("id", id), slog.Int("count", len(songIds)))
+
+    return nil
+}
+
+// ImportPlaylist creates the playlist with the songs
+// in the given order in a single transaction
+func (s *PostgresStore) ImportPlaylist(ctx context.Context, playlist types.CreatePlaylist, songIds []int) (int, error) {
+    ctx, entry := s.begin(ctx, "import playlist")
+
+    tx, err := s.db.BeginTx(ctx, nil)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to begin transaction", slog.Any("error", err))
+        return -1, err
+    }
+    defer tx.Rollback()
+
+    query := `INSERT INTO playlist ("name", "description") VALUES ($1, $2) RETURNING "id";`
+
+    var id int
+    if err := tx.QueryRowContext(ctx, query, playlist.Name, playlist.Description).Scan(&id); err != nil {
+        entry.ErrorContext(ctx, "Failed to create playlist",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return -1, err
+    }
+
+    query = `
+            INSERT INTO playlist_entry ("playlist_id", "position", "song_id")
+            SELECT $1, t.ord, t.song_id
+            FROM unnest($2::integer[]) WITH ORDINALITY AS t(song_id, ord);`
+
+    if _, err := tx.ExecContext(ctx, query, id, intArray(songIds)); err != nil {
+        entry.ErrorContext(ctx, "Failed to add playlist entries",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return -1, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        entry.ErrorContext(ctx, "Failed to commit transaction", slog.Any("error", err))
+        return -1, err
+    }
+
+    entry.InfoContext(ctx, "Playlist imported successfully", slog.Int("id", id), slog.Int("count", len(songIds)))
+
+    return id, nil
+}
+
+// RemovePlaylistEntry deletes the entry and
+// moves entries after it up
+func (s *PostgresStore) RemovePlaylistEntry(ctx context.Context, id, entryId int) error {
+    ctx, entry := s.begin(ctx, "remove playlist entry")
+
+    err := s.editPlaylist(ctx, id, func(tx *instrumentedTx, count int) error {
+        var position int
+        query := `DELETE FROM playlist_entry WHERE "playlist_id" = $1 AND "id" = $2 RETURNING "position";`
+        if err := tx.QueryRowContext(ctx, query, id, entryId).Scan(&position); err != nil {
+            return err
+        }
+
+        query = `UPDATE playlist_entry SET "position" = "position" - 1 WHERE "playlist_id" = $1 AND "position" > $2;`
+        _, err := tx.ExecContext(ctx, query, id, position)
+        return err
+    })
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to remove playlist entry", slog.Int("id", id), slog.Any("error", err))
+        return err
+    }
+
+    entry.InfoContext(ctx, "Playlist entry removed successfully", slog.Int("id", id), slog.Int("entry_id", entryId))
+
+    return nil
+}
+
+// MovePlaylistEntry moves the entry to the position,
+// entries between old and new positions are shifted
+func (s *PostgresStore) MovePlaylistEntry(ctx context.Context, id, entryId, position int) error {
+    ctx, entry := s.begin(ctx, "move playlist entry")
+
+    err := s.editPlaylist(ctx, id, func(tx *instrumentedTx, count int) error {
+        if position < 1 || position > count {
+            return ErrInvalidPosition
+        }
+
+        var current int
+        query := `SELECT "position" FROM playlist_entry WHERE "playlist_id" = $1 AND "id" = $2;`
+        if err := tx.QueryRowContext(ctx, query, id, entryId).Scan(&current); err != nil {
+            return err
+        }
+
+        switch {
+        case position < current:
+            query = `UPDATE playlist_entry SET "position" = "position" + 1
+                WHERE "playlist_id" = $1 AND "position" >= $2 AND "position" < $3;`
+        case position > current:
+            query = `UPDATE playlist_entry SET "position" = "position" - 1
+                WHERE "playlist_id" = $1 AND "position" > $3 AND "position" <= $2;`
+        default:
+            return nil
+        }
+        if _, err := tx.ExecContext(ctx, query, id, position, current); err != nil {
+            return err
+        }
+
+        query = `UPDATE playlist_entry SET "position" = $2 WHERE "id" = $1;`
+        _, err := tx.ExecContext(ctx, query, entryId, position)
+        return err
+    })
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to move playlist entry", slog.Int("id", id), slog.Any("error", err))
+        return err
+    }
+
+    entry.InfoContext(ctx, "Playlist entry moved successfully",
+        slog.Int("id", id),
+        slog.Int("entry_id", entryId),
+        slog.Int("position", position),
+    )
+
+    return nil
+}
+
+// ReorderPlaylist sets positions of all entries
+// by their order in entryIds
+func (s *PostgresStore) ReorderPlaylist(ctx context.Context, id int, entryIds []int) error {
+    ctx, entry := s.begin(ctx, "reorder playlist")
+
+    err := s.editPlaylist(ctx, id, func(tx *instrumentedTx, count int) error {
+        if len(entryIds) != count {
+            return fmt.Errorf("%d entries given, playlist has %d", len(entryIds), count)
+        }
+
+        query := `
+            UPDATE playlist_entry e SET "position" = t.ord
+            FROM unnest($2::integer[]) WITH ORDINALITY AS t(id, ord)
+            WHERE e."id" = t.id AND e."playlist_id" = $1;`
+        res, err := tx.ExecContext(ctx, query, id, intArray(entryIds))
+        if err != nil {
+            return err
+        }
+
+        // Every entry must be listed once
+        if n, err := res.RowsAffected(); err != nil || int(n) != count {
+            return errors.New("entries do not match entries of the playlist")
+        }
+        return nil
+    })
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to reorder playlist", slog.Int("id", id), slog.Any("error", err))
+        return err
+    }
+
+    entry.InfoContext(ctx, "Playlist reordered successfully", slog.Int("id", id))
+
+    return nil
+}
+
+// detachSongFromPlaylists prepares playlists for deletion of the
+// song in the transaction. Its entries are removed, or keep the song
+// name and group to be shown as missing after the song is deleted.
+func detachSongFromPlaylists(ctx context.Context, tx *instrumentedTx, entry *slog.Logger, songId int, remove bool) error {
+    if !remove {
+        query := `
+            UPDATE playlist_entry e SET "song_name" = s."name", "song_group" = s."group"
+            FROM song s WHERE e."song_id" = s."id" AND s."id" = $1;`
+        if _, err := tx.ExecContext(ctx, query, songId); err != nil {
+            entry.ErrorContext(ctx, "Failed to flag playlist entries",
+                slog.String("query", query),
+                slog.Any("error", err),
+            )
+            return err
+        }
+        entry.DebugContext(ctx, "Playlist entries flagged successfully", slog.Int("song_id", songId))
+        return nil
+    }
+
+    query := `DELETE FROM playlist_entry WHERE "song_id" = $1 RETURNING "playlist_id";`
+    rows, err := tx.QueryContext(ctx, query, songId)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to remove playlist entries",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return err
+    }
+    var playlistIds []int
+    for rows.Next() {
+        var id int
+        if err := rows.Scan(&id); err != nil {
+            rows.Close()
+            entry.ErrorContext(ctx, "Failed to scan playlist id", slog.Any("error", err))
+            return err
+        }
+        playlistIds = append(playlistIds, id)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to remove playlist entries", slog.Any("error", err))
+        return err
+    }
+
+    // Closing the gaps left in positions
+    query = `
+            UPDATE playlist_entry e SET "position" = r.rn
+            FROM (
+                SELECT "id", row_number() OVER (PARTITION BY "playlist_id" ORDER BY "position") AS rn
+                FROM playlist_entry WHERE "playlist_id" = ANY($1)
+            ) r
+            WHERE e."id" = r."id" AND e."position" <> r.rn;`
+    if _, err := tx.ExecContext(ctx, query, intArray(playlistIds)); err != nil {
+        entry.ErrorContext(ctx, "Failed to renumber playlist entries",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return err
+    }
+
+    entry.DebugContext(ctx, "Playlist entries removed successfully", slog.Int("song_id", songId))
+
+    return nil
+}
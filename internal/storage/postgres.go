@@ -1,27 +1,55 @@
 package storage
 
 import (
+    "context"
     "database/sql"
+    "encoding/json"
+    "errors"
     "fmt"
-    _ "github.com/lib/pq"
+    "github.com/lib/pq"
     "github.com/pressly/goose/v3"
+    "github.com/vasch3nko/songlibrary/internal/metrics"
     "github.com/vasch3nko/songlibrary/internal/types"
     "log/slog"
     "strings"
 )
 
+// uniqueViolation is the postgres error code of unique constraint violation
+const uniqueViolation = "23505"
+
+// onConflictKey is the conflict target of the unique group and name index
+const onConflictKey = `
+            ON CONFLICT ((lower(btrim("group"))), (lower(btrim("name"))))`
+
+// onConflictUpdate replaces details of the existing song with inserted ones
+const onConflictUpdate = `
+            DO UPDATE SET
+                "text" = excluded."text",
+                "link" = excluded."link",
+                "release_date" = excluded."release_date",
+                "language" = excluded."language",
+                "language_confidence" = excluded."language_confidence",
+                "explicit" = excluded."explicit",
+                "explicit_verses" = excluded."explicit_verses",
+                "word_count" = excluded."word_count",
+                "unique_words" = excluded."unique_words",
+                "verse_count" = excluded."verse_count",
+                "avg_verse_length" = excluded."avg_verse_length",
+                "word_freq" = excluded."word_freq"`
+
 // PostgresStore is the struct that
 // implements Storage interface
 // for Postgres database
 type PostgresStore struct {
-    db  *sql.DB
+    db  *instrumentedDB
     log *slog.Logger
 }
 
 // NewPostgresStore is a constructor function
 // that creates a PostgresStore struct
-// and connects to postgres DB
-func NewPostgresStore(host, port, user, password, dbname, sslmode string, logger *slog.Logger) (*PostgresStore, error) {
+// and connects to postgres DB. Query durations
+// and connection pool stats go to the registry.
+func NewPostgresStore(host, port, user, password, dbname, sslmode string, registry *metrics.Registry, logger *slog.Logger) (*PostgresStore, error) {
     log := logger.With("component", "storage/postgres")
 
     dsn := fmt.Sprintf(
@@ -54,107 +82,192 @@ func NewPostgresStore(host, port, user, password, dbname, sslmode string, logger
 
     log.Info("Connected to Postgres successfully")
 
-    return &PostgresStore{db: db, log: log}, nil
+    return &PostgresStore{
+        db:  &instrumentedDB{DB: db, metrics: newQueryMetrics(registry, db)},
+        log: log,
+    }, nil
+}
+
+// songColumns are the columns of song table
+// in the order that scanSong expects
+const songColumns = `"id", "name", "group", "text", "link", "release_date",
+    "language", "language_confidence",
+    coalesce("explicit_override", "explicit"), "explicit_verses"`
+
+// songFilter builds WHERE clause of the song query with
+// positional arguments starting from $1. Returns empty clause
+// if filter is empty. Song columns must be unambiguous in the query.
+func songFilter(filter types.GetSongs) (string, []interface{}) {
+    var whereClauses []string
+    var args []interface{}
+
+    add := func(clause string, arg interface{}) {
+        args = append(args, arg)
+        whereClauses = append(whereClauses, fmt.Sprintf(clause, len(args)))
+    }
+
+    if filter.Id != nil {
+        add(`"id" = $%d`, *filter.Id)
+    }
+    if filter.Song != nil {
+        add(`"song" = $%d`, *filter.Song)
+    }
+    if filter.Group != nil {
+        add(`"group" = $%d`, *filter.Group)
+    }
+    if filter.Text != nil {
+        add(`"text" = $%d`, *filter.Text)
+    }
+    if filter.Link != nil {
+        add(`"link" = $%d`, *filter.Link)
+    }
+    if filter.ReleaseDate != nil {
+        add(`"release_date" = $%d`, *filter.ReleaseDate)
+    }
+    if filter.Language != nil {
+        add(`"language" = $%d`, *filter.Language)
+    }
+    if filter.Explicit != nil {
+        add(`coalesce("explicit_override", "explicit") = $%d`, *filter.Explicit)
+    }
+    for _, tags := range filter.Tags {
+        keys := make([]string, len(tags))
+        for i, tag := range tags {
+            keys[i] = tag.Key()
+        }
+        add(`"id" IN (
+                SELECT st."song_id" FROM song_tag st JOIN tag t ON t."id" = st."tag_id"
+                WHERE t."kind" || ':' || t."name" = ANY($%d))`, pq.Array(keys))
+    }
+
+    if len(whereClauses) == 0 {
+        return "", nil
+    }
+
+    return " WHERE " + strings.Join(whereClauses, " AND "), args
 }
 
-func (s *PostgresStore) Migrate(path string) error {
+// rowScanner is the common interface of sql.Row and sql.Rows
+type rowScanner interface {
+    Scan(dest ...any) error
+}
+
+// queryRower is the common interface of sql.DB and sql.Tx
+type queryRower interface {
+    QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// scanSong scans the row selected with songColumns
+func scanSong(row rowScanner, song *types.Song) error {
+    var explicitVerses pq.Int64Array
+    if err := row.Scan(
+        &song.Id,
+        &song.Song,
+        &song.Group,
+        &song.Text,
+        &song.Link,
+        &song.ReleaseDate,
+        &song.Language,
+        &song.LanguageConfidence,
+        &song.Explicit,
+        &explicitVerses,
+    ); err != nil {
+        return err
+    }
+
+    song.ExplicitVerses = make([]int, len(explicitVerses))
+    for i, verse := range explicitVerses {
+        song.ExplicitVerses[i] = int(verse)
+    }
+
+    return nil
+}
+
+func (s *PostgresStore) Migrate(ctx context.Context, path string) error {
     const dialect = "postgres"
     entry := s.log.With(slog.String("method", "migrate"))
 
     goose.SetLogger(slog.NewLogLogger(entry.Handler(), slog.LevelInfo))
 
     if err := goose.SetDialect(dialect); err != nil {
-        entry.Error("Failed to set goose dialect",
+        entry.ErrorContext(ctx, "Failed to set goose dialect",
             slog.String("error", err.Error()),
         )
         return err
     }
 
-    entry.Debug("Goose dialect sets successfully", slog.String("dialect", dialect))
+    entry.DebugContext(ctx, "Goose dialect sets successfully", slog.String("dialect", dialect))
 
-    if err := goose.Up(s.db, path); err != nil {
-        entry.Error("Failed to up migrations",
+    if err := goose.UpContext(ctx, s.db.DB, path); err != nil {
+        entry.ErrorContext(ctx, "Failed to up migrations",
             slog.String("path", path),
             slog.String("error", err.Error()),
         )
         return err
     }
 
-    entry.Info("Migrations completed successfully")
+    entry.InfoContext(ctx, "Migrations completed successfully")
 
     return nil
 }
 
-func (s *PostgresStore) GetSongs(filter types.GetSongs, offset, limit int) ([]types.Song, error) {
-    entry := s.log.With(slog.String("method", "get songs"))
-
-    query := `SELECT "id", "name", "group", "text", "link", "release_date" FROM song`
+// Close closes the database, waiting
+// for the queries in progress to finish
+func (s *PostgresStore) Close() error {
+    return s.db.Close()
+}
 
-    var whereClauses []string
-    var args []interface{}
-    i := 1
+// Ping checks that the database accepts connections
+func (s *PostgresStore) Ping(ctx context.Context) error {
+    return s.db.PingContext(ctx)
+}
 
-    if filter.Id != nil {
-        whereClauses = append(whereClauses, fmt.Sprintf(`"id" = $%d`, i))
-        args = append(args, *filter.Id)
-        i++
-    }
-    if filter.Song != nil {
-        whereClauses = append(whereClauses, fmt.Sprintf(`"song" = $%d`, i))
-        args = append(args, *filter.Song)
-        i++
-    }
-    if filter.Group != nil {
-        whereClauses = append(whereClauses, fmt.Sprintf(`"group" = $%d`, i))
-        args = append(args, *filter.Group)
-        i++
-    }
-    if filter.Text != nil {
-        whereClauses = append(whereClauses, fmt.Sprintf(`"text" = $%d`, i))
-        args = append(args, *filter.Text)
-        i++
-    }
-    if filter.Link != nil {
-        whereClauses = append(whereClauses, fmt.Sprintf(`"link" = $%d`, i))
-        args = append(args, *filter.Link)
-        i++
+// LatestMigration returns version of the latest migration of the path
+func LatestMigration(path string) (int64, error) {
+    migrations, err := goose.CollectMigrations(path, 0, goose.MaxVersion)
+    if err != nil {
+        return 0, err
     }
-    if filter.ReleaseDate != nil {
-        whereClauses = append(whereClauses, fmt.Sprintf(`"release_date" = $%d`, i))
-        args = append(args, *filter.ReleaseDate)
-        i++
+    latest, err := migrations.Last()
+    if err != nil {
+        return 0, err
     }
+    return latest.Version, nil
+}
 
-    if len(whereClauses) > 0 {
-        query += " WHERE " + strings.Join(whereClauses, " AND ")
-    }
+// MigrationVersion returns version of the database. Like
+// Ping it is a probe, failures are logged by the caller.
+func (s *PostgresStore) MigrationVersion(ctx context.Context) (int64, error) {
+    return goose.GetDBVersionContext(ctx, s.db.DB)
+}
+
+func (s *PostgresStore) GetSongs(ctx context.Context, filter types.GetSongs, offset, limit int) ([]types.Song, error) {
+    ctx, entry := s.begin(ctx, "get songs")
 
-    query += fmt.Sprintf(" OFFSET $%d LIMIT $%d", i, i+1)
+    query := `SELECT ` + songColumns + ` FROM song`
+
+    where, args := songFilter(filter)
+    query += where
+
+    query += fmt.Sprintf(" OFFSET $%d LIMIT $%d", len(args)+1, len(args)+2)
     args = append(args, offset, limit)
 
-    rows, err := s.db.Query(query, args...)
+    rows, err := s.db.QueryContext(ctx, query, args...)
     if err != nil {
-        entry.Error("Get songs query failed",
+        entry.ErrorContext(ctx, "Get songs query failed",
             slog.String("query", query),
             slog.Any("error", err),
         )
         return nil, err
     }
 
-    entry.Debug("Get songs query completed successfully")
+    entry.DebugContext(ctx, "Get songs query completed successfully")
 
     var songs []types.Song
     for rows.Next() {
         var song types.Song
-        if err := rows.Scan(
-            &song.Id,
-            &song.Song,
-            &song.Group,
-            &song.Text,
-            &song.Link,
-            &song.ReleaseDate,
-        ); err != nil {
-            entry.Error("Failed to scan song",
+        if err := scanSong(rows, &song); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan song",
                 slog.Group("song",
                     slog.Int("id", song.Id),
                     slog.String("song", song.Song),
@@ -169,65 +282,396 @@ func (s *PostgresStore) GetSongs(filter types.GetSongs, offset, limit int) ([]ty
         }
         songs = append(songs, song)
     }
-    entry.Debug("Songs scanned successfully")
-    entry.Info("Got songs successfully")
+    entry.DebugContext(ctx, "Songs scanned successfully")
+    entry.InfoContext(ctx, "Got songs successfully")
+
+    return songs, nil
+}
+
+// exportBatchSize is the number of songs read by ForEachSong at once
+const exportBatchSize = 500
+
+// ForEachSong calls fn for every song matching the filter in order
+// of ids. Songs are read in batches, so no query is kept open
+// while fn is running. Iteration stops on the first fn error.
+func (s *PostgresStore) ForEachSong(ctx context.Context, filter types.GetSongs, fn func(types.Song) error) error {
+    ctx, entry := s.begin(ctx, "for each song")
+
+    where, args := songFilter(filter)
+    if where == "" {
+        where = " WHERE "
+    } else {
+        where += " AND "
+    }
+    query := `SELECT ` + songColumns + ` FROM song` + where +
+        fmt.Sprintf(`"id" > $%d ORDER BY id LIMIT $%d`, len(args)+1, len(args)+2)
+
+    lastId, count := 0, 0
+    for {
+        rows, err := s.db.QueryContext(ctx, query, append(args, lastId, exportBatchSize)...)
+        if err != nil {
+            entry.ErrorContext(ctx, "For each song query failed",
+                slog.String("query", query),
+                slog.Any("error", err),
+            )
+            return err
+        }
+
+        var songs []types.Song
+        for rows.Next() {
+            var song types.Song
+            if err := scanSong(rows, &song); err != nil {
+                rows.Close()
+                entry.ErrorContext(ctx, "Failed to scan song", slog.Any("error", err))
+                return err
+            }
+            songs = append(songs, song)
+        }
+        rows.Close()
+        if err := rows.Err(); err != nil {
+            entry.ErrorContext(ctx, "Failed to read songs", slog.Any("error", err))
+            return err
+        }
+
+        for _, song := range songs {
+            if err := fn(song); err != nil {
+                return err
+            }
+        }
+        count += len(songs)
+
+        if len(songs) < exportBatchSize {
+            break
+        }
+        lastId = songs[len(songs)-1].Id
+    }
+
+    entry.DebugContext(ctx, "Songs iterated successfully", slog.Int("count", count))
+
+    return nil
+}
+
+func (s *PostgresStore) GetSong(ctx context.Context, id int) (types.Song, error) {
+    ctx, entry := s.begin(ctx, "get song")
+
+    query := `SELECT ` + songColumns + ` FROM song WHERE id = $1;`
+
+    var song types.Song
+    if err := scanSong(s.db.QueryRowContext(ctx, query, id), &song); err != nil {
+        entry.ErrorContext(ctx, "Failed to get song",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return types.Song{}, err
+    }
+    entry.InfoContext(ctx, "Got song successfully", slog.Int("id", id))
+
+    return song, nil
+}
+
+// FindSong returns the song with the group and name compared
+// the way the unique index does, sql.ErrNoRows if there is none
+func (s *PostgresStore) FindSong(ctx context.Context, group, name string) (types.Song, error) {
+    ctx, entry := s.begin(ctx, "find song")
+
+    query := `SELECT ` + songColumns + ` FROM song
+            WHERE lower(btrim("group")) = lower(btrim($1)) AND lower(btrim("name")) = lower(btrim($2));`
+
+    var song types.Song
+    if err := scanSong(s.db.QueryRowContext(ctx, query, group, name), &song); err != nil {
+        if !errors.Is(err, sql.ErrNoRows) {
+            entry.ErrorContext(ctx, "Failed to find song",
+                slog.String("query", query),
+                slog.Any("error", err),
+            )
+        }
+        return types.Song{}, err
+    }
+    entry.DebugContext(ctx, "Found song successfully", slog.Int("id", song.Id))
+
+    return song, nil
+}
+
+// getSong reads the song with q, locked until the end of the
+// transaction if lock is set, sql.ErrNoRows if there is none
+func getSong(ctx context.Context, q queryRower, entry *slog.Logger, id int, lock bool) (types.Song, error) {
+    query := `SELECT ` + songColumns + ` FROM song WHERE id = $1`
+    if lock {
+        query += ` FOR UPDATE`
+    }
+
+    var song types.Song
+    if err := scanSong(q.QueryRowContext(ctx, query, id), &song); err != nil {
+        if !errors.Is(err, sql.ErrNoRows) {
+            entry.ErrorContext(ctx, "Failed to get song",
+                slog.String("query", query),
+                slog.Any("error", err),
+            )
+        }
+        return types.Song{}, err
+    }
+
+    return song, nil
+}
+
+// getSongs reads the songs of ids in the transaction by id
+func getSongs(ctx context.Context, tx *instrumentedTx, entry *slog.Logger, ids []int) (map[int]types.Song, error) {
+    songs := make(map[int]types.Song, len(ids))
+    if len(ids) == 0 {
+        return songs, nil
+    }
+
+    query := `SELECT ` + songColumns + ` FROM song WHERE id = ANY($1);`
+
+    rows, err := tx.QueryContext(ctx, query, intArray(ids))
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to get songs",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var song types.Song
+        if err := scanSong(rows, &song); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan song", slog.Any("error", err))
+            return nil, err
+        }
+        songs[song.Id] = song
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to read songs", slog.Any("error", err))
+        return nil, err
+    }
 
     return songs, nil
 }
 
-func (s *PostgresStore) GetSongText(id int) (string, error) {
-    entry := s.log.With(slog.String("method", "get song text"))
+func (s *PostgresStore) GetSongText(ctx context.Context, id int) (string, error) {
+    ctx, entry := s.begin(ctx, "get song text")
 
     query := `SELECT text FROM song WHERE id = $1;`
-    row := s.db.QueryRow(query, id)
+    row := s.db.QueryRowContext(ctx, query, id)
 
     var text string
     if err := row.Scan(&text); err != nil {
-        entry.Error("Failed to get song text",
+        entry.ErrorContext(ctx, "Failed to get song text",
             slog.String("query", query),
             slog.Any("error", err),
         )
         return "", err
     }
-    entry.Info("Got song text successfully")
+    entry.InfoContext(ctx, "Got song text successfully")
 
     return text, nil
 }
 
-func (s *PostgresStore) CreateSong(song types.CreateSong) (int, error) {
-    entry := s.log.With(slog.String("method", "create song"))
-    var id int
+// GetSongSyncedLyrics returns LRC document of the song.
+// Returns empty string if song has no synced lyrics.
+func (s *PostgresStore) GetSongSyncedLyrics(ctx context.Context, id int) (string, error) {
+    ctx, entry := s.begin(ctx, "get song synced lyrics")
+
+    query := `SELECT coalesce("synced_lyrics", '') FROM song WHERE id = $1;`
+    row := s.db.QueryRowContext(ctx, query, id)
+
+    var lyrics string
+    if err := row.Scan(&lyrics); err != nil {
+        entry.ErrorContext(ctx, "Failed to get song synced lyrics",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return "", err
+    }
+    entry.InfoContext(ctx, "Got song synced lyrics successfully")
+
+    return lyrics, nil
+}
+
+// GetSongChordSheet returns ChordPro document of the song.
+// Returns empty string if song has no chord sheet.
+func (s *PostgresStore) GetSongChordSheet(ctx context.Context, id int) (string, error) {
+    ctx, entry := s.begin(ctx, "get song chord sheet")
+
+    query := `SELECT coalesce("chord_sheet", '') FROM song WHERE id = $1;`
+    row := s.db.QueryRowContext(ctx, query, id)
+
+    var sheet string
+    if err := row.Scan(&sheet); err != nil {
+        entry.ErrorContext(ctx, "Failed to get song chord sheet",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return "", err
+    }
+    entry.InfoContext(ctx, "Got song chord sheet successfully")
+
+    return sheet, nil
+}
+
+// CreateSong inserts the song. If the song with the same
+// group and name (case-insensitive) exists, it is resolved
+// according to onConflict: ErrConflict is returned, existing
+// song is kept, or existing song details are updated.
+// Created and updated songs are audited in the same transaction.
+func (s *PostgresStore) CreateSong(ctx context.Context, song types.CreateSong, onConflict types.OnConflict) (types.CreateSongResult, error) {
+    ctx, entry := s.begin(ctx, "create song")
+
+    tx, err := s.db.BeginTx(ctx, nil)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to begin transaction", slog.Any("error", err))
+        return types.CreateSongResult{}, err
+    }
+    defer tx.Rollback()
+
+    result, err := s.createSong(ctx, tx, entry, song, onConflict)
+    if err != nil {
+        return types.CreateSongResult{}, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        entry.ErrorContext(ctx, "Failed to commit transaction", slog.Any("error", err))
+        return types.CreateSongResult{}, err
+    }
+
+    return result, nil
+}
+
+// CreateSongs inserts all the songs in a single transaction.
+// If any song fails, nothing is inserted and results
+// of the songs before the failed one are returned with the error.
+func (s *PostgresStore) CreateSongs(ctx context.Context, songs []types.CreateSong, onConflict types.OnConflict) ([]types.CreateSongResult, error) {
+    ctx, entry := s.begin(ctx, "create songs")
+
+    tx, err := s.db.BeginTx(ctx, nil)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to begin transaction", slog.Any("error", err))
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    results := make([]types.CreateSongResult, 0, len(songs))
+    for _, song := range songs {
+        result, err := s.createSong(ctx, tx, entry, song, onConflict)
+        if err != nil {
+            return results, err
+        }
+        results = append(results, result)
+    }
+
+    if err := tx.Commit(); err != nil {
+        entry.ErrorContext(ctx, "Failed to commit transaction", slog.Any("error", err))
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Songs successfully created", slog.Int("count", len(results)))
+
+    return results, nil
+}
+
+func (s *PostgresStore) createSong(ctx context.Context, tx *instrumentedTx, entry *slog.Logger, song types.CreateSong, onConflict types.OnConflict) (types.CreateSongResult, error) {
+    // Existing song is locked, so its update
+    // is audited with the state it replaces
+    var before *types.Song
+    if onConflict == types.OnConflictUpdate {
+        query := `SELECT ` + songColumns + ` FROM song
+            WHERE lower(btrim("group")) = lower(btrim($1)) AND lower(btrim("name")) = lower(btrim($2))
+            FOR UPDATE;`
+
+        var existing types.Song
+        err := scanSong(tx.QueryRowContext(ctx, query, song.Group, song.Song), &existing)
+        if err != nil && !errors.Is(err, sql.ErrNoRows) {
+            entry.ErrorContext(ctx, "Failed to find existing song",
+                slog.String("query", query),
+                slog.Any("error", err),
+            )
+            return types.CreateSongResult{}, err
+        }
+        if err == nil {
+            before = &existing
+        }
+    }
 
     query := `
-            INSERT INTO song ("name", "group", "text", "link", "release_date")
-            VALUES ($1, $2, $3, $4, $5)
-            RETURNING id;
-        `
+            INSERT INTO song (
+                "name", "group", "text", "link", "release_date",
+                "language", "language_confidence", "explicit", "explicit_verses",
+                "word_count", "unique_words", "verse_count", "avg_verse_length", "word_freq"
+            )
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+
+    switch onConflict {
+    case types.OnConflictIgnore:
+        // Updating the row to itself makes RETURNING return existing id
+        query += onConflictKey + `
+            DO UPDATE SET "id" = song."id"`
+    case types.OnConflictUpdate:
+        query += onConflictKey + onConflictUpdate
+    }
 
-    err := s.db.QueryRow(
+    // Row inserted by this statement has zero xmax
+    query += `
+            RETURNING id, (xmax = 0);`
+
+    var result types.CreateSongResult
+    var inserted bool
+    err := tx.QueryRowContext(ctx,
         query,
         song.Song,
         song.Group,
         song.Text,
         song.Link,
         song.ReleaseDate,
-    ).Scan(&id)
+        song.Analysis.Language,
+        song.Analysis.LanguageConfidence,
+        song.Analysis.Explicit,
+        intArray(song.Analysis.ExplicitVerses),
+        song.Analysis.Stats.WordCount,
+        song.Analysis.Stats.UniqueWords,
+        song.Analysis.Stats.VerseCount,
+        song.Analysis.Stats.AvgVerseLength,
+        wordFrequency(song.Analysis.Stats.WordFrequency),
+    ).Scan(&result.Id, &inserted)
 
     if err != nil {
-        entry.Error("Failed to create song",
+        var pqErr *pq.Error
+        if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+            err = ErrConflict
+        }
+        entry.ErrorContext(ctx, "Failed to create song",
             slog.String("query", query),
             slog.Any("error", err),
         )
-        return -1, err
+        return types.CreateSongResult{}, err
     }
 
-    entry.Info("Song successfully created")
+    switch {
+    case inserted:
+        result.Status = types.SongCreated
+        err = auditSong(ctx, tx, entry, types.AuditCreate, result.Id, nil)
+    case onConflict == types.OnConflictUpdate:
+        result.Status = types.SongUpdated
+        err = auditSong(ctx, tx, entry, types.AuditUpdate, result.Id, before)
+    default:
+        result.Status = types.SongExisting
+    }
+    if err != nil {
+        return types.CreateSongResult{}, err
+    }
+
+    entry.InfoContext(ctx, "Song successfully created",
+        slog.Int("id", result.Id),
+        slog.String("status", result.Status),
+    )
 
-    return id, nil
+    return result, nil
 }
 
-func (s *PostgresStore) UpdateSong(id int, song types.UpdateSong) error {
-    entry := s.log.With(slog.String("method", "update song"))
+// UpdateSong updates the given fields of the song and records
+// the change as the audit event of the action in the same transaction
+func (s *PostgresStore) UpdateSong(ctx context.Context, id int, song types.UpdateSong, action string) error {
+    ctx, entry := s.begin(ctx, "update song")
     updates := make(map[string]interface{})
 
     if song.Song != nil {
@@ -245,15 +689,32 @@ func (s *PostgresStore) UpdateSong(id int, song types.UpdateSong) error {
     if song.ReleaseDate != nil {
         updates["release_date"] = *song.ReleaseDate
     }
+    if song.SyncedLyrics != nil {
+        updates["synced_lyrics"] = nullString(*song.SyncedLyrics)
+    }
+    if song.ChordSheet != nil {
+        updates["chord_sheet"] = nullString(*song.ChordSheet)
+    }
+    if song.Analysis != nil {
+        updates["language"] = song.Analysis.Language
+        updates["language_confidence"] = song.Analysis.LanguageConfidence
+        updates["explicit"] = song.Analysis.Explicit
+        updates["explicit_verses"] = intArray(song.Analysis.ExplicitVerses)
+        updates["word_count"] = song.Analysis.Stats.WordCount
+        updates["unique_words"] = song.Analysis.Stats.UniqueWords
+        updates["verse_count"] = song.Analysis.Stats.VerseCount
+        updates["avg_verse_length"] = song.Analysis.Stats.AvgVerseLength
+        updates["word_freq"] = wordFrequency(song.Analysis.Stats.WordFrequency)
+    }
 
     if len(updates) == 0 {
         err := fmt.Errorf("no fields to update")
-        entry.Error("Failed to update song",
+        entry.ErrorContext(ctx, "Failed to update song",
             slog.Any("error", err),
         )
         return err
     }
-    entry.Debug("Updates len greater than 0")
+    entry.DebugContext(ctx, "Updates len greater than 0")
 
     var fields []string
     var args []interface{}
@@ -268,36 +729,288 @@ func (s *PostgresStore) UpdateSong(id int, song types.UpdateSong) error {
     args = append(args, id)
     query := fmt.Sprintf("UPDATE song SET %s WHERE id = $%d", strings.Join(fields, ", "), counter)
 
-    if _, err := s.db.Exec(query, args...); err != nil {
-        entry.Error("Failed to update song",
+    tx, err := s.db.BeginTx(ctx, nil)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to begin transaction", slog.Any("error", err))
+        return err
+    }
+    defer tx.Rollback()
+
+    before, err := getSong(ctx, tx, entry, id, true)
+    if errors.Is(err, sql.ErrNoRows) {
+        entry.ErrorContext(ctx, "Song not found", slog.Int("id", id), slog.Any("error", err))
+    }
+    if err != nil {
+        return err
+    }
+
+    if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+        // Renamed into the group and name of another song
+        var pqErr *pq.Error
+        if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+            err = ErrConflict
+        }
+        entry.ErrorContext(ctx, "Failed to update song",
             slog.String("query", query),
             slog.Any("error", err),
         )
         return err
     }
 
-    entry.Info("Song updated successfully", slog.Int("id", id))
+    if err := auditSong(ctx, tx, entry, action, id, &before); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        entry.ErrorContext(ctx, "Failed to commit transaction", slog.Any("error", err))
+        return err
+    }
+
+    entry.InfoContext(ctx, "Song updated successfully", slog.Int("id", id))
 
     return nil
 }
 
-func (s *PostgresStore) DeleteSong(id int) error {
-    entry := s.log.With(slog.String("method", "delete song"))
+// SetSongExplicitOverride sets manual explicit flag of the song
+// that takes precedence over the scanned one. Nil resets override.
+// The change is audited in the same transaction.
+func (s *PostgresStore) SetSongExplicitOverride(ctx context.Context, id int, explicit *bool) error {
+    ctx, entry := s.begin(ctx, "set song explicit override")
+
+    tx, err := s.db.BeginTx(ctx, nil)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to begin transaction", slog.Any("error", err))
+        return err
+    }
+    defer tx.Rollback()
+
+    before, err := getSong(ctx, tx, entry, id, true)
+    if errors.Is(err, sql.ErrNoRows) {
+        entry.ErrorContext(ctx, "Song not found", slog.Int("id", id), slog.Any("error", err))
+    }
+    if err != nil {
+        return err
+    }
+
+    query := `UPDATE song SET "explicit_override" = $1 WHERE id = $2;`
+
+    if _, err := tx.ExecContext(ctx, query, explicit, id); err != nil {
+        entry.ErrorContext(ctx, "Failed to set song explicit override",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return err
+    }
+
+    if err := auditSong(ctx, tx, entry, types.AuditUpdate, id, &before); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        entry.ErrorContext(ctx, "Failed to commit transaction", slog.Any("error", err))
+        return err
+    }
+
+    entry.InfoContext(ctx, "Song explicit override set successfully", slog.Int("id", id))
+
+    return nil
+}
+
+// MergeSongs folds the source song into the target one in transaction:
+// fills empty fields of the target, moves translations
+// the target lacks, tags and playlist entries and deletes the source.
+// Update of the target and deletion of the source are audited.
+// If the text of the target is taken from the source, its analysis
+// is replaced by the analysis of the text computed by analyze,
+// which is returned then, nil otherwise.
+func (s *PostgresStore) MergeSongs(ctx context.Context, targetId, sourceId int, analyze func(text string) types.SongAnalysis) (*types.SongAnalysis, error) {
+    ctx, entry := s.begin(ctx, "merge songs")
+
+    tx, err := s.db.BeginTx(ctx, nil)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to begin transaction", slog.Any("error", err))
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    // Both songs must exist, otherwise nothing is merged.
+    // They are locked in the order of ids, so merges do not deadlock.
+    before := map[int]*types.Song{}
+    for _, id := range []int{min(targetId, sourceId), max(targetId, sourceId)} {
+        song, err := getSong(ctx, tx, entry, id, true)
+        if errors.Is(err, sql.ErrNoRows) {
+            entry.ErrorContext(ctx, "Songs to merge not found", slog.Int("id", id), slog.Any("error", err))
+        }
+        if err != nil {
+            return nil, err
+        }
+        before[id] = &song
+    }
+
+    queries := []string{
+        `UPDATE song t SET
+                "text" = CASE WHEN t."text" = '' THEN s."text" ELSE t."text" END,
+                "link" = CASE WHEN t."link" = '' THEN s."link" ELSE t."link" END,
+                "synced_lyrics" = coalesce(t."synced_lyrics", s."synced_lyrics"),
+                "chord_sheet" = coalesce(t."chord_sheet", s."chord_sheet"),
+                "explicit_override" = coalesce(t."explicit_override", s."explicit_override")
+            FROM song s
+            WHERE t.id = $1 AND s.id = $2;`,
+        `INSERT INTO song_translation ("song_id", "lang", "text")
+            SELECT $1, "lang", "text" FROM song_translation WHERE "song_id" = $2
+            ON CONFLICT ("song_id", "lang") DO NOTHING;`,
+        `INSERT INTO song_tag ("song_id", "tag_id")
+            SELECT $1, "tag_id" FROM song_tag WHERE "song_id" = $2
+            ON CONFLICT DO NOTHING;`,
+        `UPDATE playlist_entry SET "song_id" = $1 WHERE "song_id" = $2;`,
+        `DELETE FROM song WHERE id = $2;`,
+    }
+
+    for _, query := range queries {
+        if _, err := tx.ExecContext(ctx, query, targetId, sourceId); err != nil {
+            entry.ErrorContext(ctx, "Failed to merge songs",
+                slog.String("query", query),
+                slog.Any("error", err),
+            )
+            return nil, err
+        }
+    }
+
+    // Statistics of the text taken from the source
+    // are computed for the target in the same transaction
+    var analysis *types.SongAnalysis
+    if before[targetId].Text == "" {
+        a := analyze(before[sourceId].Text)
+        analysis = &a
+
+        query := `
+            UPDATE song SET
+                "language" = $2,
+                "language_confidence" = $3,
+                "explicit" = $4,
+                "explicit_verses" = $5,
+                "word_count" = $6,
+                "unique_words" = $7,
+                "verse_count" = $8,
+                "avg_verse_length" = $9,
+                "word_freq" = $10
+            WHERE id = $1;`
+
+        if _, err := tx.ExecContext(ctx, query,
+            targetId,
+            a.Language,
+            a.LanguageConfidence,
+            a.Explicit,
+            intArray(a.ExplicitVerses),
+            a.Stats.WordCount,
+            a.Stats.UniqueWords,
+            a.Stats.VerseCount,
+            a.Stats.AvgVerseLength,
+            wordFrequency(a.Stats.WordFrequency),
+        ); err != nil {
+            entry.ErrorContext(ctx, "Failed to update merged song analysis",
+                slog.String("query", query),
+                slog.Any("error", err),
+            )
+            return nil, err
+        }
+    }
+
+    if err := auditSong(ctx, tx, entry, types.AuditUpdate, targetId, before[targetId]); err != nil {
+        return nil, err
+    }
+    if err := auditSong(ctx, tx, entry, types.AuditDelete, sourceId, before[sourceId]); err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        entry.ErrorContext(ctx, "Failed to commit transaction", slog.Any("error", err))
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Songs merged successfully",
+        slog.Int("target_id", targetId),
+        slog.Int("source_id", sourceId),
+    )
+
+    return analysis, nil
+}
+
+// DeleteSong deletes the song with its playlist entries removed,
+// or flagged as missing, and audits it in the same transaction.
+// Deleting the missing song does nothing.
+func (s *PostgresStore) DeleteSong(ctx context.Context, id int, removeEntries bool) error {
+    ctx, entry := s.begin(ctx, "delete song")
+
+    tx, err := s.db.BeginTx(ctx, nil)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to begin transaction", slog.Any("error", err))
+        return err
+    }
+    defer tx.Rollback()
+
+    before, err := getSong(ctx, tx, entry, id, true)
+    if errors.Is(err, sql.ErrNoRows) {
+        entry.DebugContext(ctx, "Song to delete not found", slog.Int("id", id))
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+
+    if err := detachSongFromPlaylists(ctx, tx, entry, id, removeEntries); err != nil {
+        return err
+    }
 
     query := `DELETE FROM song WHERE id = $1;`
 
-    if _, err := s.db.Exec(
+    if _, err := tx.ExecContext(ctx,
         query,
         id,
     ); err != nil {
-        entry.Error("Failed to delete song",
+        entry.ErrorContext(ctx, "Failed to delete song",
             slog.String("query", query),
             slog.Any("error", err),
         )
         return err
     }
 
-    slog.Debug("Song deleted successfully", slog.Int("id", id))
+    if err := auditSong(ctx, tx, entry, types.AuditDelete, id, &before); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        entry.ErrorContext(ctx, "Failed to commit transaction", slog.Any("error", err))
+        return err
+    }
+
+    entry.DebugContext(ctx, "Song deleted successfully", slog.Int("id", id))
 
     return nil
 }
+
+// intArray converts ints to postgres integer array
+func intArray(ints []int) pq.Int64Array {
+    arr := make(pq.Int64Array, len(ints))
+    for i, n := range ints {
+        arr[i] = int64(n)
+    }
+    return arr
+}
+
+// nullString stores empty string as null
+func nullString(s string) any {
+    if s == "" {
+        return nil
+    }
+    return s
+}
+
+// wordFrequency encodes word frequencies to jsonb
+func wordFrequency(freq map[string]int) string {
+    if freq == nil {
+        return "{}"
+    }
+    b, _ := json.Marshal(freq)
+    return string(b)
+}
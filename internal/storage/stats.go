@@ -0,0 +1,286 @@
+package storage
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+)
+
+// GetLibraryStats returns summary statistics
+// of the songs that match the filter
+func (s *PostgresStore) GetLibraryStats(ctx context.Context, filter types.GetSongs) (types.LibraryStats, error) {
+    ctx, entry := s.begin(ctx, "get library stats")
+
+    where, args := songFilter(filter)
+    query := `
+            SELECT
+                count(*),
+                coalesce(sum("word_count"), 0),
+                coalesce(avg("word_count"), 0),
+                coalesce(avg("avg_verse_length"), 0),
+                (
+                    SELECT count(DISTINCT "key")
+                    FROM song, jsonb_each("word_freq")` + where + `
+                )
+            FROM song` + where + `;`
+
+    var stats types.LibraryStats
+    if err := s.db.QueryRowContext(ctx, query, args...).Scan(
+        &stats.Songs,
+        &stats.Words,
+        &stats.AvgWordsPerSong,
+        &stats.AvgVerseLength,
+        &stats.UniqueWords,
+    ); err != nil {
+        entry.ErrorContext(ctx, "Failed to get library stats",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return types.LibraryStats{}, err
+    }
+
+    entry.InfoContext(ctx, "Got library stats successfully")
+
+    return stats, nil
+}
+
+// GetSongStats returns statistics of the songs that match the filter
+func (s *PostgresStore) GetSongStats(ctx context.Context, filter types.GetSongs, offset, limit int) ([]types.SongStatsEntry, error) {
+    ctx, entry := s.begin(ctx, "get song stats")
+
+    where, args := songFilter(filter)
+    query := `
+            SELECT "id", "name", "group",
+                coalesce("word_count", 0), coalesce("unique_words", 0),
+                coalesce("verse_count", 0), coalesce("avg_verse_length", 0)
+            FROM song` + where +
+        fmt.Sprintf(" ORDER BY id OFFSET $%d LIMIT $%d;", len(args)+1, len(args)+2)
+    args = append(args, offset, limit)
+
+    rows, err := s.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        entry.ErrorContext(ctx, "Get song stats query failed",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+    defer rows.Close()
+
+    var stats []types.SongStatsEntry
+    for rows.Next() {
+        var e types.SongStatsEntry
+        if err := rows.Scan(
+            &e.Id,
+            &e.Song,
+            &e.Group,
+            &e.WordCount,
+            &e.UniqueWords,
+            &e.VerseCount,
+            &e.AvgVerseLength,
+        ); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan song stats", slog.Any("error", err))
+            return nil, err
+        }
+        stats = append(stats, e)
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to iterate song stats", slog.Any("error", err))
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Got song stats successfully")
+
+    return stats, nil
+}
+
+// GetTopWords returns n most used words of every artist
+// among the songs that match the filter
+func (s *PostgresStore) GetTopWords(ctx context.Context, filter types.GetSongs, n int) ([]types.ArtistTopWords, error) {
+    ctx, entry := s.begin(ctx, "get top words")
+
+    where, args := songFilter(filter)
+    query := `
+            WITH words AS (
+                SELECT "group", "key" AS word, sum("value"::integer) AS count
+                FROM song, jsonb_each_text("word_freq")` + where + `
+                GROUP BY "group", "key"
+            ), ranked AS (
+                SELECT *, row_number() OVER (PARTITION BY "group" ORDER BY count DESC, word) AS rank
+                FROM words
+            )
+            SELECT "group", word, count FROM ranked` +
+        fmt.Sprintf(" WHERE rank <= $%d ORDER BY \"group\", rank;", len(args)+1)
+    args = append(args, n)
+
+    rows, err := s.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        entry.ErrorContext(ctx, "Get top words query failed",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+    defer rows.Close()
+
+    var artists []types.ArtistTopWords
+    for rows.Next() {
+        var group string
+        var word types.WordCount
+        if err := rows.Scan(&group, &word.Word, &word.Count); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan top word", slog.Any("error", err))
+            return nil, err
+        }
+        // Rows are ordered by group so words of the artist are adjacent
+        if len(artists) == 0 || artists[len(artists)-1].Group != group {
+            artists = append(artists, types.ArtistTopWords{Group: group})
+        }
+        last := &artists[len(artists)-1]
+        last.Words = append(last.Words, word)
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to iterate top words", slog.Any("error", err))
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Got top words successfully")
+
+    return artists, nil
+}
+
+// GetVocabularyGrowth returns number of words first used
+// in every release year among the songs that match the filter
+func (s *PostgresStore) GetVocabularyGrowth(ctx context.Context, filter types.GetSongs) ([]types.VocabularyYear, error) {
+    ctx, entry := s.begin(ctx, "get vocabulary growth")
+
+    where, args := songFilter(filter)
+    query := `
+            WITH first_use AS (
+                SELECT "key" AS word, min(extract(year FROM "release_date"))::integer AS year
+                FROM song, jsonb_each("word_freq")` + where + `
+                GROUP BY "key"
+            )
+            SELECT year, count(*), sum(count(*)) OVER (ORDER BY year)
+            FROM first_use
+            GROUP BY year
+            ORDER BY year;`
+
+    rows, err := s.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        entry.ErrorContext(ctx, "Get vocabulary growth query failed",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+    defer rows.Close()
+
+    var years []types.VocabularyYear
+    for rows.Next() {
+        var year types.VocabularyYear
+        if err := rows.Scan(&year.Year, &year.NewWords, &year.Total); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan vocabulary year", slog.Any("error", err))
+            return nil, err
+        }
+        years = append(years, year)
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to iterate vocabulary years", slog.Any("error", err))
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Got vocabulary growth successfully")
+
+    return years, nil
+}
+
+// GetSongsWithoutStats returns up to limit songs
+// which statistics were never computed
+func (s *PostgresStore) GetSongsWithoutStats(ctx context.Context, limit int) ([]types.Song, error) {
+    ctx, entry := s.begin(ctx, "get songs without stats")
+
+    query := `SELECT ` + songColumns + ` FROM song WHERE "word_freq" IS NULL ORDER BY id LIMIT $1;`
+
+    rows, err := s.db.QueryContext(ctx, query, limit)
+    if err != nil {
+        entry.ErrorContext(ctx, "Get songs without stats query failed",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+    defer rows.Close()
+
+    var songs []types.Song
+    for rows.Next() {
+        var song types.Song
+        if err := scanSong(rows, &song); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan song", slog.Any("error", err))
+            return nil, err
+        }
+        songs = append(songs, song)
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to iterate songs", slog.Any("error", err))
+        return nil, err
+    }
+
+    entry.DebugContext(ctx, "Got songs without stats successfully", slog.Int("count", len(songs)))
+
+    return songs, nil
+}
+
+// GetSongWords returns word frequencies of all songs
+func (s *PostgresStore) GetSongWords(ctx context.Context) ([]types.SongWords, error) {
+    ctx, entry := s.begin(ctx, "get song words")
+
+    query := `
+            SELECT "id", "name", "group", "language", "release_date", coalesce("word_freq", '{}')
+            FROM song ORDER BY id;
+        `
+
+    rows, err := s.db.QueryContext(ctx, query)
+    if err != nil {
+        entry.ErrorContext(ctx, "Get song words query failed",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+    defer rows.Close()
+
+    var songs []types.SongWords
+    for rows.Next() {
+        var song types.SongWords
+        var freq []byte
+        if err := rows.Scan(
+            &song.Id,
+            &song.Song,
+            &song.Group,
+            &song.Language,
+            &song.ReleaseDate,
+            &freq,
+        ); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan song words", slog.Any("error", err))
+            return nil, err
+        }
+        if err := json.Unmarshal(freq, &song.WordFrequency); err != nil {
+            entry.ErrorContext(ctx, "Failed to unmarshal word frequency",
+                slog.Int("id", song.Id),
+                slog.Any("error", err),
+            )
+            return nil, err
+        }
+        songs = append(songs, song)
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to iterate song words", slog.Any("error", err))
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Got song words successfully", slog.Int("count", len(songs)))
+
+    return songs, nil
+}
@@ -1,15 +1,97 @@
 package storage
 
 import (
+    "context"
+    "errors"
     "github.com/vasch3nko/songlibrary/internal/types"
+    "time"
 )
 
+// ErrConflict is returned when created song has
+// the same group and name as the existing one
+var ErrConflict = errors.New("song with the same group and name already exists")
+
 // Storage is the interface that
 // describes a store of a data in API
 type Storage interface {
-    GetSongs(types.GetSongs, int, int) ([]types.Song, error)
-    GetSongText(int) (string, error)
-    CreateSong(types.CreateSong) (int, error)
-    UpdateSong(int, types.UpdateSong) error
-    DeleteSong(int) error
+    GetSongs(context.Context, types.GetSongs, int, int) ([]types.Song, error)
+    GetSong(context.Context, int) (types.Song, error)
+    FindSong(ctx context.Context, group, name string) (types.Song, error)
+    ForEachSong(context.Context, types.GetSongs, func(types.Song) error) error
+    GetSongText(context.Context, int) (string, error)
+    GetSongSyncedLyrics(context.Context, int) (string, error)
+    GetSongChordSheet(context.Context, int) (string, error)
+    CreateSong(context.Context, types.CreateSong, types.OnConflict) (types.CreateSongResult, error)
+    CreateSongs(context.Context, []types.CreateSong, types.OnConflict) ([]types.CreateSongResult, error)
+    ImportSongs(context.Context, []types.CreateSong, types.OnConflict) ([]string, error)
+    UpdateSong(context.Context, int, types.UpdateSong, string) error
+    DeleteSong(context.Context, int, bool) error
+    SetSongExplicitOverride(context.Context, int, *bool) error
+    GetSongsWithoutStats(context.Context, int) ([]types.Song, error)
+    GetSongWords(context.Context) ([]types.SongWords, error)
+    MergeSongs(context.Context, int, int, func(string) types.SongAnalysis) (*types.SongAnalysis, error)
+
+    GetSongTranslations(context.Context, int) ([]types.SongTranslation, error)
+    GetSongTranslation(context.Context, int, string) (types.SongTranslation, error)
+    SetSongTranslation(context.Context, types.SongTranslation) error
+    DeleteSongTranslation(context.Context, int, string) error
+}
+
+// StatsStorage is the interface that
+// describes a store of lyrics statistics
+type StatsStorage interface {
+    GetLibraryStats(context.Context, types.GetSongs) (types.LibraryStats, error)
+    GetSongStats(context.Context, types.GetSongs, int, int) ([]types.SongStatsEntry, error)
+    GetTopWords(context.Context, types.GetSongs, int) ([]types.ArtistTopWords, error)
+    GetVocabularyGrowth(context.Context, types.GetSongs) ([]types.VocabularyYear, error)
+}
+
+// PlaylistStorage is the interface that
+// describes a store of playlists
+type PlaylistStorage interface {
+    GetPlaylists(context.Context, int, int) ([]types.Playlist, error)
+    GetPlaylist(context.Context, int) (types.Playlist, error)
+    CreatePlaylist(context.Context, types.CreatePlaylist) (int, error)
+    UpdatePlaylist(context.Context, int, types.UpdatePlaylist) error
+    DeletePlaylist(context.Context, int) error
+    AddPlaylistEntries(context.Context, int, []int, int) error
+    RemovePlaylistEntry(context.Context, int, int) error
+    MovePlaylistEntry(context.Context, int, int, int) error
+    ReorderPlaylist(context.Context, int, []int) error
+    ImportPlaylist(context.Context, types.CreatePlaylist, []int) (int, error)
+    FindSong(context.Context, string, string) (types.Song, error)
+}
+
+// TagStorage is the interface that
+// describes a store of song tags
+type TagStorage interface {
+    GetTags(context.Context, string) ([]types.TagCount, error)
+    GetTagFacets(context.Context, types.GetSongs) (types.TagFacets, error)
+    GetSongTags(context.Context, int) ([]types.Tag, error)
+    TagSong(context.Context, int, []types.Tag) error
+    UntagSong(context.Context, int, types.Tag) error
+}
+
+// ApiKeyStorage is the interface that
+// describes a store of hashed API keys
+type ApiKeyStorage interface {
+    GetApiKeys(context.Context) ([]types.ApiKey, error)
+    GetApiKeyByHash(context.Context, string) (types.ApiKey, error)
+    CreateApiKey(context.Context, types.CreateApiKey, string, string) (types.ApiKey, error)
+    RevokeApiKey(context.Context, int) error
+    TouchApiKey(context.Context, int) error
+}
+
+// AuditStorage is the interface that
+// describes a store of song audit events
+type AuditStorage interface {
+    GetAuditEvents(context.Context, types.GetAuditEvents, int, int) ([]types.AuditEvent, error)
+    DeleteAuditEventsBefore(context.Context, time.Time) (int64, error)
+}
+
+// HealthStorage is the interface that
+// describes readiness checks of a store
+type HealthStorage interface {
+    Ping(context.Context) error
+    MigrationVersion(context.Context) (int64, error)
 }
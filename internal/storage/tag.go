@@ -0,0 +1,227 @@
+package storage
+
+import (
+    "context"
+    "github.com/lib/pq"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+)
+
+// GetTags returns tags of the kind with numbers
+// of their songs, kind may be empty for all tags
+func (s *PostgresStore) GetTags(ctx context.Context, kind string) ([]types.TagCount, error) {
+    ctx, entry := s.begin(ctx, "get tags")
+
+    query := `
+            SELECT t."kind", t."name", count(*)
+            FROM tag t JOIN song_tag st ON st."tag_id" = t."id"
+            WHERE $1 = '' OR t."kind" = $1
+            GROUP BY t."kind", t."name"
+            ORDER BY t."kind", t."name";`
+
+    rows, err := s.db.QueryContext(ctx, query, kind)
+    if err != nil {
+        entry.ErrorContext(ctx, "Get tags query failed",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+    defer rows.Close()
+
+    tags := []types.TagCount{}
+    for rows.Next() {
+        var tag types.TagCount
+        if err := rows.Scan(&tag.Tag.Kind, &tag.Tag.Name, &tag.Count); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan tag", slog.Any("error", err))
+            return nil, err
+        }
+        tags = append(tags, tag)
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to read tags", slog.Any("error", err))
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Got tags successfully")
+
+    return tags, nil
+}
+
+// GetTagFacets returns number of the songs that match the
+// filter and numbers of them with every tag by tag kind
+func (s *PostgresStore) GetTagFacets(ctx context.Context, filter types.GetSongs) (types.TagFacets, error) {
+    ctx, entry := s.begin(ctx, "get tag facets")
+
+    facets := types.TagFacets{Facets: map[string][]types.TagCount{
+        types.TagKindGenre: {},
+        types.TagKindMood:  {},
+        types.TagKindTag:   {},
+    }}
+
+    where, args := songFilter(filter)
+    query := `SELECT count(*) FROM song` + where + `;`
+
+    if err := s.db.QueryRowContext(ctx, query, args...).Scan(&facets.Songs); err != nil {
+        entry.ErrorContext(ctx, "Failed to count songs",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return types.TagFacets{}, err
+    }
+
+    query = `
+            SELECT t."kind", t."name", count(*)
+            FROM tag t JOIN song_tag st ON st."tag_id" = t."id"
+            WHERE st."song_id" IN (SELECT "id" FROM song` + where + `)
+            GROUP BY t."kind", t."name"
+            ORDER BY count(*) DESC, t."name";`
+
+    rows, err := s.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        entry.ErrorContext(ctx, "Get tag facets query failed",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return types.TagFacets{}, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var tag types.TagCount
+        if err := rows.Scan(&tag.Tag.Kind, &tag.Tag.Name, &tag.Count); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan tag facet", slog.Any("error", err))
+            return types.TagFacets{}, err
+        }
+        facets.Facets[tag.Tag.Kind] = append(facets.Facets[tag.Tag.Kind], tag)
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to read tag facets", slog.Any("error", err))
+        return types.TagFacets{}, err
+    }
+
+    entry.InfoContext(ctx, "Got tag facets successfully")
+
+    return facets, nil
+}
+
+func (s *PostgresStore) GetSongTags(ctx context.Context, songId int) ([]types.Tag, error) {
+    ctx, entry := s.begin(ctx, "get song tags")
+
+    query := `
+            SELECT t."kind", t."name"
+            FROM tag t JOIN song_tag st ON st."tag_id" = t."id"
+            WHERE st."song_id" = $1
+            ORDER BY t."kind", t."name";`
+
+    rows, err := s.db.QueryContext(ctx, query, songId)
+    if err != nil {
+        entry.ErrorContext(ctx, "Get song tags query failed",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+    defer rows.Close()
+
+    tags := []types.Tag{}
+    for rows.Next() {
+        var tag types.Tag
+        if err := rows.Scan(&tag.Kind, &tag.Name); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan song tag", slog.Any("error", err))
+            return nil, err
+        }
+        tags = append(tags, tag)
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to read song tags", slog.Any("error", err))
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Got song tags successfully", slog.Int("song_id", songId))
+
+    return tags, nil
+}
+
+// TagSong adds tags to the song, tags
+// that do not exist yet are created
+func (s *PostgresStore) TagSong(ctx context.Context, songId int, tags []types.Tag) error {
+    ctx, entry := s.begin(ctx, "tag song")
+
+    kinds, names, keys := make([]string, len(tags)), make([]string, len(tags)), make([]string, len(tags))
+    for i, tag := range tags {
+        kinds[i], names[i], keys[i] = tag.Kind, tag.Name, tag.Key()
+    }
+
+    tx, err := s.db.BeginTx(ctx, nil)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to begin transaction", slog.Any("error", err))
+        return err
+    }
+    defer tx.Rollback()
+
+    query := `
+            INSERT INTO tag ("kind", "name")
+            SELECT * FROM unnest($1::text[], $2::text[])
+            ON CONFLICT ("kind", "name") DO NOTHING;`
+
+    if _, err := tx.ExecContext(ctx, query, pq.Array(kinds), pq.Array(names)); err != nil {
+        entry.ErrorContext(ctx, "Failed to create tags",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return err
+    }
+
+    query = `
+            INSERT INTO song_tag ("song_id", "tag_id")
+            SELECT $1, "id" FROM tag WHERE "kind" || ':' || "name" = ANY($2)
+            ON CONFLICT DO NOTHING;`
+
+    if _, err := tx.ExecContext(ctx, query, songId, pq.Array(keys)); err != nil {
+        entry.ErrorContext(ctx, "Failed to tag song",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        entry.ErrorContext(ctx, "Failed to commit transaction", slog.Any("error", err))
+        return err
+    }
+
+    entry.InfoContext(ctx, "Song tagged successfully", slog.Int("song_id", songId), slog.Int("count", len(tags)))
+
+    return nil
+}
+
+// UntagSong removes the tag from the song,
+// the tag is deleted if no songs have it
+func (s *PostgresStore) UntagSong(ctx context.Context, songId int, tag types.Tag) error {
+    ctx, entry := s.begin(ctx, "untag song")
+
+    query := `
+            WITH removed AS (
+                DELETE FROM song_tag st USING tag t
+                WHERE st."tag_id" = t."id" AND st."song_id" = $1 AND t."kind" = $2 AND t."name" = $3
+                RETURNING st."tag_id"
+            )
+            DELETE FROM tag WHERE "id" IN (SELECT "tag_id" FROM removed)
+                AND NOT EXISTS (
+                    SELECT 1 FROM song_tag
+                    WHERE "tag_id" = tag."id" AND "song_id" <> $1
+                );`
+
+    if _, err := s.db.ExecContext(ctx, query, songId, tag.Kind, tag.Name); err != nil {
+        entry.ErrorContext(ctx, "Failed to untag song",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return err
+    }
+
+    entry.InfoContext(ctx, "Song untagged successfully", slog.Int("song_id", songId))
+
+    return nil
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+    "context"
+    "database/sql"
+    "github.com/vasch3nko/songlibrary/internal/types"
+    "log/slog"
+)
+
+func (s *PostgresStore) GetSongTranslations(ctx context.Context, songId int) ([]types.SongTranslation, error) {
+    ctx, entry := s.begin(ctx, "get song translations")
+
+    query := `SELECT "song_id", "lang", "text" FROM song_translation WHERE "song_id" = $1 ORDER BY "lang";`
+
+    rows, err := s.db.QueryContext(ctx, query, songId)
+    if err != nil {
+        entry.ErrorContext(ctx, "Get song translations query failed",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return nil, err
+    }
+    defer rows.Close()
+
+    var translations []types.SongTranslation
+    for rows.Next() {
+        var t types.SongTranslation
+        if err := rows.Scan(&t.SongId, &t.Lang, &t.Text); err != nil {
+            entry.ErrorContext(ctx, "Failed to scan song translation", slog.Any("error", err))
+            return nil, err
+        }
+        translations = append(translations, t)
+    }
+    if err := rows.Err(); err != nil {
+        entry.ErrorContext(ctx, "Failed to iterate song translations", slog.Any("error", err))
+        return nil, err
+    }
+
+    entry.InfoContext(ctx, "Got song translations successfully", slog.Int("song_id", songId))
+
+    return translations, nil
+}
+
+func (s *PostgresStore) GetSongTranslation(ctx context.Context, songId int, lang string) (types.SongTranslation, error) {
+    ctx, entry := s.begin(ctx, "get song translation")
+
+    query := `SELECT "song_id", "lang", "text" FROM song_translation WHERE "song_id" = $1 AND "lang" = $2;`
+
+    var t types.SongTranslation
+    if err := s.db.QueryRowContext(ctx, query, songId, lang).Scan(&t.SongId, &t.Lang, &t.Text); err != nil {
+        entry.ErrorContext(ctx, "Failed to get song translation",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return types.SongTranslation{}, err
+    }
+
+    entry.InfoContext(ctx, "Got song translation successfully",
+        slog.Int("song_id", songId),
+        slog.String("lang", lang),
+    )
+
+    return t, nil
+}
+
+// SetSongTranslation creates translation or replaces existing one
+func (s *PostgresStore) SetSongTranslation(ctx context.Context, t types.SongTranslation) error {
+    ctx, entry := s.begin(ctx, "set song translation")
+
+    query := `
+            INSERT INTO song_translation ("song_id", "lang", "text")
+            VALUES ($1, $2, $3)
+            ON CONFLICT ("song_id", "lang")
+            DO UPDATE SET "text" = excluded."text", "updated_at" = now();
+        `
+
+    if _, err := s.db.ExecContext(ctx, query, t.SongId, t.Lang, t.Text); err != nil {
+        entry.ErrorContext(ctx, "Failed to set song translation",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return err
+    }
+
+    entry.InfoContext(ctx, "Song translation set successfully",
+        slog.Int("song_id", t.SongId),
+        slog.String("lang", t.Lang),
+    )
+
+    return nil
+}
+
+func (s *PostgresStore) DeleteSongTranslation(ctx context.Context, songId int, lang string) error {
+    ctx, entry := s.begin(ctx, "delete song translation")
+
+    query := `DELETE FROM song_translation WHERE "song_id" = $1 AND "lang" = $2;`
+
+    res, err := s.db.ExecContext(ctx, query, songId, lang)
+    if err != nil {
+        entry.ErrorContext(ctx, "Failed to delete song translation",
+            slog.String("query", query),
+            slog.Any("error", err),
+        )
+        return err
+    }
+
+    if n, err := res.RowsAffected(); err == nil && n == 0 {
+        err := sql.ErrNoRows
+        entry.ErrorContext(ctx, "Song translation not found", slog.Any("error", err))
+        return err
+    }
+
+    entry.InfoContext(ctx, "Song translation deleted successfully",
+        slog.Int("song_id", songId),
+        slog.String("lang", lang),
+    )
+
+    return nil
+}
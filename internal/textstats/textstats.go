@@ -0,0 +1,134 @@
+package textstats
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "unicode"
+)
+
+// Stats is the word statistics of the song text
+type Stats struct {
+    WordCount int
+    // Distinct words excluding stop words, the words of Frequencies
+    UniqueWords int
+    VerseCount  int
+    // Average number of words per verse
+    AvgVerseLength float64
+    // Frequencies of the words excluding stop words
+    Frequencies map[string]int
+}
+
+// Words splits text to lowercase words.
+// Apostrophes inside words are the part of the word.
+func Words(text string) []string {
+    fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’'
+    })
+
+    words := fields[:0]
+    for _, field := range fields {
+        if word := strings.Trim(field, "'’"); word != "" {
+            words = append(words, word)
+        }
+    }
+    return words
+}
+
+// Compute counts words of the text. Verses are separated by blank lines.
+func Compute(text string, stopWords map[string]bool) Stats {
+    stats := Stats{Frequencies: map[string]int{}}
+
+    for _, verse := range strings.Split(text, "\n\n") {
+        words := Words(verse)
+        if len(words) == 0 {
+            continue
+        }
+        stats.VerseCount++
+        stats.WordCount += len(words)
+        for _, word := range words {
+            if !stopWords[word] {
+                stats.Frequencies[word]++
+            }
+        }
+    }
+
+    stats.UniqueWords = len(stats.Frequencies)
+    if stats.VerseCount > 0 {
+        stats.AvgVerseLength = float64(stats.WordCount) / float64(stats.VerseCount)
+    }
+
+    return stats
+}
+
+// StopWords is the stop word lists by language
+type StopWords map[string]map[string]bool
+
+// LoadStopWords loads stop word lists from <dir>/<language>.txt files.
+// Each line of the file is a word, lines starting with "#" are comments.
+func LoadStopWords(dir string) (StopWords, error) {
+    // Missing directory is the error rather than no lists,
+    // so a mistyped path does not turn off stop words
+    info, err := os.Stat(dir)
+    if err != nil {
+        return nil, err
+    }
+    if !info.IsDir() {
+        return nil, fmt.Errorf("textstats: %s is not a directory", dir)
+    }
+
+    paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+    if err != nil {
+        return nil, err
+    }
+
+    stopWords := StopWords{}
+    for _, path := range paths {
+        words, err := loadWords(path)
+        if err != nil {
+            return nil, err
+        }
+        lang := strings.ToLower(strings.TrimSuffix(filepath.Base(path), ".txt"))
+        stopWords[lang] = words
+    }
+
+    return stopWords, nil
+}
+
+func loadWords(path string) (map[string]bool, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    words := map[string]bool{}
+    scanner := bufio.NewScanner(f)
+    for scanner.Scan() {
+        word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+        if word != "" && !strings.HasPrefix(word, "#") {
+            words[word] = true
+        }
+    }
+
+    return words, scanner.Err()
+}
+
+// For returns stop words of the language. If language has no list
+// (or is empty), stop words of all languages are returned.
+func (s StopWords) For(lang string) map[string]bool {
+    base, _, _ := strings.Cut(strings.ToLower(lang), "-")
+    if words, ok := s[base]; ok {
+        return words
+    }
+
+    all := map[string]bool{}
+    for _, words := range s {
+        for word := range words {
+            all[word] = true
+        }
+    }
+    return all
+}
@@ -0,0 +1,192 @@
+package tracing
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "strconv"
+    "sync"
+)
+
+// OtlpExporter sends spans to the OTLP/HTTP traces endpoint
+// of the collector ("http://localhost:4318/v1/traces")
+// in JSON encoding
+type OtlpExporter struct {
+    endpoint string
+    client   *http.Client
+}
+
+func NewOtlpExporter(endpoint string) *OtlpExporter {
+    return &OtlpExporter{endpoint: endpoint, client: &http.Client{}}
+}
+
+func (e *OtlpExporter) Export(ctx context.Context, service string, spans []SpanData) error {
+    body, err := json.Marshal(encodeSpans(service, spans))
+    if err != nil {
+        return err
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+
+    resp, err := e.client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    io.Copy(io.Discard, resp.Body)
+
+    if resp.StatusCode < 200 || resp.StatusCode > 299 {
+        return fmt.Errorf("collector responded with status %d", resp.StatusCode)
+    }
+    return nil
+}
+
+// WriterExporter writes every batch of spans as a line
+// of OTLP JSON, the format of the collector file exporter
+type WriterExporter struct {
+    mu     sync.Mutex
+    w      io.Writer
+    closer io.Closer
+}
+
+// NewWriterExporter writes spans to w, w is not closed on shutdown
+func NewWriterExporter(w io.Writer) *WriterExporter {
+    return &WriterExporter{w: w}
+}
+
+// NewFileExporter appends spans to the file, it
+// is created if missing and closed on shutdown
+func NewFileExporter(path string) (*WriterExporter, error) {
+    f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+    if err != nil {
+        return nil, err
+    }
+    return &WriterExporter{w: f, closer: f}, nil
+}
+
+func (e *WriterExporter) Export(_ context.Context, service string, spans []SpanData) error {
+    line, err := json.Marshal(encodeSpans(service, spans))
+    if err != nil {
+        return err
+    }
+
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    _, err = e.w.Write(append(line, '\n'))
+    return err
+}
+
+func (e *WriterExporter) Close() error {
+    if e.closer == nil {
+        return nil
+    }
+    return e.closer.Close()
+}
+
+// OTLP JSON encoding of ExportTraceServiceRequest, ids
+// are hex and 64-bit integers are decimal strings
+type (
+    otlpRequest struct {
+        ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+    }
+    otlpResourceSpans struct {
+        Resource   otlpResource     `json:"resource"`
+        ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+    }
+    otlpResource struct {
+        Attributes []otlpKeyValue `json:"attributes"`
+    }
+    otlpScopeSpans struct {
+        Scope otlpScope  `json:"scope"`
+        Spans []otlpSpan `json:"spans"`
+    }
+    otlpScope struct {
+        Name string `json:"name"`
+    }
+    otlpSpan struct {
+        TraceId           string         `json:"traceId"`
+        SpanId            string         `json:"spanId"`
+        ParentSpanId      string         `json:"parentSpanId,omitempty"`
+        Name              string         `json:"name"`
+        Kind              SpanKind       `json:"kind"`
+        StartTimeUnixNano string         `json:"startTimeUnixNano"`
+        EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+        Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+        Status            otlpStatus     `json:"status"`
+    }
+    otlpStatus struct {
+        // 0 is unset, 2 is error
+        Code    int    `json:"code,omitempty"`
+        Message string `json:"message,omitempty"`
+    }
+    otlpKeyValue struct {
+        Key   string       `json:"key"`
+        Value otlpAnyValue `json:"value"`
+    }
+    otlpAnyValue struct {
+        StringValue *string  `json:"stringValue,omitempty"`
+        IntValue    *string  `json:"intValue,omitempty"`
+        DoubleValue *float64 `json:"doubleValue,omitempty"`
+        BoolValue   *bool    `json:"boolValue,omitempty"`
+    }
+)
+
+func encodeSpans(service string, spans []SpanData) otlpRequest {
+    encoded := make([]otlpSpan, len(spans))
+    for i, span := range spans {
+        encoded[i] = otlpSpan{
+            TraceId:           span.TraceId.String(),
+            SpanId:            span.SpanId.String(),
+            Name:              span.Name,
+            Kind:              span.Kind,
+            StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+            EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+            Attributes:        encodeAttributes(span.Attributes),
+        }
+        if span.Parent.IsValid() {
+            encoded[i].ParentSpanId = span.Parent.String()
+        }
+        if span.Error {
+            encoded[i].Status = otlpStatus{Code: 2, Message: span.StatusMessage}
+        }
+    }
+
+    return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+        Resource:   otlpResource{Attributes: encodeAttributes([]Attribute{String("service.name", service)})},
+        ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: service}, Spans: encoded}},
+    }}}
+}
+
+func encodeAttributes(attrs []Attribute) []otlpKeyValue {
+    encoded := make([]otlpKeyValue, 0, len(attrs))
+    for _, attr := range attrs {
+        var value otlpAnyValue
+        switch v := attr.Value.(type) {
+        case string:
+            value.StringValue = &v
+        case int:
+            s := strconv.Itoa(v)
+            value.IntValue = &s
+        case int64:
+            s := strconv.FormatInt(v, 10)
+            value.IntValue = &s
+        case float64:
+            value.DoubleValue = &v
+        case bool:
+            value.BoolValue = &v
+        default:
+            s := fmt.Sprint(v)
+            value.StringValue = &s
+        }
+        encoded = append(encoded, otlpKeyValue{Key: attr.Key, Value: value})
+    }
+    return encoded
+}
@@ -0,0 +1,429 @@
+// Package tracing records spans of the requests and their
+// database and external API calls, propagates the trace
+// with W3C traceparent header and exports finished spans
+// in OTLP JSON encoding.
+package tracing
+
+import (
+    "context"
+    "encoding/binary"
+    "encoding/hex"
+    "log/slog"
+    "math"
+    "math/rand/v2"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+)
+
+// TraceId is the id of the trace shared by all its spans
+type TraceId [16]byte
+
+func (id TraceId) String() string {
+    return hex.EncodeToString(id[:])
+}
+
+func (id TraceId) IsValid() bool {
+    return id != TraceId{}
+}
+
+// SpanId is the id of the span within the trace
+type SpanId [8]byte
+
+func (id SpanId) String() string {
+    return hex.EncodeToString(id[:])
+}
+
+func (id SpanId) IsValid() bool {
+    return id != SpanId{}
+}
+
+// SpanContext identifies the span across process boundaries
+type SpanContext struct {
+    TraceId TraceId
+    SpanId  SpanId
+    // Sampled spans are exported, the rest are only propagated
+    Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+    return sc.TraceId.IsValid() && sc.SpanId.IsValid()
+}
+
+// SpanKind is the role of the span in the trace
+type SpanKind int
+
+// Values are the ones of OTLP
+const (
+    KindInternal SpanKind = 1
+    KindServer   SpanKind = 2
+    KindClient   SpanKind = 3
+)
+
+// Attribute is the key and the value (string,
+// int, int64, float64 or bool) describing the span
+type Attribute struct {
+    Key   string
+    Value any
+}
+
+func String(key, value string) Attribute {
+    return Attribute{Key: key, Value: value}
+}
+
+func Int(key string, value int) Attribute {
+    return Attribute{Key: key, Value: value}
+}
+
+func Bool(key string, value bool) Attribute {
+    return Attribute{Key: key, Value: value}
+}
+
+// SpanData is the finished span passed to the exporter
+type SpanData struct {
+    SpanContext
+    Parent        SpanId
+    Name          string
+    Kind          SpanKind
+    Start         time.Time
+    End           time.Time
+    Attributes    []Attribute
+    Error         bool
+    StatusMessage string
+}
+
+// Span is the timed operation of the trace. Methods
+// of nil span do nothing, so callers need no checks.
+type Span struct {
+    tracer *Tracer
+    mu     sync.Mutex
+    data   SpanData
+    ended  bool
+}
+
+// SpanContext returns ids of the span, zero value for nil span
+func (s *Span) SpanContext() SpanContext {
+    if s == nil {
+        return SpanContext{}
+    }
+    return s.data.SpanContext
+}
+
+// SetAttributes adds the attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+    if s == nil || !s.data.Sampled {
+        return
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.data.Attributes = append(s.data.Attributes, attrs...)
+}
+
+// RecordError marks the span failed with the error, nil error is ignored
+func (s *Span) RecordError(err error) {
+    if s == nil || err == nil || !s.data.Sampled {
+        return
+    }
+    s.SetError(err.Error())
+}
+
+// SetError marks the span failed with the message
+func (s *Span) SetError(message string) {
+    if s == nil || !s.data.Sampled {
+        return
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.data.Error = true
+    s.data.StatusMessage = message
+}
+
+// End finishes the span and queues it for export,
+// calls after the first one are ignored
+func (s *Span) End() {
+    if s == nil || !s.data.Sampled {
+        return
+    }
+    s.mu.Lock()
+    if s.ended {
+        s.mu.Unlock()
+        return
+    }
+    s.ended = true
+    s.data.End = time.Now()
+    data := s.data
+    s.mu.Unlock()
+
+    s.tracer.enqueue(data)
+}
+
+type (
+    spanKey   struct{}
+    remoteKey struct{}
+)
+
+// SpanFromContext returns the active span of ctx, nil if it has none
+func SpanFromContext(ctx context.Context) *Span {
+    span, _ := ctx.Value(spanKey{}).(*Span)
+    return span
+}
+
+// SpanContextFromContext returns ids of the active span of ctx
+// or, if there is no span yet, of the remote parent of the request
+func SpanContextFromContext(ctx context.Context) SpanContext {
+    if span := SpanFromContext(ctx); span != nil {
+        return span.SpanContext()
+    }
+    sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+    return sc
+}
+
+// Start starts the child of the active span of ctx with the
+// tracer of its parent. Without the active span (commands and
+// background jobs) nothing is traced and the span is nil.
+func Start(ctx context.Context, name string, kind SpanKind, attrs ...Attribute) (context.Context, *Span) {
+    parent := SpanFromContext(ctx)
+    if parent == nil {
+        return ctx, nil
+    }
+    return parent.tracer.start(ctx, parent.data.SpanContext, name, kind, attrs)
+}
+
+// Tracer starts root spans of the requests and exports
+// finished spans in batches. Nil tracer traces nothing.
+type Tracer struct {
+    service  string
+    ratio    float64
+    exporter Exporter
+    queue    chan SpanData
+    done     chan struct{}
+    stopped  chan struct{}
+    once     sync.Once
+    log      *slog.Logger
+}
+
+// Exporter sends the batch of finished spans
+type Exporter interface {
+    Export(ctx context.Context, service string, spans []SpanData) error
+}
+
+const (
+    // Finished spans waiting for export, new ones are dropped when full
+    queueSize = 2048
+    // Spans sent at once and the longest wait before they are sent
+    batchSize     = 512
+    batchInterval = 5 * time.Second
+    exportTimeout = 10 * time.Second
+)
+
+// NewTracer returns the tracer of the service that samples the
+// ratio (0 to 1) of new traces and sends them to the exporter.
+// Requests with the sampled remote parent are always traced.
+func NewTracer(service string, ratio float64, exporter Exporter, logger *slog.Logger) *Tracer {
+    log := logger.With("component", "tracing")
+
+    t := &Tracer{
+        service:  service,
+        ratio:    ratio,
+        exporter: exporter,
+        queue:    make(chan SpanData, queueSize),
+        done:     make(chan struct{}),
+        stopped:  make(chan struct{}),
+        log:      log,
+    }
+    go t.run()
+    return t
+}
+
+// Start starts the span of the incoming request, it is the
+// child of the remote parent extracted to ctx if there is one
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, attrs ...Attribute) (context.Context, *Span) {
+    if t == nil {
+        return ctx, nil
+    }
+    parent := SpanContextFromContext(ctx)
+    return t.start(ctx, parent, name, kind, attrs)
+}
+
+func (t *Tracer) start(ctx context.Context, parent SpanContext, name string, kind SpanKind, attrs []Attribute) (context.Context, *Span) {
+    sc := SpanContext{TraceId: parent.TraceId, SpanId: newSpanId(), Sampled: parent.Sampled}
+    if !parent.IsValid() {
+        sc.TraceId = newTraceId()
+        sc.Sampled = t.sample(sc.TraceId)
+    }
+
+    span := &Span{
+        tracer: t,
+        data: SpanData{
+            SpanContext: sc,
+            Parent:      parent.SpanId,
+            Name:        name,
+            Kind:        kind,
+            Start:       time.Now(),
+            Attributes:  attrs,
+        },
+    }
+    return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// sample decides by the lower 8 bytes of the trace id,
+// so the decision is the same for the same trace
+func (t *Tracer) sample(id TraceId) bool {
+    if t.ratio >= 1 {
+        return true
+    }
+    if t.ratio <= 0 {
+        return false
+    }
+    bound := uint64(t.ratio * math.MaxUint64)
+    return binary.BigEndian.Uint64(id[8:]) < bound
+}
+
+func (t *Tracer) enqueue(data SpanData) {
+    select {
+    case t.queue <- data:
+    default:
+        t.log.Warn("Span queue is full, span dropped", slog.String("span", data.Name))
+    }
+}
+
+// run sends queued spans every batch interval or
+// once the batch is full until the tracer is shut down
+func (t *Tracer) run() {
+    defer close(t.stopped)
+
+    ticker := time.NewTicker(batchInterval)
+    defer ticker.Stop()
+
+    batch := make([]SpanData, 0, batchSize)
+    flush := func() {
+        if len(batch) == 0 {
+            return
+        }
+        ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+        defer cancel()
+        if err := t.exporter.Export(ctx, t.service, batch); err != nil {
+            t.log.Error("Failed to export spans",
+                slog.Int("spans", len(batch)),
+                slog.String("error", err.Error()),
+            )
+        }
+        batch = batch[:0]
+    }
+
+    for {
+        select {
+        case data := <-t.queue:
+            batch = append(batch, data)
+            if len(batch) == batchSize {
+                flush()
+            }
+        case <-ticker.C:
+            flush()
+        case <-t.done:
+            // Sending spans that are queued already
+            for {
+                select {
+                case data := <-t.queue:
+                    batch = append(batch, data)
+                    if len(batch) == batchSize {
+                        flush()
+                    }
+                default:
+                    flush()
+                    return
+                }
+            }
+        }
+    }
+}
+
+// Shutdown sends queued spans and closes the exporter. Spans
+// ended after the shutdown are dropped. It returns ctx error
+// if the spans are not sent before ctx is done.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+    if t == nil {
+        return nil
+    }
+    t.once.Do(func() { close(t.done) })
+
+    select {
+    case <-t.stopped:
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+
+    if closer, ok := t.exporter.(interface{ Close() error }); ok {
+        return closer.Close()
+    }
+    return nil
+}
+
+func newTraceId() TraceId {
+    var id TraceId
+    for !id.IsValid() {
+        binary.BigEndian.PutUint64(id[:8], rand.Uint64())
+        binary.BigEndian.PutUint64(id[8:], rand.Uint64())
+    }
+    return id
+}
+
+func newSpanId() SpanId {
+    var id SpanId
+    for !id.IsValid() {
+        binary.BigEndian.PutUint64(id[:], rand.Uint64())
+    }
+    return id
+}
+
+const traceparentHeader = "traceparent"
+
+// Extract returns ctx with the remote parent of the valid
+// traceparent header ("00-<trace id>-<parent id>-<flags>"),
+// ctx itself if the header is missing or malformed
+func Extract(ctx context.Context, header http.Header) context.Context {
+    parts := strings.Split(header.Get(traceparentHeader), "-")
+    if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+        return ctx
+    }
+    // Version 00 has exactly four parts, later versions may add more
+    if parts[0] == "00" && len(parts) != 4 {
+        return ctx
+    }
+
+    var sc SpanContext
+    var flags [1]byte
+    if !decodeHex(parts[1], sc.TraceId[:]) || !decodeHex(parts[2], sc.SpanId[:]) || !decodeHex(parts[3], flags[:]) {
+        return ctx
+    }
+    if !sc.IsValid() {
+        return ctx
+    }
+    sc.Sampled = flags[0]&1 == 1
+
+    return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Inject sets traceparent header of the active span of ctx,
+// the header is not set if ctx has no span
+func Inject(ctx context.Context, header http.Header) {
+    sc := SpanContextFromContext(ctx)
+    if !sc.IsValid() {
+        return
+    }
+    flags := "00"
+    if sc.Sampled {
+        flags = "01"
+    }
+    header.Set(traceparentHeader, "00-"+sc.TraceId.String()+"-"+sc.SpanId.String()+"-"+flags)
+}
+
+// decodeHex decodes lowercase hex of exactly the length of dst
+func decodeHex(s string, dst []byte) bool {
+    if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+        return false
+    }
+    _, err := hex.Decode(dst, []byte(s))
+    return err == nil
+}
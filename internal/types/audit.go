@@ -0,0 +1,39 @@
+package types
+
+import (
+    "encoding/json"
+    "time"
+)
+
+// Actions of the audit events
+const (
+    AuditCreate = "create"
+    AuditUpdate = "update"
+    AuditDelete = "delete"
+    AuditEnrich = "enrich"
+)
+
+// AuditEvent represents the recorded change of the song.
+// Before is null for created songs, after is null for
+// deleted ones, both are snapshots of the Song.
+type AuditEvent struct {
+    Id         int64           `json:"id"`
+    SongId     int             `json:"songId"`
+    Action     string          `json:"action"`
+    Actor      string          `json:"actor"`
+    RemoteAddr string          `json:"remoteAddr"`
+    RequestId  string          `json:"requestId"`
+    Before     json.RawMessage `json:"before"`
+    After      json.RawMessage `json:"after"`
+    CreatedAt  time.Time       `json:"createdAt"`
+}
+
+// GetAuditEvents represents data that uses for getting
+// audit events from storage. Nil fields are not filtered,
+// time range includes From and excludes To.
+type GetAuditEvents struct {
+    SongId *int
+    Actor  *string
+    From   *time.Time
+    To     *time.Time
+}
@@ -0,0 +1,63 @@
+package types
+
+import (
+    "fmt"
+    "slices"
+    "time"
+)
+
+// Scope is the access level of the caller. Scopes are ordered:
+// write allows everything read does, admin allows everything.
+type Scope string
+
+const (
+    ScopeRead  Scope = "read"
+    ScopeWrite Scope = "write"
+    ScopeAdmin Scope = "admin"
+)
+
+var scopeLevels = map[Scope]int{ScopeRead: 1, ScopeWrite: 2, ScopeAdmin: 3}
+
+// ParseScope validates the scope name
+func ParseScope(s string) (Scope, error) {
+    if _, ok := scopeLevels[Scope(s)]; !ok {
+        return "", fmt.Errorf("unknown scope %q (read, write, admin)", s)
+    }
+    return Scope(s), nil
+}
+
+// Allows reports whether the scope grants the required one
+func (s Scope) Allows(required Scope) bool {
+    return scopeLevels[s] >= scopeLevels[required]
+}
+
+// Principal is the authenticated caller of the request.
+type Principal struct {
+    // Subject identifies the caller ("apikey:3")
+    Subject string
+    Scopes  []Scope
+}
+
+// Allows reports whether any scope of the principal grants the required one
+func (p Principal) Allows(required Scope) bool {
+    return slices.ContainsFunc(p.Scopes, func(s Scope) bool {
+        return s.Allows(required)
+    })
+}
+
+// ApiKey represents the API key without its secret.
+type ApiKey struct {
+    Id         int        `json:"id"`
+    Name       string     `json:"name"`
+    Prefix     string     `json:"prefix"`
+    Scopes     []Scope    `json:"scopes"`
+    CreatedAt  time.Time  `json:"createdAt"`
+    LastUsedAt *time.Time `json:"lastUsedAt"`
+    RevokedAt  *time.Time `json:"revokedAt"`
+}
+
+// CreateApiKey represents data that uses for creation of API key.
+type CreateApiKey struct {
+    Name   string
+    Scopes []Scope
+}
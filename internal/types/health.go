@@ -0,0 +1,23 @@
+package types
+
+// Health statuses of the service and its dependencies
+const (
+    HealthUp       = "up"
+    HealthDown     = "down"
+    HealthDraining = "draining"
+)
+
+// HealthCheck is the result of checking the dependency
+type HealthCheck struct {
+    Status    string  `json:"status"`
+    LatencyMs float64 `json:"latencyMs"`
+    Error     string  `json:"error,omitempty"`
+}
+
+// Readiness is the status of the service with the
+// breakdown of its dependencies. The service is up
+// if all its dependencies are up and it is not draining.
+type Readiness struct {
+    Status string                 `json:"status"`
+    Checks map[string]HealthCheck `json:"checks"`
+}
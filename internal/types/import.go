@@ -0,0 +1,30 @@
+package types
+
+// ImportOptions represents the way songs file is imported.
+type ImportOptions struct {
+    // File format (csv / jsonl)
+    Format string
+    // CSV columns of the song fields
+    Mapping map[string]string
+    // Request missing text, link and release date from external API
+    Enrich     bool
+    OnConflict OnConflict
+}
+
+// ImportRejectedRow represents the row of the
+// songs file that was not imported and the reason.
+type ImportRejectedRow struct {
+    Row    int    `json:"row"`
+    Reason string `json:"reason"`
+    Record string `json:"record"`
+}
+
+// ImportResult represents result of the songs file import.
+type ImportResult struct {
+    Rows     int `json:"rows"`
+    Created  int `json:"created"`
+    Existing int `json:"existing"`
+    Updated  int `json:"updated"`
+
+    Rejected []ImportRejectedRow `json:"rejected"`
+}
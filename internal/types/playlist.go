@@ -0,0 +1,79 @@
+package types
+
+import "time"
+
+// Playlist represents the named ordered list of songs.
+type Playlist struct {
+    Id          int       `json:"id"`
+    Name        string    `json:"name"`
+    Description string    `json:"description"`
+    CreatedAt   time.Time `json:"createdAt"`
+    UpdatedAt   time.Time `json:"updatedAt"`
+    SongCount   int       `json:"songCount"`
+
+    // Entries are set only for the single playlist
+    Entries []PlaylistEntry `json:"entries,omitempty"`
+}
+
+// PlaylistEntry represents the song at the position of
+// the playlist. Entry of the deleted song is missing,
+// it has no song id but keeps the song name and group.
+type PlaylistEntry struct {
+    Id       int    `json:"id"`
+    Position int    `json:"position"`
+    SongId   *int   `json:"songId"`
+    Song     string `json:"song"`
+    Group    string `json:"group"`
+    Link     string `json:"link,omitempty"`
+    Missing  bool   `json:"missing"`
+}
+
+// CreatePlaylist represents data that uses for creation of playlist.
+type CreatePlaylist struct {
+    Name        string `json:"name"`
+    Description string `json:"description"`
+}
+
+// UpdatePlaylist represents data that uses for updating playlist.
+type UpdatePlaylist struct {
+    Name        *string `json:"name"`
+    Description *string `json:"description"`
+}
+
+// AddPlaylistEntries represents songs that are inserted
+// to the playlist at the position, or appended if it is not set.
+type AddPlaylistEntries struct {
+    SongIds  []int `json:"songIds"`
+    Position *int  `json:"position"`
+}
+
+// MovePlaylistEntry represents the new position of the entry.
+type MovePlaylistEntry struct {
+    Position int `json:"position"`
+}
+
+// ReorderPlaylist represents all entries of the playlist in new order.
+type ReorderPlaylist struct {
+    EntryIds []int `json:"entryIds"`
+}
+
+// PlaylistImportResult represents result of the playlist file import.
+type PlaylistImportResult struct {
+    Id      int    `json:"id"`
+    Name    string `json:"name"`
+    Entries int    `json:"entries"`
+    Matched int    `json:"matched"`
+
+    Unmatched []UnmatchedPlaylistEntry `json:"unmatched"`
+}
+
+// UnmatchedPlaylistEntry represents the entry of the playlist
+// file that did not match any song of the library.
+type UnmatchedPlaylistEntry struct {
+    // Position of the entry in the file
+    Position int    `json:"position"`
+    Title    string `json:"title"`
+    Artist   string `json:"artist"`
+    Location string `json:"location"`
+    Reason   string `json:"reason"`
+}
@@ -0,0 +1,28 @@
+package types
+
+// ScanItem represents the audio file of the scanned
+// directory and what was done with its song.
+type ScanItem struct {
+    Path  string `json:"path"`
+    Id    int    `json:"id,omitempty"`
+    Song  string `json:"song,omitempty"`
+    Group string `json:"group,omitempty"`
+    Album string `json:"album,omitempty"`
+    // Created, updated, unchanged, skipped or failed
+    Status string `json:"status"`
+    // Fields of the existing song that were changed
+    Changes []string `json:"changes,omitempty"`
+    Reason  string   `json:"reason,omitempty"`
+}
+
+// ScanResult represents the summary of the directory scan.
+type ScanResult struct {
+    DryRun    bool       `json:"dryRun"`
+    Files     int        `json:"files"`
+    Created   int        `json:"created"`
+    Updated   int        `json:"updated"`
+    Unchanged int        `json:"unchanged"`
+    Skipped   int        `json:"skipped"`
+    Failed    int        `json:"failed"`
+    Items     []ScanItem `json:"items"`
+}
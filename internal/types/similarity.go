@@ -0,0 +1,32 @@
+package types
+
+// SongWords represents word frequencies of the song
+// that are used for lyrics similarity.
+type SongWords struct {
+    Id            int
+    Song          string
+    Group         string
+    Language      *string
+    ReleaseDate   Date
+    WordFrequency map[string]int
+}
+
+// SimilarSong represents the song with
+// lyrics similarity score from 0 to 1.
+type SimilarSong struct {
+    Id          int     `json:"id"`
+    Song        string  `json:"song"`
+    Group       string  `json:"group"`
+    Language    *string `json:"language"`
+    ReleaseDate Date    `json:"releaseDate"`
+    Score       float64 `json:"score"`
+}
+
+// SimilarSongsFilter represents data that uses
+// for restricting similar songs.
+type SimilarSongsFilter struct {
+    Group       *string
+    Language    *string
+    ReleaseFrom *Date
+    ReleaseTo   *Date
+}
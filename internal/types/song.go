@@ -2,12 +2,16 @@ package types
 
 // Song is the model that represents storing of song
 type Song struct {
-    Id          int    `json:"id"`
-    Song        string `json:"song"`
-    Group       string `json:"group"`
-    Text        string `json:"text"`
-    Link        string `json:"link"`
-    ReleaseDate Date   `json:"releaseDate"`
+    Id                 int      `json:"id"`
+    Song               string   `json:"song"`
+    Group              string   `json:"group"`
+    Text               string   `json:"text"`
+    Link               string   `json:"link"`
+    ReleaseDate        Date     `json:"releaseDate"`
+    Language           *string  `json:"language"`
+    LanguageConfidence *float64 `json:"languageConfidence"`
+    Explicit           bool     `json:"explicit"`
+    ExplicitVerses     []int    `json:"explicitVerses"`
 }
 
 // GetSongs represents data that uses
@@ -19,6 +23,11 @@ type GetSongs struct {
     Text        *string `json:"text"`
     Link        *string `json:"link"`
     ReleaseDate *Date   `json:"releaseDate"`
+    Language    *string `json:"language"`
+    Explicit    *bool   `json:"explicit"`
+
+    // Every group must match, tags of the group are alternatives
+    Tags [][]Tag `json:"tags"`
 }
 
 // CreateSong represents data that uses
@@ -27,6 +36,10 @@ type CreateSong struct {
     Song  string `json:"song"`
     Group string `json:"group"`
     SongDetail
+    Analysis SongAnalysis `json:"-"`
+    // Details are known (scanned files) and
+    // are not requested from external API
+    Detailed bool `json:"-"`
 }
 
 // SongDetail represents data that gets
@@ -40,9 +53,151 @@ type SongDetail struct {
 // UpdateSong represents data that uses
 // for updating song in the storage.
 type UpdateSong struct {
-    Song        *string `json:"song"`
-    Group       *string `json:"group"`
-    Text        *string `json:"text"`
-    Link        *string `json:"link"`
-    ReleaseDate *Date   `json:"releaseDate"`
+    Song         *string `json:"song"`
+    Group        *string `json:"group"`
+    Text         *string `json:"text"`
+    Link         *string `json:"link"`
+    ReleaseDate  *Date   `json:"releaseDate"`
+    SyncedLyrics *string `json:"syncedLyrics"`
+    ChordSheet   *string `json:"chordSheet"`
+
+    // Analysis is set by service when text changes
+    Analysis *SongAnalysis `json:"-"`
+}
+
+// SongAnalysis represents data that is computed
+// from the song text on every write.
+type SongAnalysis struct {
+    Language           *string
+    LanguageConfidence *float64
+    Explicit           bool
+    // 1-based indices of verses with explicit words
+    ExplicitVerses []int
+    Stats          SongStats
+}
+
+// SetExplicitOverride represents data that uses
+// for manual setting of the explicit flag.
+// Null Explicit resets to the scanned value.
+type SetExplicitOverride struct {
+    Explicit *bool `json:"explicit"`
+}
+
+// SetSyncedLyrics represents data that uses
+// for attaching time-synced (LRC) lyrics to the song.
+// If SyncText is set, plain text of the song
+// is replaced with text of the timed lines.
+// Empty LRC removes synced lyrics.
+type SetSyncedLyrics struct {
+    LRC      string `json:"lrc"`
+    SyncText bool   `json:"syncText"`
+}
+
+// SetChordSheet represents data that uses
+// for attaching ChordPro chord sheet to the song.
+// Empty ChordPro removes the chord sheet.
+type SetChordSheet struct {
+    ChordPro string `json:"chordPro"`
+}
+
+// ChordSheetOptions represents options
+// of the chord sheet rendering.
+type ChordSheetOptions struct {
+    // Output format (chordpro / text / html / json)
+    Format string
+    // Semitones to transpose by, may be negative
+    Transpose int
+    // Spell accidentals with flats instead of sharps
+    Flats bool
+    // 1-based section to render, 0 for the whole sheet
+    Page int
+}
+
+// SongTranslation represents translation of the song text.
+// Transliterations are stored as translations with
+// script subtag in the language tag (for example "ru-Latn").
+type SongTranslation struct {
+    SongId int    `json:"songId"`
+    Lang   string `json:"lang"`
+    Text   string `json:"text"`
+}
+
+// SetSongTranslation represents data that uses
+// for creating or replacing song translation.
+type SetSongTranslation struct {
+    Text string `json:"text"`
+}
+
+// SongVerse represents verse of the song text
+// aligned with the verse of its translation.
+type SongVerse struct {
+    Id          int     `json:"id"`
+    Page        int     `json:"page"`
+    Verse       string  `json:"verse"`
+    Lang        string  `json:"lang,omitempty"`
+    Translation *string `json:"translation,omitempty"`
+    Warning     string  `json:"warning,omitempty"`
+}
+
+// OnConflict is the way to resolve creation of the song
+// with the same group and name as the existing one.
+type OnConflict string
+
+const (
+    OnConflictError  OnConflict = "error"
+    OnConflictIgnore OnConflict = "ignore"
+    OnConflictUpdate OnConflict = "update"
+)
+
+// Statuses of the song creation
+const (
+    SongCreated  = "created"
+    SongExisting = "existing"
+    SongUpdated  = "updated"
+    // Existing song that has nothing to update
+    SongUnchanged = "unchanged"
+    // Song of the batch that failed
+    SongFailed = "failed"
+    // Song of the atomic batch that was not created
+    // because another song of the batch failed
+    SongSkipped = "skipped"
+)
+
+// CreateSongResult represents result of the song creation.
+type CreateSongResult struct {
+    Id     int    `json:"id"`
+    Status string `json:"status"`
+    // Ids of the existing songs that look like duplicates
+    Duplicates []int  `json:"duplicates,omitempty"`
+    Warning    string `json:"warning,omitempty"`
+}
+
+// BatchItemResult represents result of the song
+// creation in a batch, index is the position in the batch.
+type BatchItemResult struct {
+    Index      int    `json:"index"`
+    Id         int    `json:"id,omitempty"`
+    Status     string `json:"status"`
+    Duplicates []int  `json:"duplicates,omitempty"`
+    Warning    string `json:"warning,omitempty"`
+    Error      string `json:"error,omitempty"`
+}
+
+// DuplicateSong represents the song of duplicates report.
+type DuplicateSong struct {
+    Id    int    `json:"id"`
+    Song  string `json:"song"`
+    Group string `json:"group"`
+}
+
+// DuplicateGroup represents songs that are
+// most likely the same track entered several times.
+type DuplicateGroup struct {
+    Songs []DuplicateSong `json:"songs"`
+}
+
+// MergeSongs represents data that uses for folding
+// the source song into another one.
+type MergeSongs struct {
+    SourceId int `json:"sourceId"`
 }
@@ -0,0 +1,49 @@
+package types
+
+// SongStats represents word statistics
+// that are precomputed from the song text.
+type SongStats struct {
+    WordCount      int            `json:"wordCount"`
+    UniqueWords    int            `json:"uniqueWords"`
+    VerseCount     int            `json:"verseCount"`
+    AvgVerseLength float64        `json:"avgVerseLength"`
+    WordFrequency  map[string]int `json:"-"`
+}
+
+// SongStatsEntry represents statistics of the single song.
+type SongStatsEntry struct {
+    Id    int    `json:"id"`
+    Song  string `json:"song"`
+    Group string `json:"group"`
+    SongStats
+}
+
+// LibraryStats represents summary statistics
+// of the songs that match the filter.
+type LibraryStats struct {
+    Songs           int     `json:"songs"`
+    Words           int     `json:"words"`
+    UniqueWords     int     `json:"uniqueWords"`
+    AvgWordsPerSong float64 `json:"avgWordsPerSong"`
+    AvgVerseLength  float64 `json:"avgVerseLength"`
+}
+
+// WordCount represents number of uses of the word.
+type WordCount struct {
+    Word  string `json:"word"`
+    Count int    `json:"count"`
+}
+
+// ArtistTopWords represents the most used words of the artist.
+type ArtistTopWords struct {
+    Group string      `json:"group"`
+    Words []WordCount `json:"words"`
+}
+
+// VocabularyYear represents vocabulary growth in the release year:
+// words first used in the year and total words used up to it.
+type VocabularyYear struct {
+    Year     int `json:"year"`
+    NewWords int `json:"newWords"`
+    Total    int `json:"total"`
+}
@@ -0,0 +1,107 @@
+package types
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "unicode/utf8"
+)
+
+// Kinds of the song tags
+const (
+    TagKindGenre = "genre"
+    TagKindMood  = "mood"
+    TagKindTag   = "tag"
+)
+
+// Max length of the tag name column
+const maxTagName = 64
+
+// Tag is the genre, mood or custom tag of songs. It is written
+// as "genre:rock" or "mood:calm", custom tags have no kind ("live").
+type Tag struct {
+    Kind string
+    Name string
+}
+
+// ParseTag parses and normalizes the tag, names are
+// lowercased and may not contain "," and "|"
+func ParseTag(s string) (Tag, error) {
+    tag := Tag{Kind: TagKindTag, Name: s}
+    if kind, name, ok := strings.Cut(s, ":"); ok {
+        tag.Kind, tag.Name = strings.ToLower(strings.TrimSpace(kind)), name
+    }
+    tag.Name = strings.ToLower(strings.Join(strings.Fields(tag.Name), " "))
+
+    switch tag.Kind {
+    case TagKindGenre, TagKindMood, TagKindTag:
+    default:
+        return Tag{}, fmt.Errorf("unknown tag kind %q", tag.Kind)
+    }
+    if tag.Name == "" {
+        return Tag{}, errors.New("tag name is empty")
+    }
+    if utf8.RuneCountInString(tag.Name) > maxTagName {
+        return Tag{}, fmt.Errorf("tag name is longer than %d characters", maxTagName)
+    }
+    if strings.ContainsAny(tag.Name, ",|") {
+        return Tag{}, fmt.Errorf("tag name %q contains \",\" or \"|\"", tag.Name)
+    }
+    return tag, nil
+}
+
+// ParseTagGroup parses "genre:rock|genre:metal" tag alternatives
+func ParseTagGroup(s string) ([]Tag, error) {
+    var tags []Tag
+    for _, value := range strings.Split(s, "|") {
+        tag, err := ParseTag(value)
+        if err != nil {
+            return nil, err
+        }
+        tags = append(tags, tag)
+    }
+    return tags, nil
+}
+
+func (t Tag) String() string {
+    if t.Kind == TagKindTag {
+        return t.Name
+    }
+    return t.Kind + ":" + t.Name
+}
+
+// Key is the tag as stored in the song filter, kind is always set
+func (t Tag) Key() string {
+    return t.Kind + ":" + t.Name
+}
+
+func (t Tag) MarshalText() ([]byte, error) {
+    return []byte(t.String()), nil
+}
+
+func (t *Tag) UnmarshalText(b []byte) error {
+    tag, err := ParseTag(string(b))
+    if err != nil {
+        return err
+    }
+    *t = tag
+    return nil
+}
+
+// TagCount represents number of songs with the tag.
+type TagCount struct {
+    Tag   Tag `json:"tag"`
+    Count int `json:"count"`
+}
+
+// TagFacets represents tag counts of the songs
+// that match the filter, grouped by tag kind.
+type TagFacets struct {
+    Songs  int                   `json:"songs"`
+    Facets map[string][]TagCount `json:"facets"`
+}
+
+// SetSongTags represents tags that are added to the song.
+type SetSongTags struct {
+    Tags []Tag `json:"tags"`
+}
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table song add column if not exists "synced_lyrics" text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table song drop column "synced_lyrics";
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table song add column if not exists "chord_sheet" text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table song drop column "chord_sheet";
+-- +goose StatementEnd
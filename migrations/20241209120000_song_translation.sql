@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists song_translation (
+    "song_id" integer not null references song ("id") on delete cascade,
+    "lang" varchar(35) not null,
+    "text" text not null,
+    "updated_at" timestamptz not null default now(),
+    primary key ("song_id", "lang")
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table song_translation;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table song add column if not exists "language" varchar(35);
+alter table song add column if not exists "language_confidence" real;
+create index if not exists song_language_idx on song ("language");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index song_language_idx;
+alter table song drop column "language_confidence";
+alter table song drop column "language";
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table song add column if not exists "explicit" boolean not null default false;
+alter table song add column if not exists "explicit_verses" integer[] not null default '{}';
+alter table song add column if not exists "explicit_override" boolean;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table song drop column "explicit_override";
+alter table song drop column "explicit_verses";
+alter table song drop column "explicit";
+-- +goose StatementEnd
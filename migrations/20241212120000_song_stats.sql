@@ -0,0 +1,21 @@
+-- +goose Up
+-- Statistics are computed by the text analysis of the service,
+-- songs created before are backfilled on start. Unique words and
+-- word frequencies exclude stop words, so unique_words is the
+-- number of word_freq keys.
+-- +goose StatementBegin
+alter table song add column if not exists "word_count" integer;
+alter table song add column if not exists "unique_words" integer;
+alter table song add column if not exists "verse_count" integer;
+alter table song add column if not exists "avg_verse_length" real;
+alter table song add column if not exists "word_freq" jsonb;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table song drop column "word_freq";
+alter table song drop column "avg_verse_length";
+alter table song drop column "verse_count";
+alter table song drop column "unique_words";
+alter table song drop column "word_count";
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- Existing duplicates must be merged (GET /songs/duplicates,
+-- POST /songs/{id}/merge) before this migration can be applied,
+-- it fails listing the colliding song ids otherwise.
+-- +goose StatementBegin
+do $$
+declare
+    collisions text;
+begin
+    select string_agg(ids, '; ') into collisions
+    from (
+        select string_agg("id"::text, ', ' order by "id") as ids
+        from song
+        group by lower(btrim("group")), lower(btrim("name"))
+        having count(*) > 1
+    ) d;
+
+    if collisions is not null then
+        raise exception 'songs repeat group and name, merge them first: %', collisions;
+    end if;
+end $$;
+
+create unique index if not exists song_group_name_uniq
+    on song (lower(btrim("group")), lower(btrim("name")));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index song_group_name_uniq;
+-- +goose StatementEnd
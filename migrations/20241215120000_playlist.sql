@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists playlist (
+    "id" serial primary key,
+    "name" varchar(255) not null,
+    "description" text not null default '',
+    "created_at" timestamptz not null default now(),
+    "updated_at" timestamptz not null default now()
+);
+
+-- Song of the deleted entry is null, its name and group are kept
+create table if not exists playlist_entry (
+    "id" serial primary key,
+    "playlist_id" integer not null references playlist ("id") on delete cascade,
+    "position" integer not null,
+    "song_id" integer references song ("id") on delete set null,
+    "song_name" varchar(255),
+    "song_group" varchar(255),
+    constraint playlist_entry_position_uniq unique ("playlist_id", "position") deferrable initially deferred
+);
+
+create index if not exists playlist_entry_song_idx on playlist_entry ("song_id");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table playlist_entry;
+drop table playlist;
+-- +goose StatementEnd
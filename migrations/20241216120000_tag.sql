@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Kind is genre, mood or tag, names are lowercased
+create table if not exists tag (
+    "id" serial primary key,
+    "kind" varchar(16) not null,
+    "name" varchar(64) not null,
+    constraint tag_kind_name_uniq unique ("kind", "name")
+);
+
+create table if not exists song_tag (
+    "song_id" integer not null references song ("id") on delete cascade,
+    "tag_id" integer not null references tag ("id") on delete cascade,
+    primary key ("song_id", "tag_id")
+);
+
+create index if not exists song_tag_tag_idx on song_tag ("tag_id");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table song_tag;
+drop table tag;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Only SHA-256 of the key is stored, prefix identifies the key in lists
+create table if not exists api_key (
+    "id" serial primary key,
+    "name" varchar(255) not null,
+    "prefix" varchar(16) not null,
+    "hash" char(64) not null,
+    "scopes" text[] not null,
+    "created_at" timestamptz not null default now(),
+    "last_used_at" timestamptz,
+    "revoked_at" timestamptz,
+    constraint api_key_hash_uniq unique ("hash")
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table api_key;
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Events outlive their songs, so song id is not a foreign key.
+-- Before is null for created songs, after is null for deleted ones.
+create table if not exists audit_event (
+    "id" bigserial primary key,
+    "song_id" integer not null,
+    "action" varchar(16) not null,
+    "actor" varchar(255) not null,
+    "remote_addr" varchar(255) not null,
+    "request_id" varchar(255) not null,
+    "before" jsonb,
+    "after" jsonb,
+    "created_at" timestamptz not null default now()
+);
+
+create index if not exists audit_event_song_idx on audit_event ("song_id", "created_at");
+create index if not exists audit_event_actor_idx on audit_event ("actor", "created_at");
+create index if not exists audit_event_created_at_idx on audit_event ("created_at");
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Events are append-only: updates and truncation fail. Rows are
+-- deleted only by the retention job (PruneAuditEvents) with
+-- DELETE of the events older than the retention period,
+-- no other code of the service deletes them.
+create or replace function audit_event_append_only() returns trigger as $$
+begin
+    raise exception 'audit_event is append-only';
+end;
+$$ language plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+create trigger audit_event_no_update before update on audit_event
+    for each row execute function audit_event_append_only();
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+create trigger audit_event_no_truncate before truncate on audit_event
+    for each statement execute function audit_event_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table audit_event;
+drop function audit_event_append_only;
+-- +goose StatementEnd